@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// methods are the HTTP methods in the order a path's operations are generated in
+var methods = []string{"get", "post", "put", "patch", "delete"}
+
+// initialisms are the words spelled in capitals in Go names
+var initialisms = map[string]string{
+	"id":      "ID",
+	"ip":      "IP",
+	"url":     "URL",
+	"urls":    "URLs",
+	"ws":      "WS",
+	"ice":     "ICE",
+	"e2ee":    "E2EE",
+	"api":     "API",
+	"livekit": "LiveKit",
+}
+
+type generator struct {
+	doc     *document
+	body    bytes.Buffer
+	imports map[string]bool
+}
+
+// generate returns the Go source of the client's types and methods
+func generate(spec []byte, pkg string) ([]byte, error) {
+	g := &generator{doc: &document{}, imports: map[string]bool{}}
+	if err := json.Unmarshal(spec, g.doc); err != nil {
+		return nil, fmt.Errorf("decode spec: %w", err)
+	}
+	if len(g.doc.Servers) != 1 {
+		return nil, fmt.Errorf("want exactly one server, got %d", len(g.doc.Servers))
+	}
+
+	g.printf("// Version is the version of the API document the client was generated from\n")
+	g.printf("const Version = %q\n\n", g.doc.Info.Version)
+	g.printf("// basePath is where the API is served, relative to the base URL\n")
+	g.printf("const basePath = %q\n\n", g.doc.Servers[0].URL)
+
+	names := make([]string, 0, len(g.doc.Components.Schemas))
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := g.schemaType(name, g.doc.Components.Schemas[name]); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	paths := make([]string, 0, len(g.doc.Paths))
+	for path := range g.doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, method := range methods {
+			raw, ok := g.doc.Paths[path][method]
+			if !ok {
+				continue
+			}
+			var op operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			if err := g.operation(method, path, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by apigen from the OpenAPI document; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	imports := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	fmt.Fprintf(&out, "import (\n")
+	for _, path := range imports {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	fmt.Fprintf(&out, ")\n\n")
+	out.Write(g.body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+// schemaType declares the Go type of a component schema
+func (g *generator) schemaType(name string, s *schema) error {
+	if s.Type != "object" || s.Properties == nil {
+		return fmt.Errorf("only objects with properties are supported")
+	}
+
+	comment(&g.body, name, s.Description)
+	g.printf("type %s struct {\n", name)
+	for _, prop := range s.Properties.names {
+		ps := s.Properties.schemas[prop]
+		required := slices.Contains(s.Required, prop)
+		typ, err := g.goType(ps, required)
+		if err != nil {
+			return fmt.Errorf("property %s: %w", prop, err)
+		}
+		tag := prop
+		if !required {
+			tag += ",omitempty"
+		}
+		g.printf("\t%s %s `json:%q`", goName(prop), typ, tag)
+		if ps.Description != "" {
+			g.printf(" // %s", ps.Description)
+		}
+		g.printf("\n")
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// goType returns the Go type of a property. Optional references and times are pointers so
+// they can be left out; other optional values are left out when zero.
+func (g *generator) goType(s *schema, required bool) (string, error) {
+	pointer := s.Nullable || !required
+	switch {
+	case s.Ref != "":
+		if pointer {
+			return "*" + refName(s.Ref), nil
+		}
+		return refName(s.Ref), nil
+	case s.Type == "string" && s.Format == "date-time":
+		g.imports["time"] = true
+		if pointer {
+			return "*time.Time", nil
+		}
+		return "time.Time", nil
+	case s.Type == "string", s.Type == "integer", s.Type == "boolean":
+		typ := map[string]string{"string": "string", "integer": "int", "boolean": "bool"}[s.Type]
+		if s.Nullable {
+			return "*" + typ, nil
+		}
+		return typ, nil
+	case s.Type == "array":
+		item, err := g.goType(s.Items, true)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case s.Type == "object" && s.AdditionalProperties != nil:
+		value, err := g.goType(s.AdditionalProperties, true)
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + value, nil
+	case s.Type == "":
+		// Any JSON value
+		g.imports["encoding/json"] = true
+		return "json.RawMessage", nil
+	}
+	return "", fmt.Errorf("unsupported schema type %q", s.Type)
+}
+
+// operation generates the method of an operation, and the struct of its query parameters
+// if it has any. Operations that answer neither JSON nor no content, like event streams
+// and exports, are left out.
+func (g *generator) operation(method, path string, op *operation) error {
+	if op.OperationID == "" {
+		return fmt.Errorf("no operationId")
+	}
+	name := exported(op.OperationID)
+
+	result, hasResult, ok, err := g.result(op)
+	if err != nil || !ok {
+		return err
+	}
+
+	var pathParams, queryParams []*parameter
+	for _, p := range op.Parameters {
+		p, err := g.doc.resolve(p)
+		if err != nil {
+			return err
+		}
+		switch p.In {
+		case "path":
+			pathParams = append(pathParams, p)
+		case "query":
+			queryParams = append(queryParams, p)
+		}
+	}
+
+	paramsType := name + "Params"
+	if len(queryParams) > 0 {
+		if err := g.paramsType(paramsType, name, queryParams); err != nil {
+			return err
+		}
+	}
+
+	args := []string{"ctx context.Context"}
+	g.imports["context"] = true
+	for _, p := range pathParams {
+		args = append(args, p.Name+" string")
+	}
+	bodyArg := "nil"
+	if op.RequestBody != nil {
+		media, ok := op.RequestBody.Content["application/json"]
+		if !ok || media.Schema == nil || media.Schema.Ref == "" {
+			return fmt.Errorf("request body must reference a schema")
+		}
+		args = append(args, "body "+refName(media.Schema.Ref))
+		bodyArg = "body"
+	}
+	if len(queryParams) > 0 {
+		args = append(args, "params *"+paramsType)
+	}
+
+	g.printf("// %s: %s (%s %s).\n", name, op.Summary, strings.ToUpper(method), path)
+	if hasResult {
+		g.printf("func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(args, ", "), result)
+	} else {
+		g.printf("func (c *Client) %s(%s) error {\n", name, strings.Join(args, ", "))
+	}
+
+	queryArg := "nil"
+	if len(queryParams) > 0 {
+		g.imports["net/url"] = true
+		queryArg = "query"
+		g.printf("\tquery := url.Values{}\n")
+		g.printf("\tif params != nil {\n")
+		for _, p := range queryParams {
+			g.queryValue(p)
+		}
+		g.printf("\t}\n")
+	}
+
+	g.imports["net/http"] = true
+	call := fmt.Sprintf("c.do(ctx, http.Method%s, %s, %s, %s", exported(method), g.pathExpr(path), queryArg, bodyArg)
+	switch {
+	case !hasResult:
+		g.printf("\treturn %s, nil)\n", call)
+	case strings.HasPrefix(result, "*"):
+		g.printf("\tvar out %s\n", result[1:])
+		g.printf("\tif err := %s, &out); err != nil {\n\t\treturn nil, err\n\t}\n", call)
+		g.printf("\treturn &out, nil\n")
+	default:
+		g.printf("\tvar out %s\n", result)
+		g.printf("\tif err := %s, &out); err != nil {\n\t\treturn nil, err\n\t}\n", call)
+		g.printf("\treturn out, nil\n")
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// result returns the Go type of the operation's successful response. ok is false when the
+// operation answers something other than JSON or no content.
+func (g *generator) result(op *operation) (result string, hasResult, ok bool, err error) {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) != 1 {
+		return "", false, false, fmt.Errorf("want exactly one success response, got %d", len(codes))
+	}
+
+	response := op.Responses[codes[0]]
+	if len(response.Content) == 0 {
+		return "", false, true, nil
+	}
+	media, isJSON := response.Content["application/json"]
+	if !isJSON {
+		return "", false, false, nil
+	}
+
+	switch s := media.Schema; {
+	case s == nil:
+		g.imports["encoding/json"] = true
+		return "json.RawMessage", true, true, nil
+	case s.Ref != "":
+		return "*" + refName(s.Ref), true, true, nil
+	default:
+		typ, err := g.goType(s, true)
+		return typ, true, true, err
+	}
+}
+
+// paramsType declares the struct of an operation's query parameters
+func (g *generator) paramsType(typeName, method string, params []*parameter) error {
+	g.printf("// %s are the query parameters of %s\n", typeName, method)
+	g.printf("type %s struct {\n", typeName)
+	for _, p := range params {
+		typ, err := queryType(p.Schema)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		if typ == "*time.Time" {
+			g.imports["time"] = true
+		}
+		g.printf("\t%s %s", goName(p.Name), typ)
+		if p.Description != "" {
+			g.printf(" // %s", p.Description)
+		}
+		g.printf("\n")
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// queryType returns the Go type of a query parameter. Parameters are left out when zero, so
+// booleans and times are pointers.
+func queryType(s *schema) (string, error) {
+	switch {
+	case s.Type == "string" && s.Format == "date-time":
+		return "*time.Time", nil
+	case s.Type == "string":
+		return "string", nil
+	case s.Type == "integer":
+		return "int", nil
+	case s.Type == "boolean":
+		return "*bool", nil
+	}
+	return "", fmt.Errorf("unsupported query parameter type %q", s.Type)
+}
+
+func (g *generator) queryValue(p *parameter) {
+	field := "params." + goName(p.Name)
+	typ, _ := queryType(p.Schema)
+	switch typ {
+	case "*time.Time":
+		g.printf("\t\tif %s != nil {\n\t\t\tquery.Set(%q, %s.Format(time.RFC3339Nano))\n\t\t}\n", field, p.Name, field)
+	case "string":
+		g.printf("\t\tif %s != \"\" {\n\t\t\tquery.Set(%q, %s)\n\t\t}\n", field, p.Name, field)
+	case "int":
+		g.imports["strconv"] = true
+		g.printf("\t\tif %s != 0 {\n\t\t\tquery.Set(%q, strconv.Itoa(%s))\n\t\t}\n", field, p.Name, field)
+	case "*bool":
+		g.imports["strconv"] = true
+		g.printf("\t\tif %s != nil {\n\t\t\tquery.Set(%q, strconv.FormatBool(*%s))\n\t\t}\n", field, p.Name, field)
+	}
+}
+
+// pathExpr returns the Go expression of a path with its parameters escaped into it
+func (g *generator) pathExpr(path string) string {
+	var parts []string
+	rest := path
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest, "}")
+		if rest[:start] != "" {
+			parts = append(parts, fmt.Sprintf("%q", rest[:start]))
+		}
+		g.imports["net/url"] = true
+		parts = append(parts, "url.PathEscape("+rest[start+1:end]+")")
+		rest = rest[end+1:]
+	}
+	if rest != "" {
+		parts = append(parts, fmt.Sprintf("%q", rest))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// comment writes the doc comment of a declaration
+func comment(buf *bytes.Buffer, name, description string) {
+	if description != "" {
+		fmt.Fprintf(buf, "// %s: %s\n", name, description)
+	}
+}
+
+// goName turns a snake_case or camelCase name into an exported Go name
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if initialism, ok := initialisms[strings.ToLower(word)]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		b.WriteString(exported(word))
+	}
+	return b.String()
+}
+
+func exported(name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestClientIsUpToDate fails when the OpenAPI document changed without the client being regenerated
+func TestClientIsUpToDate(t *testing.T) {
+	spec, err := os.ReadFile("../../internal/openapi/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := generate(spec, "apiclient")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	got, err := os.ReadFile("../../pkg/apiclient/client_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("pkg/apiclient/client_gen.go is out of date; run go generate ./pkg/apiclient")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for name, want := range map[string]string{
+		"room_id":           "RoomID",
+		"livekit_room_name": "LiveKitRoomName",
+		"urls":              "URLs",
+		"ws_limits":         "WSLimits",
+		"next_cursor":       "NextCursor",
+	} {
+		if got := goName(name); got != want {
+			t.Errorf("goName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Command apigen generates the Go client in pkg/apiclient from the OpenAPI document.
+// It understands the subset of OpenAPI 3 the document uses.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+)
+
+func main() {
+	specPath := flag.String("spec", "internal/openapi/openapi.json", "OpenAPI document to read")
+	out := flag.String("out", "pkg/apiclient/client_gen.go", "Go file to write")
+	pkg := flag.String("package", "apiclient", "package of the generated file")
+	flag.Parse()
+
+	spec, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatal("Failed to read spec: ", err)
+	}
+
+	src, err := generate(spec, *pkg)
+	if err != nil {
+		log.Fatal("Failed to generate client: ", err)
+	}
+
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal("Failed to write client: ", err)
+	}
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"log"
+	"log/slog"
 	"nonza/backend/internal/app"
 	"nonza/backend/internal/config"
+	"nonza/backend/internal/logger"
+	"os"
 
 	_ "github.com/lib/pq"
 )
@@ -14,7 +17,11 @@ func main() {
 		log.Fatal("Failed to init config: ", err)
 	}
 
-	if err := app.Run(cfg); err != nil {
-		log.Fatal("Failed to run app: ", err)
+	appLogger := logger.New(cfg.Env, cfg.Debug)
+	slog.SetDefault(appLogger)
+
+	if err := app.Run(cfg, appLogger); err != nil {
+		appLogger.Error("Failed to run app", slog.Any("error", err))
+		os.Exit(1)
 	}
 }
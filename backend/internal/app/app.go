@@ -3,29 +3,62 @@ package app
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"nonza/backend/internal/config"
+	"nonza/backend/internal/health"
+	"nonza/backend/internal/metrics"
 	"nonza/backend/internal/migrations"
 	"nonza/backend/internal/repository"
 	"nonza/backend/internal/repository/postgresDB"
 	"nonza/backend/internal/repository/redis"
 	"nonza/backend/internal/service"
+	"nonza/backend/internal/service/domainevents"
+	"nonza/backend/internal/service/jobs"
+	"nonza/backend/internal/service/roomevents"
+	"nonza/backend/internal/service/rooms"
+	"nonza/backend/internal/service/webhooks"
+	"nonza/backend/internal/tracing"
 	"nonza/backend/internal/transport/rest"
+	"nonza/backend/internal/transport/websocket"
+	"nonza/backend/internal/webrtc/livekit"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
-
-	"github.com/robfig/cron/v3"
 )
 
-func Run(cfg *config.Config) error {
-	logger := log.New(os.Stdout, "[nonza] ", log.LstdFlags)
+func Run(cfg *config.Config, log *slog.Logger) error {
+	// ctx is the parent of request and hub work; it is cancelled once the HTTP server has drained
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		Endpoint:    cfg.Tracing.OTLPEndpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		Environment: cfg.Env,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to init tracing: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("Failed to flush traces", slog.Any("error", err))
+		}
+	}()
+	if cfg.Tracing.Enabled {
+		log.Info("Tracing enabled", slog.String("otlp_endpoint", cfg.Tracing.OTLPEndpoint))
+	}
+
+	log.Info("Initializing database connection",
+		slog.String("host", cfg.DB.Host), slog.String("port", cfg.DB.Port),
+		slog.String("user", cfg.DB.Username), slog.String("dbname", cfg.DB.DBName))
 
-	logger.Printf("Initializing database connection with config: host=%s, port=%s, user=%s, dbname=%s",
-		cfg.DB.Host, cfg.DB.Port, cfg.DB.Username, cfg.DB.DBName)
-	
 	db, err := postgresDB.NewPostgresDB(postgresDB.Config{
 		Host:          cfg.DB.Host,
 		Port:          cfg.DB.Port,
@@ -35,15 +68,15 @@ func Run(cfg *config.Config) error {
 		SSLMode:       cfg.DB.SSLMode,
 		Environment:   cfg.Env,
 		SlowThreshold: 200 * time.Millisecond,
+		Logger:        log,
 	})
 	if err != nil {
-		logger.Printf("Failed to initialize database connection: %v", err)
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
-	logger.Printf("Database connection established successfully")
+	log.Info("Database connection established")
 	defer func() {
 		if err := postgresDB.CloseDB(db); err != nil {
-			logger.Printf("Failed to close database: %v", err)
+			log.Error("Failed to close database", slog.Any("error", err))
 		}
 	}()
 
@@ -52,8 +85,8 @@ func Run(cfg *config.Config) error {
 	}
 
 	// Initialize Redis client
-	logger.Printf("Initializing Redis connection with config: host=%s, port=%s, db=%d",
-		cfg.Redis.Host, cfg.Redis.Port, cfg.Redis.DB)
+	log.Info("Initializing Redis connection",
+		slog.String("host", cfg.Redis.Host), slog.String("port", cfg.Redis.Port), slog.Int("db", cfg.Redis.DB))
 	redisCli, err := redis.NewClient(redis.Config{
 		Host:     cfg.Redis.Host,
 		Port:     cfg.Redis.Port,
@@ -62,112 +95,256 @@ func Run(cfg *config.Config) error {
 		UseSSL:   cfg.Redis.UseSSL,
 	})
 	if err != nil {
-		logger.Printf("Failed to initialize Redis connection: %v", err)
 		return fmt.Errorf("failed to connect to Redis: %w", err)
 	}
-	logger.Printf("Redis connection established successfully")
+	log.Info("Redis connection established")
 	defer func() {
 		if err := redisCli.Close(); err != nil {
-			logger.Printf("Failed to close Redis connection: %v", err)
+			log.Error("Failed to close Redis connection", slog.Any("error", err))
 		}
 	}()
 
 	// Parse document TTL
 	documentTTL, err := time.ParseDuration(cfg.DocumentTTL)
 	if err != nil {
-		logger.Printf("Invalid DOCUMENT_TTL format, using default 24h: %v", err)
+		log.Warn("Invalid DOCUMENT_TTL format, using default 24h", slog.Any("error", err))
 		documentTTL = 24 * time.Hour
 	}
-	logger.Printf("Document TTL set to: %v", documentTTL)
+	log.Info("Document TTL configured", slog.Duration("ttl", documentTTL))
+
+	jobLockTTL, err := time.ParseDuration(cfg.JobLockTTL)
+	if err != nil || jobLockTTL <= 0 {
+		log.Warn("Invalid JOB_LOCK_TTL format, using default 1m", slog.Any("error", err))
+		jobLockTTL = time.Minute
+	}
+
+	replayTTL, err := time.ParseDuration(cfg.WSReplayTTL)
+	if err != nil || replayTTL <= 0 {
+		log.Warn("Invalid WS_REPLAY_TTL format, using default 1h", slog.Any("error", err))
+		replayTTL = time.Hour
+	}
+
+	roomEventsRetention, err := time.ParseDuration(cfg.RoomEventsRetention)
+	if err != nil || roomEventsRetention <= 0 {
+		log.Warn("Invalid ROOM_EVENTS_RETENTION format, using default 24h", slog.Any("error", err))
+		roomEventsRetention = 24 * time.Hour
+	}
+
+	expiryWarning, err := time.ParseDuration(cfg.RoomExpiryWarning)
+	if err != nil || expiryWarning <= 0 {
+		log.Warn("Invalid ROOM_EXPIRY_WARNING format, using default 5m", slog.Any("error", err))
+		expiryWarning = 5 * time.Minute
+	}
+
+	idempotencyTTL, err := time.ParseDuration(cfg.IdempotencyTTL)
+	if err != nil || idempotencyTTL <= 0 {
+		log.Warn("Invalid IDEMPOTENCY_TTL format, using default 24h", slog.Any("error", err))
+		idempotencyTTL = 24 * time.Hour
+	}
+
+	// A claimed key outlives the request holding it, which is cut off after the request timeout
+	idempotencyClaimTTL := time.Minute
+	if requestTimeout, err := time.ParseDuration(cfg.HTTPRequestTimeout); err == nil && requestTimeout > 0 {
+		idempotencyClaimTTL = 2 * requestTimeout
+	}
+
+	outboxPollInterval, err := time.ParseDuration(cfg.OutboxPollInterval)
+	if err != nil || outboxPollInterval <= 0 {
+		log.Warn("Invalid OUTBOX_POLL_INTERVAL format, using default 1s", slog.Any("error", err))
+		outboxPollInterval = time.Second
+	}
+
+	outboxRetention, err := time.ParseDuration(cfg.OutboxRetention)
+	if err != nil || outboxRetention <= 0 {
+		log.Warn("Invalid OUTBOX_RETENTION format, using default 72h", slog.Any("error", err))
+		outboxRetention = 72 * time.Hour
+	}
 
 	repositories := repository.NewRepositories(db)
+
+	eventBus := domainevents.NewBus(repositories, outboxPollInterval, outboxRetention, log)
+
+	// Unparsable or zero values fall back to the service defaults
+	webhookDuration := func(value string) time.Duration {
+		d, _ := time.ParseDuration(value)
+		return d
+	}
+	webhooksService := webhooks.NewWebhooksService(repositories.Webhooks, repositories.Rooms, repositories.Organizations, webhooks.Config{
+		PollInterval: webhookDuration(cfg.WebhookPollInterval),
+		Timeout:      webhookDuration(cfg.WebhookTimeout),
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		RetryBase:    webhookDuration(cfg.WebhookRetryBase),
+		RetryMax:     webhookDuration(cfg.WebhookRetryMax),
+		AllowLocal:   cfg.Env == "local",
+	}, log)
+	webhooksService.Start()
+
+	roomEvents := roomevents.NewRoomEventsService(redisCli, roomEventsRetention, log)
+	roomEvents.Start()
+
+	wsHub := websocket.NewHub(ctx, websocket.Config{
+		SendQueueBytes:   cfg.WSSendQueueBytes,
+		ReplayBufferSize: cfg.WSReplayBufferSize,
+		ReplayTTL:        replayTTL,
+		Limits: websocket.Limits{
+			MessagesPerSecond:   cfg.WSMessagesPerSecond,
+			BytesPerSecond:      cfg.WSBytesPerSecond,
+			MaxMessageBytes:     cfg.WSMaxMessageBytes,
+			MaxClientsPerRoom:   cfg.WSMaxClientsPerRoom,
+			MaxDocumentBytes:    cfg.WSMaxDocumentBytes,
+			MaxConnectionsPerIP: cfg.WSMaxConnectionsPerIP,
+		},
+	}, redisCli, repositories.Rooms, repositories.Organizations, repositories.Snapshots, eventBus, log)
+	go wsHub.Run()
+	metrics.RegisterHub(wsHub.Stats)
+
+	livekitClient := livekit.NewClient(cfg)
+
 	services := service.NewServices(service.Deps{
-		Repositories: repositories,
+		Repositories:         repositories,
+		Redis:                redisCli,
+		LiveKit:              livekitClient,
+		JobLockTTL:           jobLockTTL,
+		ArchiveRetentionDays: cfg.ArchiveRetentionDays,
+		ExpiryWarning:        expiryWarning,
+		RoomEvents:           roomEvents,
+		Events:               eventBus,
+		Webhooks:             webhooksService,
+		IdempotencyTTL:       idempotencyTTL,
+		IdempotencyClaimTTL:  idempotencyClaimTTL,
+		Logger:               log,
+	})
+
+	// Subscribers react to domain events after the change that raised them has committed
+	eventBus.Subscribe("metrics", domainevents.CountEvent)
+	eventBus.Subscribe("room_stream", roomEvents.Forward, roomevents.StreamedEvents...)
+	eventBus.Subscribe("webhooks", webhooksService.HandleEvent, webhooks.EventTypes...)
+	eventBus.Subscribe("websocket_broadcast", wsHub.Broadcast, websocket.BroadcastEvents...)
+	eventBus.Subscribe("document_cleanup", services.Rooms.DiscardDocument, domainevents.RoomExpired)
+	eventBus.Subscribe("media_room_sync", services.Rooms.SyncMediaRoom, domainevents.RoomUpdated)
+	eventBus.Subscribe("room_teardown", services.Rooms.TeardownRoom, domainevents.RoomDeleted)
+	eventBus.Start()
+
+	checker := health.NewChecker()
+	checker.Add("postgres", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
 	})
+	checker.Add("redis", redisCli.Ping)
+	checker.Add("livekit", livekitClient.Ping)
+	checker.Add("migrations", func(context.Context) error {
+		return migrations.Status(db)
+	})
+	checker.Add("websocket_hub", wsHub.CheckAlive)
 
-	restHandler := rest.NewHandler(services, redisCli, repositories.Rooms, cfg.DocumentTTL)
+	restHandler := rest.NewHandler(services, wsHub, checker, log)
 	router := restHandler.InitRoutes(cfg)
 
-	// Setup cron for expired rooms cleanup
-	c := cron.New(cron.WithSeconds())
-	
 	// Parse cleanup schedule (default: every hour at minute 0)
 	cleanupSchedule := cfg.CleanupSchedule
 	if cleanupSchedule == "" {
 		cleanupSchedule = "0 0 * * * *" // Every hour
 	}
-	
-	_, err = c.AddFunc(cleanupSchedule, func() {
-		logger.Printf("Running expired rooms cleanup task")
-		expiredRooms, err := services.Rooms.GetExpired()
-		if err != nil {
-			logger.Printf("Error getting expired rooms: %v", err)
-			return
-		}
 
-		if len(expiredRooms) == 0 {
-			return
-		}
+	if err := services.Jobs.Register(jobs.Job{
+		Name:     rooms.ArchiveJobName,
+		Schedule: cleanupSchedule,
+		Run:      services.Rooms.ArchiveExpired,
+	}); err != nil {
+		return fmt.Errorf("failed to setup archive job: %w", err)
+	}
 
-		logger.Printf("Found %d expired rooms, cleaning up documents", len(expiredRooms))
-
-		for _, room := range expiredRooms {
-			roomID := room.ID.String()
-			
-			// Delete document from Redis
-			if err := redisCli.DeleteDocumentState(roomID); err != nil {
-				logger.Printf("Error deleting document for expired room %s: %v", roomID, err)
-			} else {
-				logger.Printf("Deleted document for expired room %s", roomID)
-			}
-		}
+	if err := services.Jobs.Register(jobs.Job{
+		Name:     rooms.PurgeJobName,
+		Schedule: cfg.PurgeSchedule,
+		Run:      services.Rooms.PurgeArchived,
+	}); err != nil {
+		return fmt.Errorf("failed to setup purge job: %w", err)
+	}
 
-		// Delete expired rooms from database
-		if err := services.Rooms.DeleteExpired(); err != nil {
-			logger.Printf("Error deleting expired rooms from database: %v", err)
-		} else {
-			logger.Printf("Deleted %d expired rooms from database", len(expiredRooms))
-		}
-	})
-	
-	if err != nil {
-		logger.Printf("Error setting up cleanup cron job: %v", err)
-		return fmt.Errorf("failed to setup cleanup cron: %w", err)
+	if err := services.Jobs.Register(jobs.Job{
+		Name:     rooms.ExpiryWarningJobName,
+		Schedule: cfg.ExpiryWarningSchedule,
+		Run:      services.Rooms.WarnExpiring,
+	}); err != nil {
+		return fmt.Errorf("failed to setup expiry warning job: %w", err)
 	}
-	
-	c.Start()
-	logger.Printf("Started expired rooms cleanup cron job (schedule: %s)", cleanupSchedule)
-	defer c.Stop()
+
+	if err := services.Jobs.Register(jobs.Job{
+		Name:     domainevents.PurgeJobName,
+		Schedule: cfg.OutboxPurgeSchedule,
+		Run:      eventBus.PurgeProcessed,
+	}); err != nil {
+		return fmt.Errorf("failed to setup outbox purge job: %w", err)
+	}
+
+	services.Jobs.Start()
 
 	httpServer := &http.Server{
 		Addr:         ":" + cfg.HTTPPort,
 		ReadTimeout:  100 * time.Second,
 		WriteTimeout: 100 * time.Second,
 		Handler:      router,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
 	}
 
 	go func() {
-		logger.Printf("Starting HTTP server on port %s", cfg.HTTPPort)
+		log.Info("Starting HTTP server", slog.String("port", cfg.HTTPPort))
 		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatalf("HTTP server error: %v", err)
+			log.Error("HTTP server error", slog.Any("error", err))
+			os.Exit(1)
 		}
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
-	logger.Println("Server started")
+	log.Info("Server started")
 	<-quit
 
-	logger.Println("Shutting down...")
+	log.Info("Shutting down")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// Fail readiness first and give load balancers time to stop routing to us
+	checker.SetShuttingDown()
+	drainDelay, err := time.ParseDuration(cfg.ShutdownDrainDelay)
+	if err != nil {
+		log.Warn("Invalid SHUTDOWN_DRAIN_DELAY format, using default 5s", slog.Any("error", err))
+		drainDelay = 5 * time.Second
+	}
+	time.Sleep(drainDelay)
 
-	if err := httpServer.Shutdown(ctx); err != nil {
-		return fmt.Errorf("error shutting down server: %w", err)
+	shutdownTimeout, err := time.ParseDuration(cfg.ShutdownTimeout)
+	if err != nil || shutdownTimeout <= 0 {
+		log.Warn("Invalid SHUTDOWN_TIMEOUT format, using default 15s", slog.Any("error", err))
+		shutdownTimeout = 15 * time.Second
 	}
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+
+	// Websocket connections are hijacked, so http.Server.Shutdown does not see them; the hub closes them itself
+	wsHub.Shutdown(shutdownCtx)
 
-	_ = services
+	// Event streams never finish on their own; closing the subscriptions ends them before the server waits on them
+	roomEvents.Stop()
+
+	err = httpServer.Shutdown(shutdownCtx)
+
+	services.Jobs.Stop(shutdownCtx)
+
+	// Events and deliveries being handled are saved; the rest stay in their outbox for the next start or another instance
+	eventBus.Stop()
+	webhooksService.Stop()
+
+	// Abort whatever is still running: hijacked websocket connections and requests that outlived the timeout
+	cancel()
+
+	if err != nil {
+		return fmt.Errorf("error shutting down server: %w", err)
+	}
 	return nil
 }
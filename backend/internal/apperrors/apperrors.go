@@ -0,0 +1,158 @@
+// Package apperrors defines the typed errors services return for expected failures. Each carries a
+// kind, which decides the HTTP status, and a stable machine-readable code clients can rely on.
+package apperrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind classifies an error by what the caller can do about it
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindUnprocessable
+	KindGone
+	KindRateLimited
+	KindUnavailable
+	KindTooLarge
+)
+
+// Status is the HTTP status errors of the kind are answered with
+func (k Kind) Status() int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindUnprocessable:
+		return http.StatusUnprocessableEntity
+	case KindGone:
+		return http.StatusGone
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Error is an expected failure with a message safe to show to clients
+type Error struct {
+	Kind    Kind
+	Code    string // stable, snake_case
+	Message string
+	Fields  []FieldError // offending input fields of a validation error
+	cause   error
+}
+
+// FieldError describes what is wrong with one input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches errors with the same code, so a wrapped or detailed copy still matches its sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error caused by err. The cause is logged, never shown to clients.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.cause = err
+	return &c
+}
+
+// WithFields returns a copy of the error naming the offending fields
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	c := *e
+	c.Fields = fields
+	return &c
+}
+
+// As returns the Error in err's chain, if any
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// Unprocessable is a well-formed request that can't be acted on, like one reusing an idempotency key
+func Unprocessable(code, message string) *Error {
+	return New(KindUnprocessable, code, message)
+}
+
+func Gone(code, message string) *Error {
+	return New(KindGone, code, message)
+}
+
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+func Unavailable(code, message string) *Error {
+	return New(KindUnavailable, code, message)
+}
+
+// TooLarge is a request body over the size the endpoint accepts
+func TooLarge(code, message string) *Error {
+	return New(KindTooLarge, code, message)
+}
+
+// InvalidField is a validation error of a single field
+func InvalidField(field, code, message string) *Error {
+	return Validation("invalid_request", "request is invalid", FieldError{Field: field, Code: code, Message: message})
+}
@@ -0,0 +1,30 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+var errMissing = NotFound("room_not_found", "room not found")
+
+func TestWrappedErrorMatchesSentinel(t *testing.T) {
+	cause := errors.New("record not found")
+	err := fmt.Errorf("load room: %w", errMissing.Wrap(cause))
+
+	if !errors.Is(err, errMissing) {
+		t.Error("wrapped error does not match its sentinel")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("wrapped error does not match its cause")
+	}
+
+	e, ok := As(err)
+	if !ok || e.Code != "room_not_found" || e.Kind.Status() != http.StatusNotFound {
+		t.Errorf("As = %+v, %v; want the room_not_found error answered with 404", e, ok)
+	}
+	if errMissing.cause != nil {
+		t.Error("Wrap changed the sentinel")
+	}
+}
@@ -6,12 +6,31 @@ import (
 )
 
 type Config struct {
-	HTTPPort        string `envconfig:"HTTP_PORT" default:"8000"`
-	HTTPReadTimeout string `envconfig:"HTTP_READ_TIMEOUT" default:"100s"`
+	HTTPPort         string `envconfig:"HTTP_PORT" default:"8000"`
+	HTTPReadTimeout  string `envconfig:"HTTP_READ_TIMEOUT" default:"100s"`
 	HTTPWriteTimeout string `envconfig:"HTTP_WRITE_TIMEOUT" default:"100s"`
-
-	WebRTCPlatform  string `envconfig:"WEBRTC_PLATFORM" default:"livekit"`
-	WebRTCURL       string `envconfig:"WEBRTC_URL"`
+	// Deadline for the database and Redis work of a single API request
+	HTTPRequestTimeout string `envconfig:"HTTP_REQUEST_TIMEOUT" default:"30s"`
+	// How long /readyz reports failure before the server stops, so load balancers drain us first
+	ShutdownDrainDelay string `envconfig:"SHUTDOWN_DRAIN_DELAY" default:"5s"`
+	// Budget for closing websocket connections, finishing HTTP requests and letting running jobs complete
+	ShutdownTimeout string `envconfig:"SHUTDOWN_TIMEOUT" default:"15s"`
+
+	// Outbound bytes buffered per websocket client; a client that falls further behind must resync or is disconnected
+	WSSendQueueBytes int `envconfig:"WS_SEND_QUEUE_BYTES" default:"4194304"`
+	// Recent document updates kept per room so reconnecting clients can resume instead of reloading the document
+	WSReplayBufferSize int    `envconfig:"WS_REPLAY_BUFFER_SIZE" default:"500"`
+	WSReplayTTL        string `envconfig:"WS_REPLAY_TTL" default:"1h"`
+	// Websocket abuse limits; organizations can override all but the per-IP limit (ws_limits)
+	WSMessagesPerSecond   int `envconfig:"WS_MESSAGES_PER_SECOND" default:"50"`
+	WSBytesPerSecond      int `envconfig:"WS_BYTES_PER_SECOND" default:"1048576"`
+	WSMaxMessageBytes     int `envconfig:"WS_MAX_MESSAGE_BYTES" default:"524288"`
+	WSMaxClientsPerRoom   int `envconfig:"WS_MAX_CLIENTS_PER_ROOM" default:"200"`
+	WSMaxDocumentBytes    int `envconfig:"WS_MAX_DOCUMENT_BYTES" default:"8388608"`
+	WSMaxConnectionsPerIP int `envconfig:"WS_MAX_CONNECTIONS_PER_IP" default:"50"`
+
+	WebRTCPlatform string `envconfig:"WEBRTC_PLATFORM" default:"livekit"`
+	WebRTCURL      string `envconfig:"WEBRTC_URL"`
 	// URL, который отдаётся клиенту (браузеру). Должен быть публичный wss://. Если пусто — используется WebRTCURL.
 	WebRTCPublicURL string `envconfig:"WEBRTC_PUBLIC_URL"`
 	WebRTCAPIKey    string `envconfig:"WEBRTC_API_KEY"`
@@ -29,16 +48,16 @@ type Config struct {
 	AudioUseInbandFEC bool   `envconfig:"AUDIO_USE_INBAND_FEC" default:"true"`
 	AudioChannels     int    `envconfig:"AUDIO_CHANNELS" default:"1"`
 
-	JWTSecret            string `envconfig:"JWT_SECRET"`
-	JWTAccessTokenTTL    string `envconfig:"JWT_ACCESS_TOKEN_TTL" default:"30m"`
-	JWTRefreshTokenTTL    string `envconfig:"JWT_REFRESH_TOKEN_TTL" default:"7d"`
-	E2EEEnabled          bool   `envconfig:"E2EE_ENABLED" default:"true"`
-	E2EERequire          bool   `envconfig:"E2EE_REQUIRE" default:"true"`
+	JWTSecret               string `envconfig:"JWT_SECRET"`
+	JWTAccessTokenTTL       string `envconfig:"JWT_ACCESS_TOKEN_TTL" default:"30m"`
+	JWTRefreshTokenTTL      string `envconfig:"JWT_REFRESH_TOKEN_TTL" default:"7d"`
+	E2EEEnabled             bool   `envconfig:"E2EE_ENABLED" default:"true"`
+	E2EERequire             bool   `envconfig:"E2EE_REQUIRE" default:"true"`
 	E2EEKeyRotationInterval string `envconfig:"E2EE_KEY_ROTATION_INTERVAL" default:"1h"`
-	E2EEFallbackWarning  bool   `envconfig:"E2EE_FALLBACK_WARNING" default:"true"`
+	E2EEFallbackWarning     bool   `envconfig:"E2EE_FALLBACK_WARNING" default:"true"`
 
 	RateLimitTokensPerMinute int `envconfig:"RATE_LIMIT_TOKENS_PER_MINUTE" default:"20"`
-	RateLimitBurst            int `envconfig:"RATE_LIMIT_BURST" default:"5"`
+	RateLimitBurst           int `envconfig:"RATE_LIMIT_BURST" default:"5"`
 
 	DB struct {
 		Host     string `envconfig:"DB_HOST" default:"localhost"`
@@ -68,14 +87,61 @@ type Config struct {
 	// Document TTL - how long to keep document state in Redis after room becomes empty
 	DocumentTTL string `envconfig:"DOCUMENT_TTL" default:"24h"`
 
-	// Cleanup schedule - cron expression for expired rooms cleanup (default: every hour)
+	// Cleanup schedule - cron expression for archiving expired rooms (default: every hour)
 	// Format: "second minute hour day month weekday"
 	// Example: "0 0 * * * *" = every hour at minute 0
 	// Example: "0 */30 * * * *" = every 30 minutes
 	CleanupSchedule string `envconfig:"CLEANUP_SCHEDULE" default:"0 0 * * * *"`
 
+	// Purge schedule - cron expression for deleting archived rooms past their retention (default: daily at 03:30)
+	PurgeSchedule string `envconfig:"PURGE_SCHEDULE" default:"0 30 3 * * *"`
+
+	// Room events (SSE stream) - how long events are kept for resuming subscribers, and when
+	// room.expiring is published before a room expires (checked by a job on ExpiryWarningSchedule)
+	RoomEventsRetention   string `envconfig:"ROOM_EVENTS_RETENTION" default:"24h"`
+	RoomExpiryWarning     string `envconfig:"ROOM_EXPIRY_WARNING" default:"5m"`
+	ExpiryWarningSchedule string `envconfig:"EXPIRY_WARNING_SCHEDULE" default:"0 * * * * *"`
+
+	// Domain event outbox - how often it is polled for events written by other instances (events
+	// written here are handled right away), and how long processed events are kept
+	OutboxPollInterval  string `envconfig:"OUTBOX_POLL_INTERVAL" default:"1s"`
+	OutboxRetention     string `envconfig:"OUTBOX_RETENTION" default:"72h"`
+	OutboxPurgeSchedule string `envconfig:"OUTBOX_PURGE_SCHEDULE" default:"0 45 * * * *"`
+
+	// Webhooks - how often the delivery outbox is polled, the per-request timeout, and the retry
+	// schedule: a failed delivery is retried after WebhookRetryBase, doubling up to WebhookRetryMax,
+	// and marked failed after WebhookMaxAttempts attempts
+	WebhookPollInterval string `envconfig:"WEBHOOK_POLL_INTERVAL" default:"2s"`
+	WebhookTimeout      string `envconfig:"WEBHOOK_TIMEOUT" default:"10s"`
+	WebhookMaxAttempts  int    `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	WebhookRetryBase    string `envconfig:"WEBHOOK_RETRY_BASE" default:"30s"`
+	WebhookRetryMax     string `envconfig:"WEBHOOK_RETRY_MAX" default:"6h"`
+
+	// Archive retention - days archived rooms and their document snapshots are kept.
+	// Organizations can override it with archive_retention_days.
+	ArchiveRetentionDays int `envconfig:"ARCHIVE_RETENTION_DAYS" default:"30"`
+
+	// Job lock TTL - lease duration for the Redis lock that keeps a job on a single replica.
+	// The lease is renewed while the job runs, so it only bounds recovery after a crashed instance.
+	JobLockTTL string `envconfig:"JOB_LOCK_TTL" default:"1m"`
+
+	// How long the response to a call with an Idempotency-Key is replayed to retries with the same key
+	IdempotencyTTL string `envconfig:"IDEMPOTENCY_TTL" default:"24h"`
+
+	// Admin API token (Authorization: Bearer <token>). Если пустой — админские роуты отвечают 403.
+	AdminToken string `envconfig:"ADMIN_TOKEN"`
+
+	// OpenTelemetry tracing: spans are exported over OTLP/gRPC to a collector (host:port)
+	Tracing struct {
+		Enabled      bool    `envconfig:"TRACING_ENABLED" default:"false"`
+		OTLPEndpoint string  `envconfig:"TRACING_OTLP_ENDPOINT" default:"localhost:4317"`
+		Insecure     bool    `envconfig:"TRACING_OTLP_INSECURE" default:"true"`
+		SampleRatio  float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`
+	}
+
+	// ENV picks the log level (local: debug, production: warn, other: info); DEBUG, if set, overrides it
 	Env   string `envconfig:"ENV" default:"local"`
-	Debug bool   `envconfig:"DEBUG" default:"false"`
+	Debug *bool  `envconfig:"DEBUG"`
 
 	// CORS: через запятую, например https://meet.nonza.ru,https://www.nonza.ru
 	CORSAllowedOrigins string `envconfig:"CORS_ALLOWED_ORIGINS"`
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"nonza/backend/internal/models"
+	"time"
+)
+
+type AuditEventResponse struct {
+	ID             string           `json:"id"`
+	OccurredAt     time.Time        `json:"occurred_at"`
+	OrganizationID string           `json:"organization_id"`
+	ActorType      string           `json:"actor_type"`
+	ActorID        string           `json:"actor_id,omitempty"`
+	Action         string           `json:"action"`
+	TargetType     string           `json:"target_type,omitempty"`
+	TargetID       string           `json:"target_id,omitempty"`
+	Status         int              `json:"status"`
+	IP             string           `json:"ip,omitempty"`
+	UserAgent      string           `json:"user_agent,omitempty"`
+	RequestID      string           `json:"request_id,omitempty"`
+	Diff           models.AuditDiff `json:"diff,omitempty"`
+}
+
+// CSVHeader names the columns of CSVRecord
+var CSVHeader = []string{"id", "occurred_at", "organization_id", "actor_type", "actor_id", "action", "target_type", "target_id", "status", "ip", "user_agent", "request_id", "diff"}
+
+func ToAuditEventResponse(event *models.AuditEvent) AuditEventResponse {
+	response := AuditEventResponse{
+		ID:         event.ID.String(),
+		OccurredAt: event.OccurredAt,
+		ActorType:  event.ActorType,
+		ActorID:    event.ActorID,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Status:     event.Status,
+		IP:         event.IP,
+		UserAgent:  event.UserAgent,
+		RequestID:  event.RequestID,
+		Diff:       event.Diff,
+	}
+	if event.OrganizationID != nil {
+		response.OrganizationID = event.OrganizationID.String()
+	}
+	return response
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/service/jobs"
+	"time"
+)
+
+type JobResponse struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+}
+
+type JobRunResponse struct {
+	ID            string     `json:"id"`
+	JobName       string     `json:"job_name"`
+	Target        *string    `json:"target,omitempty"`
+	Trigger       string     `json:"trigger"`
+	Instance      string     `json:"instance"`
+	Status        string     `json:"status"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	RoomsAffected int        `json:"rooms_affected"`
+	Error         *string    `json:"error,omitempty"`
+}
+
+func ToJobResponse(info jobs.JobInfo) JobResponse {
+	return JobResponse{
+		Name:     info.Name,
+		Schedule: info.Schedule,
+	}
+}
+
+func ToJobRunResponse(run *models.JobRun) JobRunResponse {
+	return JobRunResponse{
+		ID:            run.ID.String(),
+		JobName:       run.JobName,
+		Target:        run.Target,
+		Trigger:       string(run.Trigger),
+		Instance:      run.Instance,
+		Status:        string(run.Status),
+		StartedAt:     run.StartedAt,
+		FinishedAt:    run.FinishedAt,
+		RoomsAffected: run.RoomsAffected,
+		Error:         run.Error,
+	}
+}
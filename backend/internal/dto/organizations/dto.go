@@ -5,27 +5,117 @@ import "nonza/backend/internal/models"
 type CreateOrganizationRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+	Slug        string `json:"slug"`
 }
 
 type UpdateOrganizationRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
+	Name                 string      `json:"name" binding:"required"`
+	Description          string      `json:"description"`
+	Slug                 *string     `json:"slug"`
+	ArchiveRetentionDays *int        `json:"archive_retention_days" binding:"omitempty,min=1"`
+	WSLimits             *WSLimits   `json:"ws_limits"`
+	ShortCodes           *ShortCodes `json:"short_codes"`
+}
+
+// WSLimits overrides the server's websocket limits for the organization's rooms; omitted fields keep the default
+type WSLimits struct {
+	MessagesPerSecond *int `json:"messages_per_second,omitempty" binding:"omitempty,min=1"`
+	BytesPerSecond    *int `json:"bytes_per_second,omitempty" binding:"omitempty,min=1"`
+	MaxMessageBytes   *int `json:"max_message_bytes,omitempty" binding:"omitempty,min=1024"`
+	MaxClientsPerRoom *int `json:"max_clients_per_room,omitempty" binding:"omitempty,min=1"`
+	MaxDocumentBytes  *int `json:"max_document_bytes,omitempty" binding:"omitempty,min=1024"`
+}
+
+func (l *WSLimits) ToModel() *models.WSLimits {
+	if l == nil {
+		return nil
+	}
+	return &models.WSLimits{
+		MessagesPerSecond: l.MessagesPerSecond,
+		BytesPerSecond:    l.BytesPerSecond,
+		MaxMessageBytes:   l.MaxMessageBytes,
+		MaxClientsPerRoom: l.MaxClientsPerRoom,
+		MaxDocumentBytes:  l.MaxDocumentBytes,
+	}
+}
+
+func toWSLimits(l *models.WSLimits) *WSLimits {
+	if l == nil {
+		return nil
+	}
+	return &WSLimits{
+		MessagesPerSecond: l.MessagesPerSecond,
+		BytesPerSecond:    l.BytesPerSecond,
+		MaxMessageBytes:   l.MaxMessageBytes,
+		MaxClientsPerRoom: l.MaxClientsPerRoom,
+		MaxDocumentBytes:  l.MaxDocumentBytes,
+	}
+}
+
+// ShortCodes sets the format of short codes of the organization's new rooms: random characters
+// or words. Omitted fields take the strategy's default.
+type ShortCodes struct {
+	Strategy  string   `json:"strategy" binding:"required,oneof=random words"`
+	Length    int      `json:"length,omitempty"`
+	Alphabet  string   `json:"alphabet,omitempty"`
+	Words     []string `json:"words,omitempty"`
+	WordCount int      `json:"word_count,omitempty"`
+}
+
+func (c *ShortCodes) ToModel() *models.ShortCodeFormat {
+	if c == nil {
+		return nil
+	}
+	return &models.ShortCodeFormat{
+		Strategy:  c.Strategy,
+		Length:    c.Length,
+		Alphabet:  c.Alphabet,
+		Words:     c.Words,
+		WordCount: c.WordCount,
+	}
+}
+
+func toShortCodes(f *models.ShortCodeFormat) *ShortCodes {
+	if f == nil {
+		return nil
+	}
+	return &ShortCodes{
+		Strategy:  f.Strategy,
+		Length:    f.Length,
+		Alphabet:  f.Alphabet,
+		Words:     f.Words,
+		WordCount: f.WordCount,
+	}
 }
 
 type OrganizationResponse struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID                   string      `json:"id"`
+	Name                 string      `json:"name"`
+	Description          string      `json:"description"`
+	Slug                 *string     `json:"slug,omitempty"`
+	ArchiveRetentionDays *int        `json:"archive_retention_days,omitempty"`
+	WSLimits             *WSLimits   `json:"ws_limits,omitempty"`
+	ShortCodes           *ShortCodes `json:"short_codes,omitempty"`
+	DeletionRequestedAt  string      `json:"deletion_requested_at,omitempty"`
+	CreatedAt            string      `json:"created_at"`
+	UpdatedAt            string      `json:"updated_at"`
 }
 
 func ToOrganizationResponse(org *models.Organization) OrganizationResponse {
+	var deletionRequestedAt string
+	if org.DeletionRequestedAt != nil {
+		deletionRequestedAt = org.DeletionRequestedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
 	return OrganizationResponse{
-		ID:          org.ID.String(),
-		Name:        org.Name,
-		Description: org.Description,
-		CreatedAt:   org.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   org.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:                   org.ID.String(),
+		Name:                 org.Name,
+		Description:          org.Description,
+		Slug:                 org.Slug,
+		ArchiveRetentionDays: org.ArchiveRetentionDays,
+		WSLimits:             toWSLimits(org.WSLimits),
+		ShortCodes:           toShortCodes(org.ShortCodes),
+		DeletionRequestedAt:  deletionRequestedAt,
+		CreatedAt:            org.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:            org.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
 }
@@ -0,0 +1,17 @@
+package dto
+
+// Page is the envelope of every paginated listing
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// NextCursor is passed as cursor to get the next page; empty on the last one
+	NextCursor string `json:"next_cursor"`
+}
+
+// ToPage converts a page of models to its response
+func ToPage[M, T any](items []M, nextCursor string, convert func(*M) T) Page[T] {
+	page := Page[T]{Items: make([]T, len(items)), NextCursor: nextCursor}
+	for i := range items {
+		page.Items[i] = convert(&items[i])
+	}
+	return page
+}
@@ -1,26 +1,44 @@
 package dto
 
 import (
+	"encoding/json"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository/redis"
+	"nonza/backend/internal/service/roomevents"
 	"time"
 )
 
 type CreateRoomRequest struct {
 	Name        string `json:"name" binding:"required"`
+	Slug        string `json:"slug"`
 	RoomType    string `json:"room_type" binding:"required,oneof=conference_hall round_table"`
 	IsTemporary bool   `json:"is_temporary"`
 	ExpiresIn   string `json:"expires_in"`
 	E2EEEnabled bool   `json:"e2ee_enabled"`
 }
 
+// UpdateRoomRequest changes the given settings of a room; omitted fields keep their value.
+// Setting is_temporary to false clears the expiry; an empty slug removes it.
+type UpdateRoomRequest struct {
+	Name        *string    `json:"name" binding:"omitempty,min=1"`
+	Slug        *string    `json:"slug"`
+	RoomType    *string    `json:"room_type" binding:"omitempty,oneof=conference_hall round_table"`
+	IsTemporary *bool      `json:"is_temporary"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	E2EEEnabled *bool      `json:"e2ee_enabled"`
+}
+
 type RoomResponse struct {
 	ID              string     `json:"id"`
 	OrganizationID  string     `json:"organization_id"`
 	Name            string     `json:"name"`
+	Slug            *string    `json:"slug,omitempty"`
 	ShortCode       *string    `json:"short_code"`
 	RoomType        string     `json:"room_type"`
 	IsTemporary     bool       `json:"is_temporary"`
 	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	Status          string     `json:"status"`
+	ArchivedAt      *time.Time `json:"archived_at,omitempty"`
 	LiveKitRoomName string     `json:"livekit_room_name"`
 	E2EEEnabled     bool       `json:"e2ee_enabled"`
 	CreatedAt       time.Time  `json:"created_at"`
@@ -38,13 +56,87 @@ func ToRoomResponse(room *models.Room) RoomResponse {
 		ID:              room.ID.String(),
 		OrganizationID:  room.OrganizationID.String(),
 		Name:            room.Name,
+		Slug:            room.Slug,
 		ShortCode:       room.ShortCode,
 		RoomType:        string(room.RoomType),
 		IsTemporary:     room.IsTemporary,
 		ExpiresAt:       room.ExpiresAt,
+		Status:          string(room.Status),
+		ArchivedAt:      room.ArchivedAt,
 		LiveKitRoomName: room.LiveKitRoomName,
 		E2EEEnabled:     e2ee,
 		CreatedAt:       room.CreatedAt,
 		UpdatedAt:       room.UpdatedAt,
 	}
 }
+
+type PresenceUser struct {
+	UserID       string    `json:"user_id"`
+	DisplayName  string    `json:"display_name,omitempty"`
+	Role         string    `json:"role"`
+	Device       string    `json:"device"`
+	Connections  int       `json:"connections"`
+	ConnectedAt  time.Time `json:"connected_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+}
+
+type ParticipantResponse struct {
+	ID            string     `json:"id"`
+	RoomID        string     `json:"room_id"`
+	UserID        *string    `json:"user_id,omitempty"`
+	AnonymousID   *string    `json:"anonymous_id,omitempty"`
+	Role          string     `json:"role"`
+	IsMainSpeaker bool       `json:"is_main_speaker"`
+	JoinedAt      time.Time  `json:"joined_at"`
+	LeftAt        *time.Time `json:"left_at,omitempty"`
+}
+
+func ToParticipantResponse(participant *models.Participant) ParticipantResponse {
+	return ParticipantResponse{
+		ID:            participant.ID.String(),
+		RoomID:        participant.RoomID.String(),
+		UserID:        participant.UserID,
+		AnonymousID:   participant.AnonymousID,
+		Role:          string(participant.Role),
+		IsMainSpeaker: participant.IsMainSpeaker,
+		JoinedAt:      participant.JoinedAt,
+		LeftAt:        participant.LeftAt,
+	}
+}
+
+type PresenceResponse struct {
+	RoomID string         `json:"room_id"`
+	Users  []PresenceUser `json:"users"`
+}
+
+func ToPresenceResponse(roomID string, entries []redis.PresenceEntry) PresenceResponse {
+	users := make([]PresenceUser, len(entries))
+	for i, e := range entries {
+		users[i] = PresenceUser{
+			UserID:       e.UserID,
+			DisplayName:  e.DisplayName,
+			Role:         e.Role,
+			Device:       e.Device,
+			Connections:  e.Connections,
+			ConnectedAt:  e.ConnectedAt,
+			LastActiveAt: e.LastActiveAt,
+		}
+	}
+	return PresenceResponse{RoomID: roomID, Users: users}
+}
+
+type RoomEventResponse struct {
+	RoomID     string          `json:"room_id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func ToRoomEventResponse(event roomevents.Event) RoomEventResponse {
+	return RoomEventResponse{
+		RoomID:     event.RoomID,
+		Type:       event.Type,
+		OccurredAt: event.Time().UTC(),
+		Data:       event.Data,
+	}
+}
@@ -13,10 +13,10 @@ type ICEServer struct {
 }
 
 type TokenResponse struct {
-	Token         string       `json:"token"`
-	URL           string       `json:"url"`
-	RoomName      string       `json:"room_name"`
-	ParticipantID string       `json:"participant_id"`
-	EncryptionKey string       `json:"encryption_key,omitempty"`
-	IceServers    []ICEServer  `json:"ice_servers,omitempty"`
+	Token         string      `json:"token"`
+	URL           string      `json:"url"`
+	RoomName      string      `json:"room_name"`
+	ParticipantID string      `json:"participant_id"`
+	EncryptionKey string      `json:"encryption_key,omitempty"`
+	IceServers    []ICEServer `json:"ice_servers,omitempty"`
 }
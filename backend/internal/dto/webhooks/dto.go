@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"nonza/backend/internal/models"
+	"time"
+)
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events" binding:"required,min=1"`
+}
+
+type UpdateWebhookRequest struct {
+	URL     string   `json:"url" binding:"required,url"`
+	Events  []string `json:"events" binding:"required,min=1"`
+	Enabled *bool    `json:"enabled" binding:"required"`
+}
+
+type WebhookResponse struct {
+	ID             string   `json:"id"`
+	OrganizationID string   `json:"organization_id"`
+	URL            string   `json:"url"`
+	Events         []string `json:"events"`
+	Enabled        bool     `json:"enabled"`
+	// Secret is only returned when the endpoint is created
+	Secret    string `json:"secret,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func ToWebhookResponse(endpoint *models.WebhookEndpoint) WebhookResponse {
+	return WebhookResponse{
+		ID:             endpoint.ID.String(),
+		OrganizationID: endpoint.OrganizationID.String(),
+		URL:            endpoint.URL,
+		Events:         endpoint.Events,
+		Enabled:        endpoint.Enabled,
+		CreatedAt:      endpoint.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      endpoint.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// ToCreatedWebhookResponse is ToWebhookResponse with the signing secret
+func ToCreatedWebhookResponse(endpoint *models.WebhookEndpoint) WebhookResponse {
+	response := ToWebhookResponse(endpoint)
+	response.Secret = endpoint.Secret
+	return response
+}
+
+type DeliveryResponse struct {
+	ID             string     `json:"id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // only while pending
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	ResponseStatus *int       `json:"response_status,omitempty"`
+	ResponseBody   *string    `json:"response_body,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func ToDeliveryResponse(delivery *models.WebhookDelivery) DeliveryResponse {
+	var nextAttemptAt *time.Time
+	if delivery.Status == models.WebhookDeliveryPending {
+		nextAttemptAt = &delivery.NextAttemptAt
+	}
+	return DeliveryResponse{
+		ID:             delivery.ID.String(),
+		EventID:        delivery.EventID.String(),
+		EventType:      delivery.EventType,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  nextAttemptAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
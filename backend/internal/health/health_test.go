@@ -0,0 +1,42 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestReady(t *testing.T) {
+	c := NewChecker()
+	c.Add("ok", func(context.Context) error { return nil })
+
+	if report := c.Ready(context.Background()); report.Status != StatusOK {
+		t.Fatalf("all checks pass: got %q, want %q", report.Status, StatusOK)
+	}
+
+	c.Add("broken", func(context.Context) error { return errors.New("down") })
+	report := c.Ready(context.Background())
+	if report.Status != StatusFail {
+		t.Fatalf("one check fails: got %q, want %q", report.Status, StatusFail)
+	}
+	if got := report.Checks["broken"]; got.Status != StatusFail || got.Error != "down" {
+		t.Errorf("broken check result: %+v", got)
+	}
+	if got := report.Checks["ok"]; got.Status != StatusOK {
+		t.Errorf("ok check result: %+v", got)
+	}
+}
+
+func TestReady_FailsDuringShutdown(t *testing.T) {
+	c := NewChecker()
+	c.Add("ok", func(context.Context) error { return nil })
+	c.SetShuttingDown()
+
+	report := c.Ready(context.Background())
+	if report.Status != StatusFail {
+		t.Fatalf("got %q, want %q", report.Status, StatusFail)
+	}
+	if _, ok := report.Checks["shutdown"]; !ok {
+		t.Error("expected shutdown entry in report")
+	}
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys (or key suffixes) whose values never reach the log output
+var sensitiveKeys = []string{
+	"password",
+	"secret",
+	"token",
+	"credential",
+	"encryption_key",
+	"authorization",
+}
+
+type ctxKey struct{}
+
+// New builds the application logger at the level for env and debug (see Level). Local
+// environments get human-readable text output, everything else JSON for log shippers.
+func New(env string, debug *bool) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       Level(env, debug),
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	if env == "local" {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+
+	return slog.New(handler)
+}
+
+// Level returns the log level of an environment: debug for local, warn for production, info
+// for the rest. DEBUG, when set, overrides it: true logs at debug, false at info.
+func Level(env string, debug *bool) slog.Level {
+	if debug != nil {
+		if *debug {
+			return slog.LevelDebug
+		}
+		return slog.LevelInfo
+	}
+	switch env {
+	case "local":
+		return slog.LevelDebug
+	case "production":
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if isSensitive(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if key == sensitive || strings.HasSuffix(key, "_"+sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithContext stores logger in ctx so downstream code logs with the same correlation fields
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
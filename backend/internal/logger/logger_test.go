@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redact}))
+
+	log.Info("issued",
+		slog.String("encryption_key", "c2VjcmV0"),
+		slog.String("turn_secret", "hunter2"),
+		slog.String("Authorization", "Bearer abc"),
+		slog.String("room_id", "r-1"),
+	)
+
+	out := buf.String()
+	for _, leaked := range []string{"c2VjcmV0", "hunter2", "Bearer abc"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("sensitive value %q leaked: %s", leaked, out)
+		}
+	}
+	if !strings.Contains(out, "room_id=r-1") {
+		t.Errorf("non-sensitive attribute missing: %s", out)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	on, off := true, false
+	cases := []struct {
+		env   string
+		debug *bool
+		want  slog.Level
+	}{
+		{"local", nil, slog.LevelDebug},
+		{"staging", nil, slog.LevelInfo},
+		{"production", nil, slog.LevelWarn},
+		{"production", &on, slog.LevelDebug},
+		{"local", &off, slog.LevelInfo},
+	}
+	for _, c := range cases {
+		if got := Level(c.env, c.debug); got != c.want {
+			t.Errorf("Level(%q, %v) = %v, want %v", c.env, c.debug, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+// GinMiddleware assigns every request an ID (reusing X-Request-ID if the client sent one),
+// stores a logger carrying it in the request context and logs the completed request.
+func GinMiddleware(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Set(requestIDKey, requestID)
+
+		log := base.With(slog.String(requestIDKey, requestID))
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			log = log.With(slog.String("trace_id", span.TraceID().String()))
+		}
+		c.Request = c.Request.WithContext(WithContext(c.Request.Context(), log))
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		level := slog.LevelInfo
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		log.Log(c.Request.Context(), level, "http request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.Int("status", c.Writer.Status()),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// RequestID returns the ID assigned to the request by GinMiddleware
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
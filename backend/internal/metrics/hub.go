@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// roomSizeBuckets are inclusive upper bounds of the rooms-by-size ranges; larger rooms fall into the last one
+var roomSizeBuckets = []int{1, 2, 5, 10, 25, 50}
+
+// HubStats is a point-in-time view of the websocket hub
+type HubStats struct {
+	Clients   int
+	RoomSizes []int
+}
+
+type hubCollector struct {
+	stats func() HubStats
+
+	clients     *prometheus.Desc
+	rooms       *prometheus.Desc
+	roomsBySize *prometheus.Desc
+}
+
+// RegisterHub exposes websocket hub gauges computed from stats at scrape time
+func RegisterHub(stats func() HubStats) {
+	prometheus.MustRegister(&hubCollector{
+		stats: stats,
+		clients: prometheus.NewDesc(prometheus.BuildFQName(namespace, "ws", "clients"),
+			"Connected websocket clients.", nil, nil),
+		rooms: prometheus.NewDesc(prometheus.BuildFQName(namespace, "ws", "rooms"),
+			"Rooms with at least one connected client.", nil, nil),
+		roomsBySize: prometheus.NewDesc(prometheus.BuildFQName(namespace, "ws", "rooms_by_size"),
+			"Rooms grouped by number of connected clients.", []string{"size"}, nil),
+	})
+}
+
+func (c *hubCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.clients
+	ch <- c.rooms
+	ch <- c.roomsBySize
+}
+
+func (c *hubCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+
+	ch <- prometheus.MustNewConstMetric(c.clients, prometheus.GaugeValue, float64(stats.Clients))
+	ch <- prometheus.MustNewConstMetric(c.rooms, prometheus.GaugeValue, float64(len(stats.RoomSizes)))
+
+	counts := make([]int, len(roomSizeBuckets)+1)
+	for _, size := range stats.RoomSizes {
+		i := 0
+		for i < len(roomSizeBuckets) && size > roomSizeBuckets[i] {
+			i++
+		}
+		counts[i]++
+	}
+	for i, label := range roomSizeLabels() {
+		ch <- prometheus.MustNewConstMetric(c.roomsBySize, prometheus.GaugeValue, float64(counts[i]), label)
+	}
+}
+
+// roomSizeLabels renders the buckets as ranges: "1", "2", "3-5", ..., "51+"
+func roomSizeLabels() []string {
+	labels := make([]string, 0, len(roomSizeBuckets)+1)
+	lower := 1
+	for _, upper := range roomSizeBuckets {
+		if lower == upper {
+			labels = append(labels, fmt.Sprint(upper))
+		} else {
+			labels = append(labels, fmt.Sprintf("%d-%d", lower, upper))
+		}
+		lower = upper + 1
+	}
+	return append(labels, fmt.Sprintf("%d+", lower))
+}
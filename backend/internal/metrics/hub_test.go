@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHubCollector_RoomsBySize(t *testing.T) {
+	collector := &hubCollector{
+		stats: func() HubStats {
+			return HubStats{Clients: 70, RoomSizes: []int{1, 1, 2, 7, 60}}
+		},
+		clients:     prometheus.NewDesc("clients", "h", nil, nil),
+		rooms:       prometheus.NewDesc("rooms", "h", nil, nil),
+		roomsBySize: prometheus.NewDesc("rooms_by_size", "h", []string{"size"}, nil),
+	}
+
+	expected := `
+# HELP rooms_by_size h
+# TYPE rooms_by_size gauge
+rooms_by_size{size="1"} 2
+rooms_by_size{size="2"} 1
+rooms_by_size{size="3-5"} 0
+rooms_by_size{size="6-10"} 1
+rooms_by_size{size="11-25"} 0
+rooms_by_size{size="26-50"} 0
+rooms_by_size{size="51+"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rooms_by_size"); err != nil {
+		t.Fatal(err)
+	}
+}
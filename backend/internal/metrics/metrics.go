@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "nonza"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	WSBinaryMessages = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ws",
+		Name:      "binary_messages_total",
+		Help:      "Binary (Y.js) messages received from clients and relayed to their room.",
+	})
+
+	WSBytesRelayed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ws",
+		Name:      "relayed_bytes_total",
+		Help:      "Bytes queued to clients, counted once per recipient.",
+	})
+
+	WSDroppedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ws",
+		Name:      "dropped_messages_total",
+		Help:      "Messages dropped because a client's outbound queue was full.",
+	}, []string{"channel"})
+
+	WSRejectedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ws",
+		Name:      "rejected_messages_total",
+		Help:      "Client messages rejected by protocol validation, by error code.",
+	}, []string{"code"})
+
+	WSSlowConsumers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ws",
+		Name:      "slow_consumers_total",
+		Help:      "Clients whose outbound queue overflowed, by the action taken (resync or disconnect).",
+	}, []string{"action"})
+
+	WSLimitViolations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ws",
+		Name:      "limit_violations_total",
+		Help:      "Connections closed or refused for exceeding a websocket limit, by limit.",
+	}, []string{"limit"})
+
+	DocumentStateBytes = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "document_state_bytes",
+		Help:      "Size of Y.js document states written to Redis.",
+		Buckets:   prometheus.ExponentialBuckets(256, 4, 9), // 256B .. 16MB
+	})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "GORM query latency by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	TokensIssued = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tokens",
+		Name:      "issued_total",
+		Help:      "LiveKit access tokens issued.",
+	})
+
+	DomainEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "handled_total",
+		Help:      "Domain events taken from the outbox, by type.",
+	}, []string{"type"})
+
+	OutboxHandlerFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "handler_failures_total",
+		Help:      "Failed attempts of an event bus subscriber to handle an event, by subscriber.",
+	}, []string{"subscriber"})
+
+	ShortCodeAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rooms",
+		Name:      "short_code_attempts_total",
+		Help:      "Short codes drawn for new rooms, by strategy and outcome (created, collision or blocked).",
+	}, []string{"strategy", "result"})
+
+	WebhookAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "attempts_total",
+		Help:      "Webhook delivery attempts, by outcome (delivered, retry or failed).",
+	}, []string{"result"})
+)
+
+// Channel labels for WSDroppedMessages
+const (
+	ChannelText   = "text"
+	ChannelBinary = "binary"
+	ChannelUpdate = "update" // Y.js updates dropped together when a slow client is asked to resync
+)
+
+// Action labels for WSSlowConsumers
+const (
+	SlowConsumerResync     = "resync"
+	SlowConsumerDisconnect = "disconnect"
+)
+
+// Limit labels for WSLimitViolations
+const (
+	LimitMessageSize   = "message_size"
+	LimitMessageRate   = "message_rate"
+	LimitByteRate      = "byte_rate"
+	LimitRoomClients   = "room_clients"
+	LimitDocumentSize  = "document_size"
+	LimitIPConnections = "ip_connections"
+)
+
+// Result labels for ShortCodeAttempts
+const (
+	ShortCodeCreated   = "created"
+	ShortCodeCollision = "collision" // another room has the code; a new one is drawn
+	ShortCodeBlocked   = "blocked"   // the code contains a blocked word; a new one is drawn
+)
+
+// Result labels for WebhookAttempts
+const (
+	WebhookDelivered = "delivered"
+	WebhookRetry     = "retry"
+	WebhookFailed    = "failed" // attempts exhausted or the endpoint was disabled
+)
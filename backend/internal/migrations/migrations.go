@@ -1,17 +1,67 @@
 package migrations
 
 import (
+	"fmt"
 	"nonza/backend/internal/models"
 
 	"gorm.io/gorm"
 )
 
-func RunMigrations(db *gorm.DB) error {
-	return db.AutoMigrate(
+func schema() []interface{} {
+	return []interface{}{
 		&models.Organization{},
+		&models.OrganizationSlugRedirect{},
 		&models.Room{},
+		&models.RoomSlugRedirect{},
 		&models.MeetingDocument{},
 		&models.Participant{},
 		&models.DocumentOperation{},
-	)
+		&models.JobRun{},
+		&models.DocumentSnapshot{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.OutboxEvent{},
+		&models.AuditEvent{},
+	}
+}
+
+func RunMigrations(db *gorm.DB) error {
+	if err := dedupeDocumentSnapshots(db); err != nil {
+		return err
+	}
+	return db.AutoMigrate(schema()...)
+}
+
+// dedupeDocumentSnapshots keeps only the latest snapshot of each room and drops the plain room_id
+// index, so that the unique one replacing it can be built over snapshots from before it existed
+func dedupeDocumentSnapshots(db *gorm.DB) error {
+	migrator := db.Migrator()
+	snapshot := &models.DocumentSnapshot{}
+	if !migrator.HasTable(snapshot) || migrator.HasIndex(snapshot, "idx_document_snapshots_room") {
+		return nil
+	}
+
+	err := db.Exec(`DELETE FROM document_snapshots older USING document_snapshots newer
+		WHERE older.room_id = newer.room_id
+		AND (older.created_at, older.id) < (newer.created_at, newer.id)`).Error
+	if err != nil {
+		return fmt.Errorf("deduplicate document snapshots: %w", err)
+	}
+	if migrator.HasIndex(snapshot, "idx_document_snapshots_room_id") {
+		if err := migrator.DropIndex(snapshot, "idx_document_snapshots_room_id"); err != nil {
+			return fmt.Errorf("drop document snapshot index: %w", err)
+		}
+	}
+	return nil
+}
+
+// Status reports an error if a table of the schema is missing
+func Status(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, model := range schema() {
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("table for %T is missing", model)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actor types of audit events
+const (
+	AuditActorAdmin       = "admin"       // a caller holding the admin token
+	AuditActorParticipant = "participant" // a participant requesting a media token; ActorID is the participant ID
+	AuditActorAnonymous   = "anonymous"   // any other caller of the API, known by its address only
+)
+
+// AuditEvent records a state-changing or security-relevant call: who did what to which target,
+// from where, and with what effect. Rows are only ever inserted.
+type AuditEvent struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OccurredAt     time.Time  `gorm:"not null;index:idx_audit_events_org_time,priority:2"`
+	OrganizationID *uuid.UUID `gorm:"type:uuid;index:idx_audit_events_org_time,priority:1"`
+	ActorType      string     `gorm:"type:varchar(20);not null"`
+	ActorID        string     `gorm:"type:varchar(255)"`
+	Action         string     `gorm:"type:varchar(100);not null"`
+	TargetType     string     `gorm:"type:varchar(50)"`
+	TargetID       string     `gorm:"type:varchar(255)"`
+	Status         int        `gorm:"not null"` // HTTP status of the call
+	IP             string     `gorm:"type:varchar(64)"`
+	UserAgent      string     `gorm:"type:text"`
+	RequestID      string     `gorm:"type:varchar(128)"`
+	Diff           AuditDiff  `gorm:"type:jsonb;serializer:json"`
+}
+
+// AuditDiff maps changed fields to their old and new values
+type AuditDiff map[string]FieldChange
+
+type FieldChange struct {
+	Old any `json:"old,omitempty"`
+	New any `json:"new,omitempty"`
+}
+
+// AuditFilter selects audit events of an organization; zero fields match everything
+type AuditFilter struct {
+	OrganizationID uuid.UUID
+	Action         string
+	ActorID        string
+	TargetType     string
+	TargetID       string
+	From           *time.Time // inclusive
+	To             *time.Time // exclusive
+}
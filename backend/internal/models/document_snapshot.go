@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentSnapshot is the durable copy of a room's Y.js document state, one per room. It is
+// written when the room expires, before its state is removed from Redis, and when the server
+// shuts down; each write replaces the one before.
+type DocumentSnapshot struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RoomID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_document_snapshots_room"`
+	State     []byte    `gorm:"type:bytea;not null"`
+	Size      int       `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time // when the state was last replaced
+
+	Room Room `gorm:"foreignKey:RoomID"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "gorm.io/gorm"
+
+// UniqueViolation is a write refused because another row already has the value of a unique
+// field. Field names it when the repository knows which one, e.g. "short_code". It matches
+// gorm.ErrDuplicatedKey.
+type UniqueViolation struct {
+	Field      string
+	Constraint string
+}
+
+func (e *UniqueViolation) Error() string {
+	if e.Field == "" {
+		return "duplicate key violates unique constraint " + e.Constraint
+	}
+	return "duplicate " + e.Field
+}
+
+func (e *UniqueViolation) Is(target error) bool {
+	return target == gorm.ErrDuplicatedKey
+}
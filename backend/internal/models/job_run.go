@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning   JobRunStatus = "running"
+	JobRunStatusSucceeded JobRunStatus = "succeeded"
+	JobRunStatusFailed    JobRunStatus = "failed"
+)
+
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule"
+	JobTriggerManual   JobTrigger = "manual"
+)
+
+// JobRun is one execution of a background job, kept as run history.
+type JobRun struct {
+	ID            uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	JobName       string       `gorm:"type:varchar(100);not null;index"`
+	Target        *string      `gorm:"type:varchar(255);index"`
+	Trigger       JobTrigger   `gorm:"type:varchar(20);not null"`
+	Instance      string       `gorm:"type:varchar(255);not null"`
+	Status        JobRunStatus `gorm:"type:varchar(20);not null"`
+	StartedAt     time.Time    `gorm:"not null;index"`
+	FinishedAt    *time.Time
+	RoomsAffected int     `gorm:"default:0"`
+	Error         *string `gorm:"type:text"`
+}
@@ -14,8 +14,57 @@ type Organization struct {
 	Description string
 	OwnerID     *string `gorm:"type:varchar(255)"`
 	Settings    JSONB   `gorm:"type:jsonb"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// Slug names the organization in the vanity links of its rooms, /r/<slug>/<room slug>
+	Slug *string `gorm:"type:varchar(255);uniqueIndex"`
+	// ArchiveRetentionDays overrides how long archived rooms are kept; nil uses the server default
+	ArchiveRetentionDays *int
+	// WSLimits overrides the server's websocket limits for the organization's rooms; nil uses the server defaults
+	WSLimits *WSLimits `gorm:"type:jsonb;serializer:json"`
+	// ShortCodes sets how the short codes of the organization's rooms look; nil uses the server default
+	ShortCodes *ShortCodeFormat `gorm:"type:jsonb;serializer:json"`
+	// DeletionRequestedAt marks the organization as being deleted; its data is removed by a background job
+	DeletionRequestedAt *time.Time `gorm:"index"`
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// WSLimits are per-organization overrides of websocket limits; nil fields keep the server default
+type WSLimits struct {
+	MessagesPerSecond *int `json:"messages_per_second,omitempty"`
+	BytesPerSecond    *int `json:"bytes_per_second,omitempty"`
+	MaxMessageBytes   *int `json:"max_message_bytes,omitempty"`
+	MaxClientsPerRoom *int `json:"max_clients_per_room,omitempty"`
+	MaxDocumentBytes  *int `json:"max_document_bytes,omitempty"`
+}
+
+// OrganizationSlugRedirect keeps a slug an organization no longer has, so vanity links with it
+// still lead to the organization's rooms until another organization takes the slug
+type OrganizationSlugRedirect struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Slug           string    `gorm:"type:varchar(255);not null;uniqueIndex"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;index"`
+	CreatedAt      time.Time
+}
+
+// Short code strategies
+const (
+	ShortCodeRandom = "random" // random characters from an alphabet, e.g. abc-defg-hij
+	ShortCodeWords  = "words"  // words from a list, e.g. amber-fox-river
+)
+
+// ShortCodeFormat is how an organization's room codes are generated; zero fields use the defaults
+type ShortCodeFormat struct {
+	Strategy  string   `json:"strategy"`
+	Length    int      `json:"length,omitempty"`     // random: number of characters
+	Alphabet  string   `json:"alphabet,omitempty"`   // random: characters to draw from
+	Words     []string `json:"words,omitempty"`      // words: list to draw from instead of the built-in one
+	WordCount int      `json:"word_count,omitempty"` // words: words per code
+}
+
+// OrganizationFilter selects organizations; nil and zero fields match everything
+type OrganizationFilter struct {
+	NamePrefix string // case-insensitive
+	Deleting   *bool  // deletion requested
 }
 
 type JSONB map[string]interface{}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent is a domain event, written in the transaction of the change it describes and handed
+// to the subscribers of the event bus afterwards. Pending lists the subscribers yet to handle it;
+// the event is processed once none are left or its attempts run out.
+type OutboxEvent struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key"` // assigned by the bus; subscribers deduplicate by it
+	Type           string     `gorm:"type:varchar(100);not null"`
+	RoomID         uuid.UUID  `gorm:"type:uuid;not null;index"`
+	OrganizationID *uuid.UUID `gorm:"type:uuid"`
+	Payload        []byte     `gorm:"type:jsonb;not null"`
+	OccurredAt     time.Time  `gorm:"not null"`
+	Pending        []string   `gorm:"type:jsonb;serializer:json;not null"`
+	Attempts       int        `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_outbox_events_due,where:processed_at IS NULL"`
+	LastError      *string    `gorm:"type:text"`
+	ProcessedAt    *time.Time `gorm:"index"`
+}
@@ -27,3 +27,10 @@ type Participant struct {
 
 	Room Room `gorm:"foreignKey:RoomID"`
 }
+
+// ParticipantFilter selects participants of a room; nil and zero fields match everything
+type ParticipantFilter struct {
+	RoomID uuid.UUID
+	Role   ParticipantRole
+	Active *bool // still in the room, i.e. not left
+}
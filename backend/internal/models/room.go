@@ -15,19 +15,49 @@ const (
 	RoomTypeStreaming      RoomType = "streaming"
 )
 
+type RoomStatus string
+
+const (
+	RoomStatusActive   RoomStatus = "active"
+	RoomStatusArchived RoomStatus = "archived"
+)
+
 type Room struct {
 	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	OrganizationID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	OrganizationID  uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_rooms_organization_slug,priority:1"`
 	Name            string    `gorm:"not null"`
-	Slug            *string   `gorm:"type:varchar(255);unique"`
-	ShortCode       *string   `gorm:"type:varchar(12);unique;index"`
+	Slug            *string   `gorm:"type:varchar(255);uniqueIndex:idx_rooms_organization_slug,priority:2"`
+	ShortCode       *string   `gorm:"type:varchar(64);unique;index"`
 	RoomType        RoomType  `gorm:"type:varchar(50);not null;default:'conference_hall'"`
 	IsTemporary     bool      `gorm:"default:true"`
 	ExpiresAt       *time.Time
-	LiveKitRoomName string `gorm:"type:varchar(255);not null"`
-	Settings        JSONB  `gorm:"type:jsonb"`
+	Status          RoomStatus `gorm:"type:varchar(20);not null;default:'active';index"`
+	ArchivedAt      *time.Time `gorm:"index"`
+	LiveKitRoomName string     `gorm:"type:varchar(255);not null"`
+	Settings        JSONB      `gorm:"type:jsonb"`
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 
 	Organization Organization `gorm:"foreignKey:OrganizationID"`
 }
+
+// RoomSlugRedirect keeps a slug a room no longer has, so links with it still lead to the room
+// until another room of the organization takes the slug
+type RoomSlugRedirect struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_room_slug_redirects_organization_slug,priority:1"`
+	Slug           string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_room_slug_redirects_organization_slug,priority:2"`
+	RoomID         uuid.UUID `gorm:"type:uuid;not null;index"`
+	CreatedAt      time.Time
+}
+
+// RoomFilter selects rooms of an organization; nil and zero fields match everything
+type RoomFilter struct {
+	OrganizationID uuid.UUID
+	Status         RoomStatus
+	RoomType       RoomType
+	IsTemporary    *bool
+	// Expired selects rooms whose expiry time has passed, or, when false, rooms without one or not yet expired
+	Expired    *bool
+	NamePrefix string // case-insensitive
+}
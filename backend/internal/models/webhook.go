@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEndpoint is a URL an organization registered to receive events it subscribed to
+type WebhookEndpoint struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationID uuid.UUID `gorm:"type:uuid;not null;index"`
+	URL            string    `gorm:"type:text;not null"`
+	// Secret signs deliveries (HMAC-SHA256); it is shown once, when the endpoint is created
+	Secret    string   `gorm:"type:varchar(100);not null"`
+	Events    []string `gorm:"type:jsonb;serializer:json;not null"`
+	Enabled   bool     `gorm:"not null;default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Organization Organization `gorm:"foreignKey:OrganizationID"`
+}
+
+// Subscribed reports whether the endpoint wants events of the given type
+func (e *WebhookEndpoint) Subscribed(eventType string) bool {
+	for _, t := range e.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event to send to one endpoint. Pending deliveries are the outbox the
+// dispatcher works through; finished ones are the endpoint's delivery log.
+type WebhookDelivery struct {
+	ID             uuid.UUID             `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EndpointID     uuid.UUID             `gorm:"type:uuid;not null;index"`
+	OrganizationID uuid.UUID             `gorm:"type:uuid;not null"`
+	EventID        uuid.UUID             `gorm:"type:uuid;not null;index"` // shared by redeliveries of the same event
+	EventType      string                `gorm:"type:varchar(100);not null"`
+	Payload        []byte                `gorm:"type:jsonb;not null"` // request body, identical on every attempt
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int                   `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `gorm:"not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastAttemptAt  *time.Time
+	ResponseStatus *int
+	ResponseBody   *string `gorm:"type:text"`
+	LastError      *string `gorm:"type:text"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	Endpoint WebhookEndpoint `gorm:"foreignKey:EndpointID"`
+}
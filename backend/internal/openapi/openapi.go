@@ -0,0 +1,31 @@
+// Package openapi holds the OpenAPI 3 document of the REST API. The document is written by
+// hand next to the handlers; the route contract test keeps the two in step and the Go client
+// in pkg/apiclient is generated from it.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Spec returns the document as written
+func Spec() []byte {
+	return spec
+}
+
+// Document returns the document with the websocket message catalogue appended under x-websocket
+func Document(websocketSchema map[string]any) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+	doc["x-websocket"] = map[string]any{
+		"path":        "/ws",
+		"description": "Messages exchanged over the websocket connection. Clients connect with the room_id and user_id query parameters, add last_seq to resume, and pick the protocol version through Sec-WebSocket-Protocol.",
+		"messages":    websocketSchema,
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,134 @@
+// Package pagination implements keyset pagination of listings sorted by one field, with ties
+// broken by ID, and the opaque cursors clients page with.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"nonza/backend/internal/apperrors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCursor = apperrors.InvalidField("cursor", "invalid_cursor", "invalid cursor")
+	ErrInvalidSort   = apperrors.InvalidField("sort", "invalid_sort", "unknown sort field")
+)
+
+// Kind is the type of a sort field's values
+type Kind int
+
+const (
+	KindTime Kind = iota
+	KindString
+)
+
+// Fields are the sortable fields of a listing, by column name
+type Fields map[string]Kind
+
+// Sort orders a listing by a column; the ID breaks ties in the same direction
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// String is the query form of the sort: the field name, prefixed with "-" when descending
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Position is where a row stands in a sorted listing
+type Position struct {
+	Value any // time.Time or string, after the sort field's kind
+	ID    uuid.UUID
+}
+
+// Page selects up to Limit rows following After, nil for the first page
+type Page struct {
+	Sort  Sort
+	After *Position
+	Limit int
+}
+
+// Lookahead is the page with one more row, which tells whether a next page exists
+func (p Page) Lookahead() Page {
+	p.Limit++
+	return p
+}
+
+// Parse builds a page from the sort and cursor query parameters. An empty sort takes
+// defaultSort; a cursor is only valid with the sort it was issued for.
+func Parse(sort, cursor string, limit int, fields Fields, defaultSort string) (Page, error) {
+	if sort == "" {
+		sort = defaultSort
+	}
+	field, desc := strings.CutPrefix(sort, "-")
+	kind, ok := fields[field]
+	if !ok {
+		return Page{}, ErrInvalidSort
+	}
+
+	page := Page{Sort: Sort{Field: field, Desc: desc}, Limit: limit}
+	if cursor != "" {
+		after, err := decode(cursor, page.Sort, kind)
+		if err != nil {
+			return Page{}, err
+		}
+		page.After = after
+	}
+	return page, nil
+}
+
+// Cut trims rows fetched with the page's Lookahead to the page and returns the cursor of the
+// next page, empty if this is the last one
+func Cut[T any](rows []T, page Page, position func(*T) Position) ([]T, string) {
+	if len(rows) <= page.Limit {
+		return rows, ""
+	}
+	rows = rows[:page.Limit]
+	return rows, encode(page.Sort, position(&rows[len(rows)-1]))
+}
+
+type cursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func encode(sort Sort, pos Position) string {
+	c := cursor{Sort: sort.String(), ID: pos.ID}
+	switch v := pos.Value.(type) {
+	case time.Time:
+		c.Value = v.UTC().Format(time.RFC3339Nano)
+	case string:
+		c.Value = v
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(raw string, sort Sort, kind Kind) (*Position, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort.String() || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	pos := &Position{Value: c.Value, ID: c.ID}
+	if kind == KindTime {
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		pos.Value = t
+	}
+	return pos, nil
+}
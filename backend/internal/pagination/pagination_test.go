@@ -0,0 +1,57 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var fields = Fields{"created_at": KindTime, "name": KindString}
+
+func TestCursorFollowsSort(t *testing.T) {
+	type row struct {
+		name string
+		id   uuid.UUID
+	}
+	rows := []row{{"a", uuid.New()}, {"b", uuid.New()}, {"c", uuid.New()}}
+
+	page, err := Parse("name", "", 2, fields, "-created_at")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, next := Cut(rows, page, func(r *row) Position { return Position{Value: r.name, ID: r.id} })
+	if len(items) != 2 || next == "" {
+		t.Fatalf("got %d items and cursor %q, want 2 and a cursor", len(items), next)
+	}
+
+	page, err = Parse("name", next, 2, fields, "-created_at")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.After == nil || page.After.Value != "b" || page.After.ID != rows[1].id {
+		t.Errorf("cursor decoded to %+v, want the position of b", page.After)
+	}
+
+	if _, err := Parse("-name", next, 2, fields, "-created_at"); err != ErrInvalidCursor {
+		t.Errorf("cursor with another sort: %v, want ErrInvalidCursor", err)
+	}
+	if _, err := Parse("id", "", 2, fields, "-created_at"); err != ErrInvalidSort {
+		t.Errorf("unknown sort field: %v, want ErrInvalidSort", err)
+	}
+}
+
+func TestTimeCursor(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	id := uuid.New()
+	page := Page{Sort: Sort{Field: "created_at", Desc: true}, Limit: 1}
+
+	_, next := Cut([]int{1, 2}, page, func(*int) Position { return Position{Value: at, ID: id} })
+	got, err := Parse("", next, 1, fields, "-created_at")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := got.After.Value.(time.Time); !ok || !v.Equal(at) {
+		t.Errorf("cursor value %v, want %v", got.After.Value, at)
+	}
+}
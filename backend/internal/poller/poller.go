@@ -0,0 +1,95 @@
+// Package poller runs the dispatch loop of the Postgres outboxes: claim a batch of due rows
+// under a lease, hand it over, and poll again. Rows whose handling is cut short stay claimed
+// until their lease runs out, then any instance picks them up.
+package poller
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Claim locks up to limit rows due at now, hiding them from other instances for lease
+type Claim[T any] func(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]T, error)
+
+// Handle processes a claimed batch and saves the outcome of each row. ctx is cancelled when the
+// poller stops; rows it leaves unsaved are picked up again when their lease runs out.
+type Handle[T any] func(ctx context.Context, rows []T)
+
+// Config configures a poller; all fields but Wake are required
+type Config[T any] struct {
+	Name      string        // what is claimed, for logs, e.g. "outbox events"
+	Interval  time.Duration // how often due rows are polled for
+	BatchSize int           // how many rows one poll claims
+	Lease     time.Duration // how long claimed rows are hidden from other instances; must outlast handling
+	Claim     Claim[T]
+	Handle    Handle[T]
+	// Wake, if set, triggers a poll without waiting for the interval, e.g. when rows were written on this instance
+	Wake <-chan struct{}
+	Log  *slog.Logger
+}
+
+type Poller[T any] struct {
+	cfg    Config[T]
+	cancel context.CancelFunc // Stops the loop; set by Start
+	done   chan struct{}      // Closed when the loop has returned
+}
+
+func New[T any](cfg Config[T]) *Poller[T] {
+	return &Poller[T]{cfg: cfg, done: make(chan struct{})}
+}
+
+// Start begins polling
+func (p *Poller[T]) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	go p.run(ctx)
+}
+
+// Stop ends polling and waits for the batch being handled
+func (p *Poller[T]) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
+
+func (p *Poller[T]) run(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch means more may be due; a backlog drains without waiting for ticks
+		if p.Poll(ctx) == p.cfg.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.cfg.Wake:
+		}
+	}
+}
+
+// Poll claims a batch of due rows and handles it. Returns how many were claimed.
+func (p *Poller[T]) Poll(ctx context.Context) int {
+	if ctx.Err() != nil {
+		return 0
+	}
+
+	rows, err := p.cfg.Claim(ctx, time.Now(), p.cfg.BatchSize, p.cfg.Lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.cfg.Log.Warn("Failed to claim "+p.cfg.Name, slog.Any("error", err))
+		}
+		return 0
+	}
+	if len(rows) > 0 {
+		p.cfg.Handle(ctx, rows)
+	}
+	return len(rows)
+}
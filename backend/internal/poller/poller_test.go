@@ -0,0 +1,39 @@
+package poller
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBacklogDrainsWithoutWaitingForTicks(t *testing.T) {
+	var backlog, handled atomic.Int64
+	backlog.Store(25)
+
+	p := New(Config[int]{
+		Name:      "rows",
+		Interval:  time.Hour,
+		BatchSize: 10,
+		Lease:     time.Minute,
+		Claim: func(_ context.Context, _ time.Time, limit int, _ time.Duration) ([]int, error) {
+			n := min(int(backlog.Load()), limit)
+			backlog.Add(int64(-n))
+			return make([]int, n), nil
+		},
+		Handle: func(_ context.Context, rows []int) { handled.Add(int64(len(rows))) },
+		Log:    slog.New(slog.DiscardHandler),
+	})
+	p.Start()
+
+	deadline := time.Now().Add(time.Second)
+	for handled.Load() < 25 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	p.Stop()
+
+	if got := handled.Load(); got != 25 {
+		t.Errorf("handled %d of 25 rows before the first tick, want the whole backlog", got)
+	}
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
+)
+
+// AuditEvents is append-only: events can be recorded and read, never changed
+type AuditEvents interface {
+	Create(ctx context.Context, event *models.AuditEvent) error
+	List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error)
+}
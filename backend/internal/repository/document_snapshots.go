@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"nonza/backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type DocumentSnapshots interface {
+	// Save writes the room's snapshot, replacing the one it had
+	Save(ctx context.Context, snapshot *models.DocumentSnapshot) error
+	GetByRoomID(ctx context.Context, roomID uuid.UUID) (*models.DocumentSnapshot, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"nonza/backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type JobRuns interface {
+	Create(ctx context.Context, run *models.JobRun) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.JobRun, error)
+	GetByJobName(ctx context.Context, jobName string, limit int) ([]models.JobRun, error)
+	GetLatestByTarget(ctx context.Context, jobName, target string) (*models.JobRun, error)
+	Update(ctx context.Context, run *models.JobRun) error
+	UpdateProgress(ctx context.Context, id uuid.UUID, roomsAffected int) error
+}
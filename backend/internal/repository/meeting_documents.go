@@ -1,14 +1,15 @@
 package repository
 
 import (
+	"context"
 	"nonza/backend/internal/models"
 
 	"github.com/google/uuid"
 )
 
 type MeetingDocuments interface {
-	Create(doc *models.MeetingDocument) error
-	GetByRoomID(roomID uuid.UUID) (*models.MeetingDocument, error)
-	Update(doc *models.MeetingDocument) error
-	IncrementVersion(roomID uuid.UUID) error
+	Create(ctx context.Context, doc *models.MeetingDocument) error
+	GetByRoomID(ctx context.Context, roomID uuid.UUID) (*models.MeetingDocument, error)
+	Update(ctx context.Context, doc *models.MeetingDocument) error
+	IncrementVersion(ctx context.Context, roomID uuid.UUID) error
 }
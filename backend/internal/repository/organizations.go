@@ -1,14 +1,24 @@
 package repository
 
 import (
+	"context"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Organizations interface {
-	Create(org *models.Organization) error
-	GetByID(id uuid.UUID) (*models.Organization, error)
-	Update(org *models.Organization) error
-	Delete(id uuid.UUID) error
+	Create(ctx context.Context, org *models.Organization) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	GetBySlug(ctx context.Context, slug string) (*models.Organization, error)
+	GetSlugRedirect(ctx context.Context, slug string) (*models.OrganizationSlugRedirect, error)
+	// SaveSlugRedirect points the slug at the organization, replacing where it pointed before
+	SaveSlugRedirect(ctx context.Context, redirect *models.OrganizationSlugRedirect) error
+	DeleteSlugRedirect(ctx context.Context, slug string) error
+	List(ctx context.Context, filter models.OrganizationFilter, page pagination.Page) ([]models.Organization, error)
+	Update(ctx context.Context, org *models.Organization) error
+	MarkForDeletion(ctx context.Context, id uuid.UUID, at time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"nonza/backend/internal/models"
+	"time"
+)
+
+type Outbox interface {
+	Create(ctx context.Context, events []models.OutboxEvent) error
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	Update(ctx context.Context, event *models.OutboxEvent) error
+	DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error)
+}
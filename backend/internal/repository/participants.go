@@ -1,15 +1,18 @@
 package repository
 
 import (
+	"context"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
 
 	"github.com/google/uuid"
 )
 
 type Participants interface {
-	Create(participant *models.Participant) error
-	GetByID(id uuid.UUID) (*models.Participant, error)
-	GetByRoomID(roomID uuid.UUID) ([]models.Participant, error)
-	Update(participant *models.Participant) error
-	Delete(id uuid.UUID) error
+	Create(ctx context.Context, participant *models.Participant) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Participant, error)
+	GetByRoomID(ctx context.Context, roomID uuid.UUID) ([]models.Participant, error)
+	List(ctx context.Context, filter models.ParticipantFilter, page pagination.Page) ([]models.Participant, error)
+	Update(ctx context.Context, participant *models.Participant) error
+	Delete(ctx context.Context, id uuid.UUID) error
 }
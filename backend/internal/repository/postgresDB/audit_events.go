@@ -0,0 +1,48 @@
+package postgresDB
+
+import (
+	"context"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
+
+	"gorm.io/gorm"
+)
+
+type AuditEventsRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditEventsRepository(db *gorm.DB) *AuditEventsRepository {
+	return &AuditEventsRepository{db: db}
+}
+
+func (r *AuditEventsRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// List returns a page of the events matching the filter
+func (r *AuditEventsRepository) List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error) {
+	query := r.db.WithContext(ctx).Where("organization_id = ?", filter.OrganizationID)
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("occurred_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("occurred_at < ?", *filter.To)
+	}
+
+	var events []models.AuditEvent
+	err := paginate(query, "audit_events", page).Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,35 @@
+package postgresDB
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// claimDue claims up to limit rows of an outbox table: it locks the due rows query selects,
+// skipping rows other instances are claiming, and moves their next_attempt_at past the lease so
+// no other instance claims them while they are handled. lease is called on each claimed row
+// with the new next_attempt_at, to set it on the row and return the row's ID.
+func claimDue[T any](ctx context.Context, db *gorm.DB, now time.Time, limit int, lease time.Duration,
+	query func(*gorm.DB) *gorm.DB, claim func(row *T, until time.Time) uuid.UUID) ([]T, error) {
+	var rows []T
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := query(tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})).
+			Limit(limit).
+			Find(&rows).Error
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+
+		until := now.Add(lease)
+		ids := make([]uuid.UUID, len(rows))
+		for i := range rows {
+			ids[i] = claim(&rows[i], until)
+		}
+		return tx.Model(new(T)).Where("id IN ?", ids).Update("next_attempt_at", until).Error
+	})
+	return rows, err
+}
@@ -0,0 +1,34 @@
+package postgresDB
+
+import (
+	"context"
+	"nonza/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DocumentSnapshotsRepository struct {
+	db *gorm.DB
+}
+
+func NewDocumentSnapshotsRepository(db *gorm.DB) *DocumentSnapshotsRepository {
+	return &DocumentSnapshotsRepository{db: db}
+}
+
+func (r *DocumentSnapshotsRepository) Save(ctx context.Context, snapshot *models.DocumentSnapshot) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "room_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"state", "size", "updated_at"}),
+	}).Create(snapshot).Error
+}
+
+func (r *DocumentSnapshotsRepository) GetByRoomID(ctx context.Context, roomID uuid.UUID) (*models.DocumentSnapshot, error) {
+	var snapshot models.DocumentSnapshot
+	err := r.db.WithContext(ctx).Where("room_id = ?", roomID).First(&snapshot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,29 @@
+package postgresDB
+
+import (
+	"errors"
+	"nonza/backend/internal/models"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolationCode is the SQLSTATE of a unique constraint violation
+const uniqueViolationCode = "23505"
+
+// duplicate returns a unique violation as a *models.UniqueViolation naming the first of fields
+// that the violated constraint or index is named after. Other errors are returned as they are.
+func duplicate(err error, fields ...string) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolationCode {
+		return err
+	}
+	violation := &models.UniqueViolation{Constraint: pgErr.ConstraintName}
+	for _, field := range fields {
+		if strings.Contains(pgErr.ConstraintName, field) {
+			violation.Field = field
+			break
+		}
+	}
+	return violation
+}
@@ -0,0 +1,62 @@
+package postgresDB
+
+import (
+	"context"
+	"nonza/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobRunsRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRunsRepository(db *gorm.DB) *JobRunsRepository {
+	return &JobRunsRepository{db: db}
+}
+
+func (r *JobRunsRepository) Create(ctx context.Context, run *models.JobRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *JobRunsRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.JobRun, error) {
+	var run models.JobRun
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetByJobName returns the latest runs of a job, newest first
+func (r *JobRunsRepository) GetByJobName(ctx context.Context, jobName string, limit int) ([]models.JobRun, error) {
+	var runs []models.JobRun
+	err := r.db.WithContext(ctx).Where("job_name = ?", jobName).
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
+
+func (r *JobRunsRepository) GetLatestByTarget(ctx context.Context, jobName, target string) (*models.JobRun, error) {
+	var run models.JobRun
+	err := r.db.WithContext(ctx).Where("job_name = ? AND target = ?", jobName, target).
+		Order("started_at DESC").
+		First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *JobRunsRepository) Update(ctx context.Context, run *models.JobRun) error {
+	return r.db.WithContext(ctx).Save(run).Error
+}
+
+// UpdateProgress records intermediate progress of a running job
+func (r *JobRunsRepository) UpdateProgress(ctx context.Context, id uuid.UUID, roomsAffected int) error {
+	return r.db.WithContext(ctx).Model(&models.JobRun{}).
+		Where("id = ?", id).
+		Update("rooms_affected", roomsAffected).Error
+}
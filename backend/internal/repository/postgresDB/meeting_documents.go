@@ -1,6 +1,7 @@
 package postgresDB
 
 import (
+	"context"
 	"nonza/backend/internal/models"
 
 	"github.com/google/uuid"
@@ -15,25 +16,25 @@ func NewMeetingDocumentsRepository(db *gorm.DB) *MeetingDocumentsRepository {
 	return &MeetingDocumentsRepository{db: db}
 }
 
-func (r *MeetingDocumentsRepository) Create(doc *models.MeetingDocument) error {
-	return r.db.Create(doc).Error
+func (r *MeetingDocumentsRepository) Create(ctx context.Context, doc *models.MeetingDocument) error {
+	return r.db.WithContext(ctx).Create(doc).Error
 }
 
-func (r *MeetingDocumentsRepository) GetByRoomID(roomID uuid.UUID) (*models.MeetingDocument, error) {
+func (r *MeetingDocumentsRepository) GetByRoomID(ctx context.Context, roomID uuid.UUID) (*models.MeetingDocument, error) {
 	var doc models.MeetingDocument
-	err := r.db.Where("room_id = ?", roomID).First(&doc).Error
+	err := r.db.WithContext(ctx).Where("room_id = ?", roomID).First(&doc).Error
 	if err != nil {
 		return nil, err
 	}
 	return &doc, nil
 }
 
-func (r *MeetingDocumentsRepository) Update(doc *models.MeetingDocument) error {
-	return r.db.Save(doc).Error
+func (r *MeetingDocumentsRepository) Update(ctx context.Context, doc *models.MeetingDocument) error {
+	return r.db.WithContext(ctx).Save(doc).Error
 }
 
-func (r *MeetingDocumentsRepository) IncrementVersion(roomID uuid.UUID) error {
-	return r.db.Model(&models.MeetingDocument{}).
+func (r *MeetingDocumentsRepository) IncrementVersion(ctx context.Context, roomID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.MeetingDocument{}).
 		Where("room_id = ?", roomID).
 		Update("version", gorm.Expr("version + 1")).Error
 }
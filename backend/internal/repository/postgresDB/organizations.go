@@ -1,11 +1,14 @@
 package postgresDB
 
 import (
-	"log"
+	"context"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrganizationsRepository struct {
@@ -16,45 +19,91 @@ func NewOrganizationsRepository(db *gorm.DB) *OrganizationsRepository {
 	return &OrganizationsRepository{db: db}
 }
 
-func (r *OrganizationsRepository) Create(org *models.Organization) error {
-	log.Printf("[OrganizationsRepository] Create called: name=%s", org.Name)
-	
-	// Check database connection
-	sqlDB, err := r.db.DB()
+func (r *OrganizationsRepository) Create(ctx context.Context, org *models.Organization) error {
+	return duplicate(r.db.WithContext(ctx).Create(org).Error, "slug")
+}
+
+func (r *OrganizationsRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&org).Error
 	if err != nil {
-		log.Printf("[OrganizationsRepository] Failed to get underlying sql.DB: %v", err)
-		return err
-	}
-	
-	if err := sqlDB.Ping(); err != nil {
-		log.Printf("[OrganizationsRepository] Database ping failed: %v", err)
-		return err
-	}
-	log.Printf("[OrganizationsRepository] Database connection OK")
-	
-	log.Printf("[OrganizationsRepository] Executing INSERT for organization: name=%s", org.Name)
-	if err := r.db.Create(org).Error; err != nil {
-		log.Printf("[OrganizationsRepository] INSERT failed: %v", err)
-		return err
+		return nil, err
 	}
-	
-	log.Printf("[OrganizationsRepository] Successfully created organization: id=%s", org.ID)
-	return nil
+	return &org, nil
 }
 
-func (r *OrganizationsRepository) GetByID(id uuid.UUID) (*models.Organization, error) {
+func (r *OrganizationsRepository) GetBySlug(ctx context.Context, slug string) (*models.Organization, error) {
 	var org models.Organization
-	err := r.db.Where("id = ?", id).First(&org).Error
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&org).Error
 	if err != nil {
 		return nil, err
 	}
 	return &org, nil
 }
 
-func (r *OrganizationsRepository) Update(org *models.Organization) error {
-	return r.db.Save(org).Error
+// List returns a page of the organizations matching the filter
+func (r *OrganizationsRepository) GetSlugRedirect(ctx context.Context, slug string) (*models.OrganizationSlugRedirect, error) {
+	var redirect models.OrganizationSlugRedirect
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&redirect).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redirect, nil
+}
+
+func (r *OrganizationsRepository) SaveSlugRedirect(ctx context.Context, redirect *models.OrganizationSlugRedirect) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"organization_id", "created_at"}),
+	}).Create(redirect).Error
+}
+
+func (r *OrganizationsRepository) DeleteSlugRedirect(ctx context.Context, slug string) error {
+	return r.db.WithContext(ctx).Where("slug = ?", slug).Delete(&models.OrganizationSlugRedirect{}).Error
+}
+
+func (r *OrganizationsRepository) List(ctx context.Context, filter models.OrganizationFilter, page pagination.Page) ([]models.Organization, error) {
+	query := r.db.WithContext(ctx)
+	if filter.NamePrefix != "" {
+		query = query.Where("organizations.name ILIKE ?", prefixPattern(filter.NamePrefix))
+	}
+	if filter.Deleting != nil {
+		if *filter.Deleting {
+			query = query.Where("organizations.deletion_requested_at IS NOT NULL")
+		} else {
+			query = query.Where("organizations.deletion_requested_at IS NULL")
+		}
+	}
+
+	var orgs []models.Organization
+	err := paginate(query, "organizations", page).Find(&orgs).Error
+	return orgs, err
+}
+
+func (r *OrganizationsRepository) Update(ctx context.Context, org *models.Organization) error {
+	return duplicate(r.db.WithContext(ctx).Save(org).Error, "slug")
+}
+
+// MarkForDeletion sets the deletion timestamp unless the organization is already marked
+func (r *OrganizationsRepository) MarkForDeletion(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Organization{}).
+		Where("id = ? AND deletion_requested_at IS NULL", id).
+		Update("deletion_requested_at", at).Error
 }
 
-func (r *OrganizationsRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Organization{}, "id = ?", id).Error
+// Delete removes the organization together with its webhook endpoints, their delivery log and
+// the redirects of its former slugs
+func (r *OrganizationsRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("organization_id = ?", id).Delete(&models.OrganizationSlugRedirect{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ?", id).Delete(&models.WebhookEndpoint{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Organization{}, "id = ?", id).Error
+	})
 }
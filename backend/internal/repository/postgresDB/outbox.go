@@ -0,0 +1,46 @@
+package postgresDB
+
+import (
+	"context"
+	"nonza/backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) Create(ctx context.Context, events []models.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&events).Error
+}
+
+// ClaimDue claims up to limit unprocessed events whose next attempt is due, oldest first.
+// Events whose handler dies are picked up again once the lease runs out.
+func (r *OutboxRepository) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	return claimDue(ctx, r.db, now, limit, lease, func(q *gorm.DB) *gorm.DB {
+		return q.Where("processed_at IS NULL AND next_attempt_at <= ?", now).Order("occurred_at")
+	}, func(event *models.OutboxEvent, until time.Time) uuid.UUID {
+		event.NextAttemptAt = until
+		return event.ID
+	})
+}
+
+func (r *OutboxRepository) Update(ctx context.Context, event *models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Save(event).Error
+}
+
+// DeleteProcessedBefore removes events processed before the given time
+func (r *OutboxRepository) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("processed_at < ?", before).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
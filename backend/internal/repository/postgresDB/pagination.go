@@ -0,0 +1,31 @@
+package postgresDB
+
+import (
+	"fmt"
+	"nonza/backend/internal/pagination"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// paginate applies the page's keyset condition, order and limit to a query. The sort field is one
+// of the listing's pagination.Fields, so it is safe to use as a column name.
+func paginate(query *gorm.DB, table string, page pagination.Page) *gorm.DB {
+	column := table + "." + page.Sort.Field
+	id := table + ".id"
+	op, dir := ">", "ASC"
+	if page.Sort.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if page.After != nil {
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", column, id, op), page.After.Value, page.After.ID)
+	}
+	return query.Order(fmt.Sprintf("%s %s, %s %s", column, dir, id, dir)).Limit(page.Limit)
+}
+
+// prefixPattern is a LIKE pattern matching strings that start with prefix
+func prefixPattern(prefix string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+	return escaped + "%"
+}
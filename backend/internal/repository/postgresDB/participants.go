@@ -1,7 +1,9 @@
 package postgresDB
 
 import (
+	"context"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -15,29 +17,48 @@ func NewParticipantsRepository(db *gorm.DB) *ParticipantsRepository {
 	return &ParticipantsRepository{db: db}
 }
 
-func (r *ParticipantsRepository) Create(participant *models.Participant) error {
-	return r.db.Create(participant).Error
+func (r *ParticipantsRepository) Create(ctx context.Context, participant *models.Participant) error {
+	return r.db.WithContext(ctx).Create(participant).Error
 }
 
-func (r *ParticipantsRepository) GetByID(id uuid.UUID) (*models.Participant, error) {
+func (r *ParticipantsRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Participant, error) {
 	var participant models.Participant
-	err := r.db.Where("id = ?", id).First(&participant).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&participant).Error
 	if err != nil {
 		return nil, err
 	}
 	return &participant, nil
 }
 
-func (r *ParticipantsRepository) GetByRoomID(roomID uuid.UUID) ([]models.Participant, error) {
+func (r *ParticipantsRepository) GetByRoomID(ctx context.Context, roomID uuid.UUID) ([]models.Participant, error) {
 	var participants []models.Participant
-	err := r.db.Where("room_id = ? AND left_at IS NULL", roomID).Find(&participants).Error
+	err := r.db.WithContext(ctx).Where("room_id = ? AND left_at IS NULL", roomID).Find(&participants).Error
 	return participants, err
 }
 
-func (r *ParticipantsRepository) Update(participant *models.Participant) error {
-	return r.db.Save(participant).Error
+// List returns a page of the room's participants matching the filter
+func (r *ParticipantsRepository) List(ctx context.Context, filter models.ParticipantFilter, page pagination.Page) ([]models.Participant, error) {
+	query := r.db.WithContext(ctx).Where("participants.room_id = ?", filter.RoomID)
+	if filter.Role != "" {
+		query = query.Where("participants.role = ?", filter.Role)
+	}
+	if filter.Active != nil {
+		if *filter.Active {
+			query = query.Where("participants.left_at IS NULL")
+		} else {
+			query = query.Where("participants.left_at IS NOT NULL")
+		}
+	}
+
+	var participants []models.Participant
+	err := paginate(query, "participants", page).Find(&participants).Error
+	return participants, err
+}
+
+func (r *ParticipantsRepository) Update(ctx context.Context, participant *models.Participant) error {
+	return r.db.WithContext(ctx).Save(participant).Error
 }
 
-func (r *ParticipantsRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Participant{}, "id = ?", id).Error
+func (r *ParticipantsRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Participant{}, "id = ?", id).Error
 }
@@ -2,12 +2,14 @@ package postgresDB
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"nonza/backend/internal/metrics"
 	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
 type Config struct {
@@ -19,19 +21,22 @@ type Config struct {
 	SSLMode       string
 	Environment   string
 	SlowThreshold time.Duration
+	Logger        *slog.Logger
 }
 
 func NewPostgresDB(cfg Config) (*gorm.DB, error) {
-	log.Printf("[PostgresDB] Initializing database connection")
-	log.Printf("[PostgresDB] Config: host=%s, port=%s, user=%s, dbname=%s, sslmode=%s", 
-		cfg.Host, cfg.Port, cfg.Username, cfg.DBName, cfg.SSLMode)
-	
+	log := cfg.Logger
+	if log == nil {
+		log = slog.Default()
+	}
+	log = log.With(slog.String("component", "postgres"))
+
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.DBName, cfg.SSLMode,
 	)
-	log.Printf("[PostgresDB] DSN: host=%s port=%s user=%s password=*** dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.Username, cfg.DBName, cfg.SSLMode)
+	log.Debug("Connecting to database", slog.String("host", cfg.Host), slog.String("port", cfg.Port),
+		slog.String("user", cfg.Username), slog.String("dbname", cfg.DBName), slog.String("sslmode", cfg.SSLMode))
 
 	var logLevel logger.LogLevel
 	if cfg.Environment == "local" {
@@ -40,37 +45,44 @@ func NewPostgresDB(cfg Config) (*gorm.DB, error) {
 		logLevel = logger.Error
 	}
 
-	log.Printf("[PostgresDB] Attempting to connect to database...")
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger: logger.New(slog.NewLogLogger(log.Handler(), slog.LevelDebug), logger.Config{
+			SlowThreshold:             cfg.SlowThreshold,
+			LogLevel:                  logLevel,
+			IgnoreRecordNotFoundError: true,
+		}),
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
 	})
 	if err != nil {
-		log.Printf("[PostgresDB] Failed to connect to database: %v", err)
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
-	log.Printf("[PostgresDB] Successfully opened GORM connection")
+
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register metrics plugin: %w", err)
+	}
+
+	// Query latency is already exported by the metrics plugin; only spans are needed here
+	if err := db.Use(tracing.NewPlugin(tracing.WithoutMetrics())); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Printf("[PostgresDB] Failed to get sql.DB: %v", err)
 		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
 	}
 
-	log.Printf("[PostgresDB] Testing database connection with ping...")
 	if err := sqlDB.Ping(); err != nil {
-		log.Printf("[PostgresDB] Database ping failed: %v", err)
 		return nil, fmt.Errorf("database ping failed: %w", err)
 	}
-	log.Printf("[PostgresDB] Database ping successful")
 
 	sqlDB.SetMaxIdleConns(10)
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	log.Printf("[PostgresDB] Database connection pool configured: maxIdle=10, maxOpen=100, maxLifetime=1h")
+	log.Debug("Database connection pool configured",
+		slog.Int("max_idle", 10), slog.Int("max_open", 100), slog.Duration("max_lifetime", time.Hour))
 	return db, nil
 }
 
@@ -1,11 +1,14 @@
 package postgresDB
 
 import (
+	"context"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RoomsRepository struct {
@@ -16,60 +19,166 @@ func NewRoomsRepository(db *gorm.DB) *RoomsRepository {
 	return &RoomsRepository{db: db}
 }
 
-func (r *RoomsRepository) Create(room *models.Room) error {
-	return r.db.Create(room).Error
+func (r *RoomsRepository) Create(ctx context.Context, room *models.Room) error {
+	return duplicate(r.db.WithContext(ctx).Create(room).Error, "short_code", "slug")
 }
 
-func (r *RoomsRepository) GetByID(id uuid.UUID) (*models.Room, error) {
+func (r *RoomsRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Room, error) {
 	var room models.Room
-	err := r.db.Where("id = ?", id).First(&room).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&room).Error
 	if err != nil {
 		return nil, err
 	}
 	return &room, nil
 }
 
-func (r *RoomsRepository) GetByShortCode(shortCode string) (*models.Room, error) {
+func (r *RoomsRepository) GetByShortCode(ctx context.Context, shortCode string) (*models.Room, error) {
 	var room models.Room
-	err := r.db.Where("short_code = ?", shortCode).First(&room).Error
+	err := r.db.WithContext(ctx).Where("short_code = ?", shortCode).First(&room).Error
 	if err != nil {
 		return nil, err
 	}
 	return &room, nil
 }
 
-func (r *RoomsRepository) GetBySlug(slug string) (*models.Room, error) {
+func (r *RoomsRepository) GetBySlug(ctx context.Context, orgID uuid.UUID, slug string) (*models.Room, error) {
 	var room models.Room
-	err := r.db.Where("slug = ?", slug).First(&room).Error
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND slug = ?", orgID, slug).First(&room).Error
 	if err != nil {
 		return nil, err
 	}
 	return &room, nil
 }
 
-func (r *RoomsRepository) GetByOrganizationID(orgID uuid.UUID) ([]models.Room, error) {
+func (r *RoomsRepository) GetSlugRedirect(ctx context.Context, orgID uuid.UUID, slug string) (*models.RoomSlugRedirect, error) {
+	var redirect models.RoomSlugRedirect
+	err := r.db.WithContext(ctx).Where("organization_id = ? AND slug = ?", orgID, slug).First(&redirect).Error
+	if err != nil {
+		return nil, err
+	}
+	return &redirect, nil
+}
+
+func (r *RoomsRepository) SaveSlugRedirect(ctx context.Context, redirect *models.RoomSlugRedirect) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"room_id", "created_at"}),
+	}).Create(redirect).Error
+}
+
+func (r *RoomsRepository) DeleteSlugRedirect(ctx context.Context, orgID uuid.UUID, slug string) error {
+	return r.db.WithContext(ctx).Where("organization_id = ? AND slug = ?", orgID, slug).Delete(&models.RoomSlugRedirect{}).Error
+}
+
+// List returns a page of the organization's rooms matching the filter
+func (r *RoomsRepository) List(ctx context.Context, filter models.RoomFilter, page pagination.Page) ([]models.Room, error) {
+	query := r.db.WithContext(ctx).Where("rooms.organization_id = ?", filter.OrganizationID)
+	if filter.Status != "" {
+		query = query.Where("rooms.status = ?", filter.Status)
+	}
+	if filter.RoomType != "" {
+		query = query.Where("rooms.room_type = ?", filter.RoomType)
+	}
+	if filter.IsTemporary != nil {
+		query = query.Where("rooms.is_temporary = ?", *filter.IsTemporary)
+	}
+	if filter.Expired != nil {
+		if *filter.Expired {
+			query = query.Where("rooms.expires_at IS NOT NULL AND rooms.expires_at < ?", time.Now())
+		} else {
+			query = query.Where("(rooms.expires_at IS NULL OR rooms.expires_at >= ?)", time.Now())
+		}
+	}
+	if filter.NamePrefix != "" {
+		query = query.Where("rooms.name ILIKE ?", prefixPattern(filter.NamePrefix))
+	}
+
+	var rooms []models.Room
+	err := paginate(query, "rooms", page).Find(&rooms).Error
+	return rooms, err
+}
+
+// GetBatchByOrganizationID returns up to limit rooms of the organization in any status
+func (r *RoomsRepository) GetBatchByOrganizationID(ctx context.Context, orgID uuid.UUID, limit int) ([]models.Room, error) {
 	var rooms []models.Room
-	err := r.db.Where("organization_id = ?", orgID).Find(&rooms).Error
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("id").Limit(limit).Find(&rooms).Error
 	return rooms, err
 }
 
-func (r *RoomsRepository) Update(room *models.Room) error {
-	return r.db.Save(room).Error
+func (r *RoomsRepository) Update(ctx context.Context, room *models.Room) error {
+	return duplicate(r.db.WithContext(ctx).Omit(clause.Associations).Save(room).Error, "short_code", "slug")
 }
 
-func (r *RoomsRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Room{}, "id = ?", id).Error
+func (r *RoomsRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.Room{}, "id = ?", id).Error
 }
 
-func (r *RoomsRepository) DeleteExpired() error {
+// GetExpired returns list of active rooms whose expiration time has passed
+func (r *RoomsRepository) GetExpired(ctx context.Context) ([]models.Room, error) {
+	var rooms []models.Room
 	now := time.Now()
-	return r.db.Where("is_temporary = ? AND expires_at < ?", true, now).Delete(&models.Room{}).Error
+	err := r.db.WithContext(ctx).Where("status = ? AND expires_at IS NOT NULL AND expires_at < ?", models.RoomStatusActive, now).Find(&rooms).Error
+	return rooms, err
 }
 
-// GetExpired returns list of expired rooms (not deleted, just expired)
-func (r *RoomsRepository) GetExpired() ([]models.Room, error) {
+// GetExpiringBefore returns active rooms that have not expired yet but will by before
+func (r *RoomsRepository) GetExpiringBefore(ctx context.Context, before time.Time) ([]models.Room, error) {
 	var rooms []models.Room
-	now := time.Now()
-	err := r.db.Where("expires_at IS NOT NULL AND expires_at < ?", now).Find(&rooms).Error
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at >= ? AND expires_at <= ?", models.RoomStatusActive, time.Now(), before).
+		Find(&rooms).Error
+	return rooms, err
+}
+
+// Archive moves a room to the archived state
+func (r *RoomsRepository) Archive(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.Room{}).
+		Where("id = ? AND status = ?", id, models.RoomStatusActive).
+		Updates(map[string]interface{}{
+			"status":      models.RoomStatusArchived,
+			"archived_at": at,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetPurgeable returns archived rooms whose organization retention window has passed.
+// Organizations without their own retention use defaultRetentionDays.
+func (r *RoomsRepository) GetPurgeable(ctx context.Context, defaultRetentionDays int, now time.Time) ([]models.Room, error) {
+	var rooms []models.Room
+	err := r.db.WithContext(ctx).Joins("JOIN organizations ON organizations.id = rooms.organization_id").
+		Where("rooms.status = ?", models.RoomStatusArchived).
+		Where("rooms.archived_at + make_interval(days => COALESCE(organizations.archive_retention_days, ?)) < ?", defaultRetentionDays, now).
+		Find(&rooms).Error
 	return rooms, err
 }
+
+// DeletePermanently hard-deletes rooms together with their participants, documents and snapshots
+func (r *RoomsRepository) DeletePermanently(ctx context.Context, ids []uuid.UUID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		documentIDs := tx.Model(&models.MeetingDocument{}).Select("id").Where("room_id IN ?", ids)
+		if err := tx.Where("document_id IN (?)", documentIDs).Delete(&models.DocumentOperation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("room_id IN ?", ids).Delete(&models.MeetingDocument{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("room_id IN ?", ids).Delete(&models.Participant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("room_id IN ?", ids).Delete(&models.DocumentSnapshot{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("room_id IN ?", ids).Delete(&models.RoomSlugRedirect{}).Error; err != nil {
+			return err
+		}
+		res := tx.Where("id IN ?", ids).Delete(&models.Room{})
+		deleted = res.RowsAffected
+		return res.Error
+	})
+	return deleted, err
+}
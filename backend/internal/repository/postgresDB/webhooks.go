@@ -0,0 +1,94 @@
+package postgresDB
+
+import (
+	"context"
+	"nonza/backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhooksRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhooksRepository(db *gorm.DB) *WebhooksRepository {
+	return &WebhooksRepository{db: db}
+}
+
+func (r *WebhooksRepository) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	return r.db.WithContext(ctx).Create(endpoint).Error
+}
+
+func (r *WebhooksRepository) GetEndpoint(ctx context.Context, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&endpoint).Error
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *WebhooksRepository) GetEndpointsByOrganizationID(ctx context.Context, orgID uuid.UUID) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Order("created_at").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *WebhooksRepository) UpdateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	return r.db.WithContext(ctx).Save(endpoint).Error
+}
+
+// DeleteEndpoint removes the endpoint together with its delivery log
+func (r *WebhooksRepository) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("endpoint_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.WebhookEndpoint{}, "id = ?", id).Error
+	})
+}
+
+func (r *WebhooksRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&deliveries).Error
+}
+
+func (r *WebhooksRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetDeliveriesByEndpointID returns the endpoint's latest deliveries, newest first
+func (r *WebhooksRepository) GetDeliveriesByEndpointID(ctx context.Context, endpointID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("endpoint_id = ?", endpointID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDueDeliveries claims up to limit pending deliveries whose next attempt is due.
+// A delivery whose sender dies is retried once the lease runs out.
+func (r *WebhooksRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	return claimDue(ctx, r.db, now, limit, lease, func(q *gorm.DB) *gorm.DB {
+		return q.Preload("Endpoint").
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at")
+	}, func(delivery *models.WebhookDelivery, until time.Time) uuid.UUID {
+		delivery.NextAttemptAt = until
+		return delivery.ID
+	})
+}
+
+func (r *WebhooksRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Omit("Endpoint").Save(delivery).Error
+}
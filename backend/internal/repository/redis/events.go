@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RoomEvent is an entry of a room's event stream. ID is the Redis stream ID ("<ms>-<n>").
+type RoomEvent struct {
+	ID     string
+	RoomID string
+	Type   string
+	Data   []byte
+}
+
+// noRoomEvents is the ID that precedes every entry of a stream
+const noRoomEvents = "0-0"
+
+const roomEventsKeyPrefix = "room_events:"
+
+func roomEventsKey(roomID string) string {
+	return roomEventsKeyPrefix + roomID
+}
+
+func roomEventOnceKey(roomID, key string) string {
+	return fmt.Sprintf("room_events_once:%s:%s", roomID, key)
+}
+
+// AppendRoomEvent adds an event to the room's stream, keeping about limit entries that expire
+// ttl after the last event, and returns its ID
+func (c *Client) AppendRoomEvent(ctx context.Context, roomID, eventType string, data []byte, limit int64, ttl time.Duration) (string, error) {
+	key := roomEventsKey(roomID)
+	var add *redis.StringCmd
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		add = pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: key,
+			MaxLen: limit,
+			Approx: true,
+			Values: []any{"type", eventType, "data", data},
+		})
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return add.Val(), nil
+}
+
+// MarkRoomEventOnce records key for the room and reports whether it was new, so an event
+// published from several instances or job runs goes out once. The mark expires after ttl.
+func (c *Client) MarkRoomEventOnce(ctx context.Context, roomID, key string, ttl time.Duration) (bool, error) {
+	return c.rdb.SetNX(ctx, roomEventOnceKey(roomID, key), 1, ttl).Result()
+}
+
+// LastRoomEventID returns the ID of the room's newest event, or "0-0" if it has none
+func (c *Client) LastRoomEventID(ctx context.Context, roomID string) (string, error) {
+	entries, err := c.rdb.XRevRangeN(ctx, roomEventsKey(roomID), "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return noRoomEvents, nil
+	}
+	return entries[0].ID, nil
+}
+
+// RoomEventsBetween returns the room's events after the ID after up to and including upTo, oldest first.
+// The stream is capped, so the oldest events may be gone.
+func (c *Client) RoomEventsBetween(ctx context.Context, roomID, after, upTo string) ([]RoomEvent, error) {
+	entries, err := c.rdb.XRange(ctx, roomEventsKey(roomID), "("+after, upTo).Result()
+	if err != nil {
+		return nil, err
+	}
+	return toRoomEvents(roomID, entries), nil
+}
+
+// ReadRoomEvents waits up to block for events after the given ID of each room (roomID -> ID)
+// and returns them; nothing is returned if none arrived in time
+func (c *Client) ReadRoomEvents(ctx context.Context, after map[string]string, block time.Duration) ([]RoomEvent, error) {
+	streams := make([]string, 0, 2*len(after))
+	ids := make([]string, 0, len(after))
+	for roomID, id := range after {
+		streams = append(streams, roomEventsKey(roomID))
+		ids = append(ids, id)
+	}
+	streams = append(streams, ids...)
+
+	res, err := c.rdb.XRead(ctx, &redis.XReadArgs{Streams: streams, Block: block}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var events []RoomEvent
+	for _, stream := range res {
+		events = append(events, toRoomEvents(strings.TrimPrefix(stream.Stream, roomEventsKeyPrefix), stream.Messages)...)
+	}
+	return events, nil
+}
+
+func toRoomEvents(roomID string, entries []redis.XMessage) []RoomEvent {
+	events := make([]RoomEvent, 0, len(entries))
+	for _, entry := range entries {
+		eventType, _ := entry.Values["type"].(string)
+		data, _ := entry.Values["data"].(string)
+		events = append(events, RoomEvent{ID: entry.ID, RoomID: roomID, Type: eventType, Data: []byte(data)})
+	}
+	return events
+}
@@ -0,0 +1,40 @@
+package redis
+
+import "context"
+
+// hubEventsChannel carries the events every instance's websocket hub acts on for its own clients
+const hubEventsChannel = "hub:events"
+
+// PublishHubEvent sends an event to the websocket hubs of all instances. Pub/sub keeps nothing:
+// an instance that is disconnected from Redis at the time misses the event.
+func (c *Client) PublishHubEvent(ctx context.Context, data []byte) error {
+	return c.rdb.Publish(ctx, hubEventsChannel, data).Err()
+}
+
+// SubscribeHubEvents delivers the events published with PublishHubEvent until ctx is cancelled.
+// The subscription is reestablished after a lost connection.
+func (c *Client) SubscribeHubEvents(ctx context.Context) <-chan []byte {
+	sub := c.rdb.Subscribe(ctx, hubEventsChannel)
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case out <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
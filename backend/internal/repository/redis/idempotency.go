@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyRecord is what is kept for an idempotency key: the fingerprint of the request that
+// claimed it and, once that request has been handled, its response
+type IdempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status,omitempty"` // 0 while the request is being handled
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+func idempotencyKey(scope, key string) string {
+	return fmt.Sprintf("idempotency:%s:%s", scope, key)
+}
+
+// ClaimIdempotencyKey records a request with the given fingerprint under the key for ttl, unless
+// the key is taken. Returns the record of the key and whether this call claimed it.
+func (c *Client) ClaimIdempotencyKey(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	claim := &IdempotencyRecord{Fingerprint: fingerprint}
+	data, err := json.Marshal(claim)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The key can expire between the two commands; the second round claims it then
+	for range 2 {
+		claimed, err := c.rdb.SetNX(ctx, idempotencyKey(scope, key), data, ttl).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if claimed {
+			return claim, true, nil
+		}
+
+		existing, err := c.rdb.Get(ctx, idempotencyKey(scope, key)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		var record IdempotencyRecord
+		if err := json.Unmarshal(existing, &record); err != nil {
+			return nil, false, err
+		}
+		return &record, false, nil
+	}
+	return nil, false, fmt.Errorf("idempotency key %s changed hands while being claimed", key)
+}
+
+// SaveIdempotencyRecord stores the record of a handled request under its key for ttl
+func (c *Client) SaveIdempotencyRecord(ctx context.Context, scope, key string, record IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return c.rdb.Set(ctx, idempotencyKey(scope, key), data, ttl).Err()
+}
+
+// DeleteIdempotencyKey frees the key for the next request that uses it
+func (c *Client) DeleteIdempotencyKey(ctx context.Context, scope, key string) error {
+	return c.rdb.Del(ctx, idempotencyKey(scope, key)).Err()
+}
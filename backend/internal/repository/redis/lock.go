@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Compare-and-set scripts so an instance never extends or releases a lease it no longer owns.
+var (
+	extendLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+func lockKey(name string) string {
+	return fmt.Sprintf("lock:%s", name)
+}
+
+// AcquireLock takes a lease on name for owner. Returns false if another owner holds it.
+func (c *Client) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	return c.rdb.SetNX(ctx, lockKey(name), owner, ttl).Result()
+}
+
+// ExtendLock renews the lease if owner still holds it. Returns false if the lease was lost.
+func (c *Client) ExtendLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	res, err := extendLockScript.Run(ctx, c.rdb, []string{lockKey(name)}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// ReleaseLock drops the lease if owner still holds it
+func (c *Client) ReleaseLock(ctx context.Context, name, owner string) error {
+	return releaseLockScript.Run(ctx, c.rdb, []string{lockKey(name)}, owner).Err()
+}
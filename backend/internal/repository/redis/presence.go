@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PresenceStaleAfter is how long a presence record stays valid without being refreshed
+const PresenceStaleAfter = 90 * time.Second
+
+// PresenceEntry is a user connected to a room's document channel
+type PresenceEntry struct {
+	UserID       string    `json:"user_id"`
+	DisplayName  string    `json:"display_name,omitempty"`
+	Role         string    `json:"role"`
+	Device       string    `json:"device"`
+	Connections  int       `json:"connections"`
+	ConnectedAt  time.Time `json:"connected_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+}
+
+// presenceRecord is one instance's view of a user; instances refresh HeartbeatAt while the user stays
+type presenceRecord struct {
+	PresenceEntry
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
+
+// Presence is kept in one hash per room with a field per user and instance ("<user>/<instance>"),
+// so each instance only ever overwrites or removes its own records
+func presenceKey(roomID string) string {
+	return fmt.Sprintf("presence:%s", roomID)
+}
+
+func presenceField(userID, instance string) string {
+	return userID + "/" + instance
+}
+
+// SetPresence records instance's view of a user in the room; the room's record set expires ttl after the last write
+func (c *Client) SetPresence(ctx context.Context, roomID, instance string, entry PresenceEntry, ttl time.Duration) error {
+	data, err := json.Marshal(presenceRecord{PresenceEntry: entry, HeartbeatAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	key := presenceKey(roomID)
+	pipe := c.rdb.TxPipeline()
+	pipe.HSet(ctx, key, presenceField(entry.UserID, instance), data)
+	pipe.Expire(ctx, key, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// RemovePresence drops instance's record of a user in the room
+func (c *Client) RemovePresence(ctx context.Context, roomID, instance, userID string) error {
+	return c.rdb.HDel(ctx, presenceKey(roomID), presenceField(userID, instance)).Err()
+}
+
+// GetPresence returns the users in the room, merged across instances and ordered by connection time.
+// Records not refreshed within PresenceStaleAfter belong to instances that went away and are ignored.
+func (c *Client) GetPresence(ctx context.Context, roomID string) ([]PresenceEntry, error) {
+	fields, err := c.rdb.HGetAll(ctx, presenceKey(roomID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-PresenceStaleAfter)
+	byUser := make(map[string]*PresenceEntry, len(fields))
+	for _, value := range fields {
+		var record presenceRecord
+		if err := json.Unmarshal([]byte(value), &record); err != nil || record.HeartbeatAt.Before(cutoff) {
+			continue
+		}
+
+		entry, ok := byUser[record.UserID]
+		if !ok {
+			e := record.PresenceEntry
+			byUser[record.UserID] = &e
+			continue
+		}
+		entry.Connections += record.Connections
+		if record.ConnectedAt.Before(entry.ConnectedAt) {
+			entry.ConnectedAt = record.ConnectedAt
+		}
+		if record.LastActiveAt.After(entry.LastActiveAt) {
+			entry.LastActiveAt = record.LastActiveAt
+			entry.Device = record.Device
+		}
+	}
+
+	entries := make([]PresenceEntry, 0, len(byUser))
+	for _, entry := range byUser {
+		entries = append(entries, *entry)
+	}
+	slices.SortFunc(entries, func(a, b PresenceEntry) int {
+		return a.ConnectedAt.Compare(b.ConnectedAt)
+	})
+	return entries, nil
+}
@@ -3,16 +3,19 @@ package redis
 import (
 	"context"
 	"fmt"
+	"nonza/backend/internal/metrics"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
 type Client struct {
 	rdb *redis.Client
-	ctx context.Context
 }
 
+const connectTimeout = 5 * time.Second
+
 type Config struct {
 	Host     string
 	Port     string
@@ -23,44 +26,59 @@ type Config struct {
 
 func NewClient(cfg Config) (*Client, error) {
 	addr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
-	
+
 	opts := &redis.Options{
 		Addr:     addr,
 		Password: cfg.Password,
 		DB:       cfg.DB,
 	}
-	
+
 	// Note: TLS support would require additional configuration
 	// For now, UseSSL is ignored as it requires tls.Config setup
 
 	rdb := redis.NewClient(opts)
-	ctx := context.Background()
-
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		return nil, fmt.Errorf("failed to instrument Redis tracing: %w", err)
+	}
 	// Test connection
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
 	if err := rdb.Ping(ctx).Err(); err != nil {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &Client{
-		rdb: rdb,
-		ctx: ctx,
-	}, nil
+	return &Client{rdb: rdb}, nil
+}
+
+// Ping checks that Redis is reachable
+func (c *Client) Ping(ctx context.Context) error {
+	return c.rdb.Ping(ctx).Err()
 }
 
 func (c *Client) Close() error {
 	return c.rdb.Close()
 }
 
-// SetDocumentState stores Y.js document state with TTL
-func (c *Client) SetDocumentState(roomID string, data []byte, ttl time.Duration) error {
+// SetDocumentState stores Y.js document state with TTL.
+// seq is the last relayed update the state is known to include (see AppendDocumentUpdate).
+// The room's sequence counter lives as long as the state, so numbering never restarts below seq.
+func (c *Client) SetDocumentState(ctx context.Context, roomID string, data []byte, seq int64, ttl time.Duration) error {
 	key := fmt.Sprintf("yjs:document:%s", roomID)
-	return c.rdb.Set(c.ctx, key, data, ttl).Err()
+	metrics.DocumentStateBytes.Observe(float64(len(data)))
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, ttl)
+		pipe.Set(ctx, stateSeqKey(roomID), seq, ttl)
+		pipe.SetNX(ctx, seqKey(roomID), seq, 0)
+		pipe.PExpire(ctx, seqKey(roomID), ttl)
+		return nil
+	})
+	return err
 }
 
 // GetDocumentState retrieves Y.js document state
-func (c *Client) GetDocumentState(roomID string) ([]byte, error) {
+func (c *Client) GetDocumentState(ctx context.Context, roomID string) ([]byte, error) {
 	key := fmt.Sprintf("yjs:document:%s", roomID)
-	data, err := c.rdb.Get(c.ctx, key).Bytes()
+	data, err := c.rdb.Get(ctx, key).Bytes()
 	if err == redis.Nil {
 		return nil, nil // Document not found
 	}
@@ -70,14 +88,20 @@ func (c *Client) GetDocumentState(roomID string) ([]byte, error) {
 	return data, nil
 }
 
-// DeleteDocumentState removes document state
-func (c *Client) DeleteDocumentState(roomID string) error {
+// DeleteDocumentState removes document state along with its update log
+func (c *Client) DeleteDocumentState(ctx context.Context, roomID string) error {
 	key := fmt.Sprintf("yjs:document:%s", roomID)
-	return c.rdb.Del(c.ctx, key).Err()
+	return c.rdb.Del(ctx, key, stateSeqKey(roomID), seqKey(roomID), updatesKey(roomID)).Err()
 }
 
-// ExtendTTL extends the TTL of a document
-func (c *Client) ExtendTTL(roomID string, ttl time.Duration) error {
+// ExtendTTL extends the TTL of a document along with its sequence numbers
+func (c *Client) ExtendTTL(ctx context.Context, roomID string, ttl time.Duration) error {
 	key := fmt.Sprintf("yjs:document:%s", roomID)
-	return c.rdb.Expire(c.ctx, key, ttl).Err()
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Expire(ctx, key, ttl)
+		pipe.Expire(ctx, stateSeqKey(roomID), ttl)
+		pipe.Expire(ctx, seqKey(roomID), ttl)
+		return nil
+	})
+	return err
 }
@@ -0,0 +1,88 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// appendUpdateScript numbers an update and appends it to the room's capped log in one step,
+// so log order always matches sequence order. Entry IDs are "0-<seq>". The counter keeps the
+// longer of its own TTL, which SetDocumentState and ExtendTTL align with the document's, and the
+// log's: were it to expire before the document, numbering would restart below the stored state's.
+var appendUpdateScript = redis.NewScript(`
+local seq = redis.call("INCR", KEYS[1])
+redis.call("XADD", KEYS[2], "MAXLEN", ARGV[2], "0-" .. seq, "u", ARGV[1])
+if redis.call("PTTL", KEYS[1]) < tonumber(ARGV[3]) then
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+redis.call("PEXPIRE", KEYS[2], ARGV[3])
+return seq`)
+
+// DocumentUpdate is a relayed Y.js update and its position in the room's sequence
+type DocumentUpdate struct {
+	Seq  int64
+	Data []byte
+}
+
+func seqKey(roomID string) string {
+	return fmt.Sprintf("yjs:seq:%s", roomID)
+}
+
+func updatesKey(roomID string) string {
+	return fmt.Sprintf("yjs:updates:%s", roomID)
+}
+
+func stateSeqKey(roomID string) string {
+	return fmt.Sprintf("yjs:document_seq:%s", roomID)
+}
+
+// AppendDocumentUpdate assigns the next sequence number of the room to update and keeps it in a
+// replay log of at most limit entries. The log expires ttl after the last update, the sequence
+// number no earlier than that and not before the document state.
+func (c *Client) AppendDocumentUpdate(ctx context.Context, roomID string, update []byte, limit int, ttl time.Duration) (int64, error) {
+	return appendUpdateScript.Run(ctx, c.rdb, []string{seqKey(roomID), updatesKey(roomID)},
+		update, limit, ttl.Milliseconds()).Int64()
+}
+
+// DocumentSeq returns the sequence number of the room's latest update (0 if none)
+func (c *Client) DocumentSeq(ctx context.Context, roomID string) (int64, error) {
+	return getSeq(ctx, c.rdb, seqKey(roomID))
+}
+
+// DocumentStateSeq returns the sequence number stored with the document state (0 if unknown)
+func (c *Client) DocumentStateSeq(ctx context.Context, roomID string) (int64, error) {
+	return getSeq(ctx, c.rdb, stateSeqKey(roomID))
+}
+
+// DocumentUpdatesSince returns the logged updates with a sequence number above after, oldest first.
+// The log is capped, so callers must check the first entry follows after to know nothing is missing.
+func (c *Client) DocumentUpdatesSince(ctx context.Context, roomID string, after int64) ([]DocumentUpdate, error) {
+	entries, err := c.rdb.XRange(ctx, updatesKey(roomID), fmt.Sprintf("0-%d", after+1), "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make([]DocumentUpdate, 0, len(entries))
+	for _, entry := range entries {
+		seq, err := strconv.ParseInt(strings.TrimPrefix(entry.ID, "0-"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed update id %q: %w", entry.ID, err)
+		}
+		data, _ := entry.Values["u"].(string)
+		updates = append(updates, DocumentUpdate{Seq: seq, Data: []byte(data)})
+	}
+	return updates, nil
+}
+
+func getSeq(ctx context.Context, rdb *redis.Client, key string) (int64, error) {
+	seq, err := rdb.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return seq, err
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"nonza/backend/internal/repository/postgresDB"
 
 	"gorm.io/gorm"
@@ -11,6 +12,13 @@ type Repositories struct {
 	Rooms            Rooms
 	MeetingDocuments MeetingDocuments
 	Participants     Participants
+	JobRuns          JobRuns
+	Snapshots        DocumentSnapshots
+	Webhooks         Webhooks
+	Outbox           Outbox
+	AuditEvents      AuditEvents
+
+	db *gorm.DB
 }
 
 func NewRepositories(db *gorm.DB) *Repositories {
@@ -18,11 +26,30 @@ func NewRepositories(db *gorm.DB) *Repositories {
 	roomRepo := postgresDB.NewRoomsRepository(db)
 	docRepo := postgresDB.NewMeetingDocumentsRepository(db)
 	partRepo := postgresDB.NewParticipantsRepository(db)
+	jobRunRepo := postgresDB.NewJobRunsRepository(db)
+	snapshotRepo := postgresDB.NewDocumentSnapshotsRepository(db)
+	webhookRepo := postgresDB.NewWebhooksRepository(db)
+	outboxRepo := postgresDB.NewOutboxRepository(db)
+	auditRepo := postgresDB.NewAuditEventsRepository(db)
 
 	return &Repositories{
 		Organizations:    orgRepo,
 		Rooms:            roomRepo,
 		MeetingDocuments: docRepo,
 		Participants:     partRepo,
+		JobRuns:          jobRunRepo,
+		Snapshots:        snapshotRepo,
+		Webhooks:         webhookRepo,
+		Outbox:           outboxRepo,
+		AuditEvents:      auditRepo,
+		db:               db,
 	}
 }
+
+// Transaction runs fn with repositories bound to a single database transaction,
+// committed if fn returns nil and rolled back otherwise
+func (r *Repositories) Transaction(ctx context.Context, fn func(tx *Repositories) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepositories(tx))
+	})
+}
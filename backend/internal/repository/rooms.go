@@ -1,19 +1,32 @@
 package repository
 
 import (
+	"context"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Rooms interface {
-	Create(room *models.Room) error
-	GetByID(id uuid.UUID) (*models.Room, error)
-	GetByShortCode(shortCode string) (*models.Room, error)
-	GetBySlug(slug string) (*models.Room, error)
-	GetByOrganizationID(orgID uuid.UUID) ([]models.Room, error)
-	Update(room *models.Room) error
-	Delete(id uuid.UUID) error
-	DeleteExpired() error
-	GetExpired() ([]models.Room, error)
+	Create(ctx context.Context, room *models.Room) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Room, error)
+	GetByShortCode(ctx context.Context, shortCode string) (*models.Room, error)
+	GetBySlug(ctx context.Context, orgID uuid.UUID, slug string) (*models.Room, error)
+	// GetSlugRedirect returns the room that had the slug in the organization before it changed
+	GetSlugRedirect(ctx context.Context, orgID uuid.UUID, slug string) (*models.RoomSlugRedirect, error)
+	// SaveSlugRedirect points a former slug at the room, replacing an older redirect of the slug
+	SaveSlugRedirect(ctx context.Context, redirect *models.RoomSlugRedirect) error
+	DeleteSlugRedirect(ctx context.Context, orgID uuid.UUID, slug string) error
+	List(ctx context.Context, filter models.RoomFilter, page pagination.Page) ([]models.Room, error)
+	GetBatchByOrganizationID(ctx context.Context, orgID uuid.UUID, limit int) ([]models.Room, error)
+	Update(ctx context.Context, room *models.Room) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetExpired(ctx context.Context) ([]models.Room, error)
+	GetExpiringBefore(ctx context.Context, before time.Time) ([]models.Room, error)
+	// Archive archives the room if it is still active and reports whether it was
+	Archive(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	GetPurgeable(ctx context.Context, defaultRetentionDays int, now time.Time) ([]models.Room, error)
+	DeletePermanently(ctx context.Context, ids []uuid.UUID) (int64, error)
 }
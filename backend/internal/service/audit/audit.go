@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
+	"nonza/backend/internal/repository"
+	"reflect"
+	"time"
+)
+
+type auditService struct {
+	repo repository.AuditEvents
+}
+
+func (s *auditService) Record(ctx context.Context, event *models.AuditEvent) error {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	return s.repo.Create(ctx, event)
+}
+
+func (s *auditService) List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, string, error) {
+	events, err := s.repo.List(ctx, filter, page.Lookahead())
+	if err != nil {
+		return nil, "", err
+	}
+	events, next := pagination.Cut(events, page, func(e *models.AuditEvent) pagination.Position {
+		return pagination.Position{Value: e.OccurredAt, ID: e.ID}
+	})
+	return events, next, nil
+}
+
+// Diff compares the top-level JSON fields of two values and returns those that differ.
+// A nil before yields every field of after as new, a nil after every field of before as old.
+// Timestamps kept by the database are left out.
+func Diff(before, after any) models.AuditDiff {
+	old, _ := fields(before)
+	cur, _ := fields(after)
+
+	diff := models.AuditDiff{}
+	for name, value := range cur {
+		if prev, ok := old[name]; !ok || !reflect.DeepEqual(prev, value) {
+			diff[name] = models.FieldChange{Old: old[name], New: value}
+		}
+	}
+	for name, value := range old {
+		if _, ok := cur[name]; !ok {
+			diff[name] = models.FieldChange{Old: value}
+		}
+	}
+	delete(diff, "created_at")
+	delete(diff, "updated_at")
+	return diff
+}
+
+func fields(v any) (map[string]any, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type org struct {
+		Name      string `json:"name"`
+		Plan      string `json:"plan"`
+		UpdatedAt string `json:"updated_at"`
+	}
+
+	diff := Diff(org{Name: "Acme", Plan: "free", UpdatedAt: "a"}, org{Name: "Acme", Plan: "pro", UpdatedAt: "b"})
+	if len(diff) != 1 || diff["plan"].Old != "free" || diff["plan"].New != "pro" {
+		t.Errorf("update diff = %v, want only plan free -> pro", diff)
+	}
+
+	created := Diff(nil, org{Name: "Acme"})
+	if created["name"].New != "Acme" || created["name"].Old != nil {
+		t.Errorf("create diff = %v, want name as new", created)
+	}
+
+	var none *org
+	deleted := Diff(&org{Name: "Acme"}, none)
+	if deleted["name"].Old != "Acme" || deleted["name"].New != nil {
+		t.Errorf("delete diff = %v, want name as old", deleted)
+	}
+}
@@ -0,0 +1,9 @@
+package audit
+
+import (
+	"nonza/backend/internal/repository"
+)
+
+func NewAuditService(repo repository.AuditEvents) Audit {
+	return &auditService{repo: repo}
+}
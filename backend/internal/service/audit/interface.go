@@ -0,0 +1,18 @@
+package audit
+
+import (
+	"context"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
+)
+
+// SortFields are the fields the audit log can be sorted by
+var SortFields = pagination.Fields{"occurred_at": pagination.KindTime}
+
+type Audit interface {
+	// Record appends an event to the audit log; OccurredAt defaults to now
+	Record(ctx context.Context, event *models.AuditEvent) error
+
+	// List returns a page of the events matching the filter and the cursor of the next page, empty on the last one
+	List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, string, error)
+}
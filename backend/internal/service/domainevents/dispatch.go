@@ -0,0 +1,110 @@
+package domainevents
+
+import (
+	"context"
+	"log/slog"
+	"nonza/backend/internal/metrics"
+	"nonza/backend/internal/models"
+	"strings"
+	"time"
+)
+
+const (
+	// batchSize is how many due events one poll claims
+	batchSize = 50
+
+	// lease is how long claimed events are hidden from other instances while this one handles them
+	lease = 2 * time.Minute
+
+	// handlerTimeout bounds a single subscriber's handling of an event
+	handlerTimeout = 10 * time.Second
+
+	// An event whose subscribers still fail after maxAttempts is given up on. Retries are spaced
+	// by retryBase, doubling with each attempt up to retryMax.
+	maxAttempts = 10
+	retryBase   = time.Second
+	retryMax    = 5 * time.Minute
+)
+
+// Start begins handing outbox events to their subscribers
+func (b *bus) Start() {
+	b.dispatcher.Start()
+}
+
+// Stop ends the dispatcher after the event being handled. Unhandled events stay in the
+// outbox for the next start or another instance.
+func (b *bus) Stop() {
+	b.dispatcher.Stop()
+}
+
+// handleClaimed hands claimed events to their subscribers, oldest first
+func (b *bus) handleClaimed(ctx context.Context, events []models.OutboxEvent) {
+	for i := range events {
+		if ctx.Err() != nil {
+			// The rest are picked up again when their lease runs out
+			return
+		}
+		b.handle(context.WithoutCancel(ctx), &events[i])
+		if err := b.repos.Outbox.Update(context.WithoutCancel(ctx), &events[i]); err != nil {
+			b.log.Error("Failed to save outbox event", slog.String("event_id", events[i].ID.String()), slog.Any("error", err))
+		}
+	}
+}
+
+// handle passes the event to the subscribers it is pending for and records the outcome on it:
+// the subscribers that failed stay pending and are retried later; with none left, or once
+// attempts run out, the event is processed
+func (b *bus) handle(ctx context.Context, row *models.OutboxEvent) {
+	event := toEvent(row)
+
+	failed := []string{}
+	var errs []string
+	for _, name := range row.Pending {
+		sub, ok := b.subscriber(name)
+		if !ok {
+			b.log.Warn("Dropping outbox event for unknown subscriber", slog.String("subscriber", name), slog.String("event_id", row.ID.String()))
+			continue
+		}
+
+		hctx, cancel := context.WithTimeout(ctx, handlerTimeout)
+		err := sub.handler(hctx, event)
+		cancel()
+		if err != nil {
+			metrics.OutboxHandlerFailures.WithLabelValues(name).Inc()
+			failed = append(failed, name)
+			errs = append(errs, name+": "+err.Error())
+		}
+	}
+
+	now := time.Now()
+	row.Pending = failed
+	if len(failed) == 0 {
+		row.ProcessedAt = &now
+		row.LastError = nil
+		return
+	}
+
+	row.Attempts++
+	msg := strings.Join(errs, "; ")
+	row.LastError = &msg
+	if row.Attempts >= maxAttempts {
+		row.ProcessedAt = &now
+		b.log.Error("Giving up on outbox event",
+			slog.String("event_id", row.ID.String()),
+			slog.String("type", row.Type),
+			slog.Any("subscribers", failed),
+			slog.String("error", msg))
+		return
+	}
+	row.NextAttemptAt = now.Add(backoff(row.Attempts))
+}
+
+// backoff returns the delay after the given failed attempt
+func backoff(attempts int) time.Duration {
+	if shift := attempts - 1; shift < 20 {
+		if d := retryBase << shift; d < retryMax {
+			return d
+		}
+	}
+	return retryMax
+}
@@ -0,0 +1,157 @@
+package domainevents
+
+import (
+	"context"
+	"log/slog"
+	"nonza/backend/internal/metrics"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/poller"
+	"nonza/backend/internal/repository"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PurgeJobName is the job name the removal of processed events is registered under
+const PurgeJobName = "purge_outbox_events"
+
+type bus struct {
+	repos     *repository.Repositories
+	retention time.Duration // how long processed events are kept
+	log       *slog.Logger
+
+	mu          sync.RWMutex
+	subscribers []subscriber // in the order they subscribed, which is the order they handle an event in
+
+	wake       chan struct{} // Signals the dispatcher that events were written on this instance
+	dispatcher *poller.Poller[models.OutboxEvent]
+}
+
+type subscriber struct {
+	name    string
+	handler Handler
+	types   []string // nil for all events
+}
+
+func (b *bus) Subscribe(name string, handler Handler, eventTypes ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if slices.ContainsFunc(b.subscribers, func(s subscriber) bool { return s.name == name }) {
+		panic("domainevents: duplicate subscriber " + name)
+	}
+	b.subscribers = append(b.subscribers, subscriber{name: name, handler: handler, types: eventTypes})
+}
+
+func (b *bus) Publish(ctx context.Context, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := b.repos.Outbox.Create(ctx, b.toRows(events)); err != nil {
+		return err
+	}
+	b.notify()
+	return nil
+}
+
+func (b *bus) Transaction(ctx context.Context, fn func(tx *repository.Repositories) ([]Event, error)) error {
+	err := b.repos.Transaction(ctx, func(tx *repository.Repositories) error {
+		events, err := fn(tx)
+		if err != nil {
+			return err
+		}
+		return tx.Outbox.Create(ctx, b.toRows(events))
+	})
+	if err != nil {
+		return err
+	}
+	b.notify()
+	return nil
+}
+
+func (b *bus) PurgeProcessed(ctx context.Context) (int, error) {
+	n, err := b.repos.Outbox.DeleteProcessedBefore(ctx, time.Now().Add(-b.retention))
+	return int(n), err
+}
+
+// notify wakes the dispatcher, so events written here are handled without waiting for the next poll
+func (b *bus) notify() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// toRows turns events into outbox rows pending for their subscribers.
+// Events nobody subscribed to are stored as processed.
+func (b *bus) toRows(events []Event) []models.OutboxEvent {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	rows := make([]models.OutboxEvent, len(events))
+	for i, e := range events {
+		pending := []string{}
+		for _, s := range b.subscribers {
+			if s.types == nil || slices.Contains(s.types, e.Type) {
+				pending = append(pending, s.name)
+			}
+		}
+
+		var orgID *uuid.UUID
+		if e.OrganizationID != uuid.Nil {
+			orgID = &e.OrganizationID
+		}
+
+		rows[i] = models.OutboxEvent{
+			ID:             e.ID,
+			Type:           e.Type,
+			RoomID:         e.RoomID,
+			OrganizationID: orgID,
+			Payload:        e.Data,
+			OccurredAt:     e.OccurredAt,
+			Pending:        pending,
+			NextAttemptAt:  e.OccurredAt,
+		}
+		if len(pending) == 0 {
+			rows[i].ProcessedAt = &e.OccurredAt
+		}
+	}
+	return rows
+}
+
+func (b *bus) subscriber(name string) (subscriber, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, s := range b.subscribers {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return subscriber{}, false
+}
+
+func toEvent(row *models.OutboxEvent) Event {
+	e := Event{
+		ID:         row.ID,
+		Type:       row.Type,
+		RoomID:     row.RoomID,
+		OccurredAt: row.OccurredAt,
+		Data:       row.Payload,
+	}
+	if row.OrganizationID != nil {
+		e.OrganizationID = *row.OrganizationID
+	}
+	return e
+}
+
+// CountEvent is the subscriber behind the event and token metrics
+func CountEvent(_ context.Context, event Event) error {
+	metrics.DomainEvents.WithLabelValues(event.Type).Inc()
+	if event.Type == TokenIssued {
+		metrics.TokensIssued.Inc()
+	}
+	return nil
+}
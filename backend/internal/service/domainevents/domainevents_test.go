@@ -0,0 +1,148 @@
+package domainevents
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// memoryOutbox is an Outbox kept in memory; ClaimDue honours due times and leases like the Postgres one
+type memoryOutbox struct {
+	mu     sync.Mutex
+	events []models.OutboxEvent
+}
+
+func (m *memoryOutbox) Create(_ context.Context, events []models.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func (m *memoryOutbox) ClaimDue(_ context.Context, now time.Time, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var claimed []models.OutboxEvent
+	for i := range m.events {
+		e := &m.events[i]
+		if e.ProcessedAt == nil && !e.NextAttemptAt.After(now) && len(claimed) < limit {
+			e.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, *e)
+		}
+	}
+	return claimed, nil
+}
+
+func (m *memoryOutbox) Update(_ context.Context, event *models.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.events {
+		if m.events[i].ID == event.ID {
+			m.events[i] = *event
+		}
+	}
+	return nil
+}
+
+func (m *memoryOutbox) DeleteProcessedBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *memoryOutbox) get(id uuid.UUID) models.OutboxEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.events {
+		if e.ID == id {
+			return e
+		}
+	}
+	return models.OutboxEvent{}
+}
+
+// due makes the event's next attempt due now, as if its backoff had passed
+func (m *memoryOutbox) due(id uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.events {
+		if m.events[i].ID == id {
+			m.events[i].NextAttemptAt = time.Now()
+		}
+	}
+}
+
+func TestFailedSubscriberIsRetriedAlone(t *testing.T) {
+	outbox := &memoryOutbox{}
+	b := NewBus(&repository.Repositories{Outbox: outbox}, time.Second, time.Hour, slog.New(slog.DiscardHandler)).(*bus)
+
+	var stream, hooks []uuid.UUID
+	failHooks := true
+	b.Subscribe("stream", func(_ context.Context, e Event) error {
+		stream = append(stream, e.ID)
+		return nil
+	})
+	b.Subscribe("hooks", func(_ context.Context, e Event) error {
+		hooks = append(hooks, e.ID)
+		if failHooks {
+			return errors.New("endpoint down")
+		}
+		return nil
+	}, RoomCreated)
+
+	created, _ := NewEvent(RoomCreated, uuid.New(), uuid.New(), RoomCreatedPayload{Name: "standup"})
+	joined, _ := NewEvent(ParticipantJoined, created.RoomID, uuid.Nil, ParticipantPayload{UserID: "u1"})
+	if err := b.Publish(context.Background(), created, joined); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := outbox.get(joined.ID).Pending; !slices.Equal(got, []string{"stream"}) {
+		t.Errorf("participant.joined pending for %v, want only the subscriber of all events", got)
+	}
+
+	b.dispatcher.Poll(context.Background())
+	row := outbox.get(created.ID)
+	if row.ProcessedAt != nil || !slices.Equal(row.Pending, []string{"hooks"}) || row.Attempts != 1 {
+		t.Fatalf("after a failure: processed %v, pending %v, attempts %d; want hooks pending after 1 attempt",
+			row.ProcessedAt, row.Pending, row.Attempts)
+	}
+	if row.NextAttemptAt.Before(time.Now()) {
+		t.Errorf("retry is due immediately, want it backed off")
+	}
+	if outbox.get(joined.ID).ProcessedAt == nil {
+		t.Errorf("participant.joined not processed")
+	}
+
+	failHooks = false
+	outbox.due(created.ID)
+	b.dispatcher.Poll(context.Background())
+	if row := outbox.get(created.ID); row.ProcessedAt == nil || len(row.Pending) != 0 {
+		t.Errorf("after the retry: processed %v, pending %v; want processed", row.ProcessedAt, row.Pending)
+	}
+
+	if len(stream) != 2 {
+		t.Errorf("stream subscriber saw %d events, want 2: a retry must not repeat subscribers that succeeded", len(stream))
+	}
+	if !slices.Equal(hooks, []uuid.UUID{created.ID, created.ID}) {
+		t.Errorf("hooks subscriber saw %v, want room.created twice", hooks)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	if d := backoff(1); d != retryBase {
+		t.Errorf("backoff(1) = %s, want %s", d, retryBase)
+	}
+	if d := backoff(3); d != 4*retryBase {
+		t.Errorf("backoff(3) = %s, want %s", d, 4*retryBase)
+	}
+	if d := backoff(50); d != retryMax {
+		t.Errorf("backoff(50) = %s, want the cap %s", d, retryMax)
+	}
+}
@@ -0,0 +1,29 @@
+package domainevents
+
+import (
+	"log/slog"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/poller"
+	"nonza/backend/internal/repository"
+	"time"
+)
+
+func NewBus(repos *repository.Repositories, pollInterval, retention time.Duration, log *slog.Logger) Bus {
+	b := &bus{
+		repos:     repos,
+		retention: retention,
+		log:       log.With(slog.String("component", "domain_events")),
+		wake:      make(chan struct{}, 1),
+	}
+	b.dispatcher = poller.New(poller.Config[models.OutboxEvent]{
+		Name:      "outbox events",
+		Interval:  pollInterval,
+		BatchSize: batchSize,
+		Lease:     lease,
+		Claim:     repos.Outbox.ClaimDue,
+		Handle:    b.handleClaimed,
+		Wake:      b.wake,
+		Log:       b.log,
+	})
+	return b
+}
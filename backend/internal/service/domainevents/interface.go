@@ -0,0 +1,139 @@
+package domainevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"nonza/backend/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types of the domain event bus
+const (
+	RoomCreated         = "room.created"
+	RoomUpdated         = "room.updated"
+	RoomDeleted         = "room.deleted"
+	RoomExpired         = "room.expired"
+	RoomClosed          = "room.closed"
+	ParticipantJoined   = "participant.joined"
+	ParticipantLeft     = "participant.left"
+	DocumentUpdated     = "document.updated"
+	DocumentSnapshotted = "document.snapshotted"
+	TokenIssued         = "token.issued"
+)
+
+// Event is a change in the domain. ID identifies it across redeliveries, so subscribers can
+// drop events they have already handled.
+type Event struct {
+	ID             uuid.UUID
+	Type           string
+	RoomID         uuid.UUID
+	OrganizationID uuid.UUID // uuid.Nil when the publisher does not know it
+	OccurredAt     time.Time
+	Data           json.RawMessage
+}
+
+// NewEvent returns an event of the room with data encoded as JSON
+func NewEvent(eventType string, roomID, orgID uuid.UUID, data any) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+	return Event{
+		ID:             uuid.New(),
+		Type:           eventType,
+		RoomID:         roomID,
+		OrganizationID: orgID,
+		OccurredAt:     time.Now(),
+		Data:           payload,
+	}, nil
+}
+
+// RoomCreatedPayload is the data of room.created
+type RoomCreatedPayload struct {
+	OrganizationID string     `json:"organization_id"`
+	Name           string     `json:"name"`
+	Slug           *string    `json:"slug,omitempty"`
+	ShortCode      string     `json:"short_code"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// RoomUpdatedPayload is the data of room.updated: the room's settings after the change and
+// the JSON names of the fields that changed
+type RoomUpdatedPayload struct {
+	Name        string     `json:"name"`
+	Slug        *string    `json:"slug,omitempty"`
+	RoomType    string     `json:"room_type"`
+	IsTemporary bool       `json:"is_temporary"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	E2EEEnabled bool       `json:"e2ee_enabled"`
+	Changed     []string   `json:"changed"`
+}
+
+// RoomDeletedPayload is the data of room.deleted. Reason is why the room went: room_deleted,
+// or organization_deleted for the rooms of a deleted organization.
+type RoomDeletedPayload struct {
+	OrganizationID string `json:"organization_id"`
+	Name           string `json:"name"`
+	LiveKitRoom    string `json:"livekit_room"`
+	Reason         string `json:"reason"`
+}
+
+// ParticipantPayload is the data of participant.joined and participant.left
+type ParticipantPayload struct {
+	UserID      string `json:"user_id"`
+	DisplayName string `json:"display_name,omitempty"`
+	Role        string `json:"role,omitempty"`
+}
+
+// DocumentUpdatedPayload is the data of document.updated, published at most once per burst of edits
+type DocumentUpdatedPayload struct {
+	Seq int64 `json:"seq"` // sequence number of the update that triggered the event
+}
+
+// DocumentSnapshottedPayload is the data of document.snapshotted
+type DocumentSnapshottedPayload struct {
+	SnapshotID string `json:"snapshot_id"`
+	Size       int    `json:"size"`
+}
+
+// ExpiryPayload is the data of room.expired (and of room.expiring on the room event stream)
+type ExpiryPayload struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ClosedPayload is the data of room.closed
+type ClosedPayload struct {
+	Reason string `json:"reason"`
+}
+
+// TokenIssuedPayload is the data of token.issued
+type TokenIssuedPayload struct {
+	ParticipantID   string `json:"participant_id"`
+	ParticipantName string `json:"participant_name"`
+}
+
+// Handler handles an event for a subscriber. Delivery is at least once: a handler may see an
+// event again after it failed or its instance died, and should tolerate that.
+type Handler func(ctx context.Context, event Event) error
+
+// Bus carries domain events from the service that changed state to the subscribers reacting to
+// it (hub, webhooks, metrics, ...). Events go through an outbox table: written in the transaction
+// of the change, then handed to each subscriber until it succeeds.
+type Bus interface {
+	// Subscribe registers a handler under a unique name for the given event types, or for all
+	// events if none are given. Subscribers are registered at startup, before events are published:
+	// an event goes to the subscribers registered when it was written.
+	Subscribe(name string, handler Handler, eventTypes ...string)
+	// Publish writes events to the outbox on their own, for changes not stored in Postgres
+	Publish(ctx context.Context, events ...Event) error
+	// Transaction runs fn in a database transaction and writes the events it returns to the outbox
+	// in the same transaction, so they are delivered if and only if fn's changes commit
+	Transaction(ctx context.Context, fn func(tx *repository.Repositories) ([]Event, error)) error
+	// PurgeProcessed deletes events processed longer ago than the retention. Returns the number deleted.
+	PurgeProcessed(ctx context.Context) (int, error)
+	Start()
+	Stop()
+}
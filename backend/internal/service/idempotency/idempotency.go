@@ -0,0 +1,43 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"nonza/backend/internal/repository/redis"
+)
+
+type idempotencyService struct {
+	store    Store
+	ttl      time.Duration
+	claimTTL time.Duration
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, scope, key, fingerprint string) (*Response, error) {
+	record, claimed, err := s.store.ClaimIdempotencyKey(ctx, scope, key, fingerprint, s.claimTTL)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case claimed:
+		return nil, nil
+	case record.Fingerprint != fingerprint:
+		return nil, ErrKeyReused
+	case record.Status == 0:
+		return nil, ErrKeyInUse
+	}
+	return &Response{Status: record.Status, ContentType: record.ContentType, Body: record.Body}, nil
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, scope, key, fingerprint string, response Response) error {
+	return s.store.SaveIdempotencyRecord(ctx, scope, key, redis.IdempotencyRecord{
+		Fingerprint: fingerprint,
+		Status:      response.Status,
+		ContentType: response.ContentType,
+		Body:        response.Body,
+	}, s.ttl)
+}
+
+func (s *idempotencyService) Release(ctx context.Context, scope, key string) error {
+	return s.store.DeleteIdempotencyKey(ctx, scope, key)
+}
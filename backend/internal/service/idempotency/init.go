@@ -0,0 +1,13 @@
+package idempotency
+
+import "time"
+
+// NewIdempotencyService keeps responses for ttl. A claimed key whose request never completes,
+// e.g. because the instance died, is freed after claimTTL.
+func NewIdempotencyService(store Store, ttl, claimTTL time.Duration) Idempotency {
+	return &idempotencyService{
+		store:    store,
+		ttl:      ttl,
+		claimTTL: claimTTL,
+	}
+}
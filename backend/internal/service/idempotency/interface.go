@@ -0,0 +1,42 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/repository/redis"
+)
+
+var (
+	ErrKeyReused = apperrors.Unprocessable("idempotency_key_reused", "idempotency key was already used for a different request")
+	ErrKeyInUse  = apperrors.Conflict("idempotency_key_in_use", "a request with this idempotency key is still being handled")
+)
+
+// Response is a stored response, replayed to retries of the request
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Idempotency makes retried requests take effect once. A key is scoped, e.g. to an
+// organization; the fingerprint identifies the request that first used it.
+type Idempotency interface {
+	// Begin claims the key for the request. It returns the stored response when the request was
+	// already handled, ErrKeyInUse while it is being handled and ErrKeyReused when the key
+	// belongs to a different request. A nil response with no error means the request should
+	// be handled and then passed to Complete or Release.
+	Begin(ctx context.Context, scope, key, fingerprint string) (*Response, error)
+	// Complete stores the response of the request that claimed the key
+	Complete(ctx context.Context, scope, key, fingerprint string, response Response) error
+	// Release frees the key, so a retry handles the request again
+	Release(ctx context.Context, scope, key string) error
+}
+
+// Store keeps idempotency records; implemented by the Redis client
+type Store interface {
+	ClaimIdempotencyKey(ctx context.Context, scope, key, fingerprint string, ttl time.Duration) (*redis.IdempotencyRecord, bool, error)
+	SaveIdempotencyRecord(ctx context.Context, scope, key string, record redis.IdempotencyRecord, ttl time.Duration) error
+	DeleteIdempotencyKey(ctx context.Context, scope, key string) error
+}
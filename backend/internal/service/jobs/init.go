@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"nonza/backend/internal/repository"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+)
+
+func NewJobsService(repo repository.JobRuns, locker Locker, lockTTL time.Duration, log *slog.Logger) Jobs {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &jobsService{
+		repo:     repo,
+		locker:   locker,
+		lockTTL:  lockTTL,
+		instance: hostname + "-" + uuid.NewString()[:8],
+		cron:     cron.New(cron.WithSeconds()),
+		log:      log.With(slog.String("component", "jobs")),
+		jobs:     make(map[string]Job),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrJobNotFound = apperrors.NotFound("job_not_found", "job not found")
+	ErrRunNotFound = apperrors.NotFound("job_run_not_found", "job run not found")
+	ErrJobLocked   = apperrors.Conflict("job_locked", "job is already running on another instance")
+	ErrStopped     = apperrors.Unavailable("shutting_down", "jobs service is shutting down")
+)
+
+// Job is a unit of background work. Run returns the number of rooms it affected.
+type Job struct {
+	Name     string
+	Schedule string
+	Run      func(ctx context.Context) (int, error)
+}
+
+// Task is one-off work bound to a target (e.g. an organization being deleted).
+// It calls report with the running total of rooms affected so progress can be polled.
+type Task func(ctx context.Context, report func(roomsAffected int)) (int, error)
+
+type JobInfo struct {
+	Name     string
+	Schedule string
+}
+
+// Locker provides a distributed lease so only one replica runs a job at a time
+type Locker interface {
+	AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	ExtendLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	ReleaseLock(ctx context.Context, name, owner string) error
+}
+
+type Jobs interface {
+	Register(job Job) error
+	Start()
+	Stop(ctx context.Context)
+	List() []JobInfo
+	Trigger(ctx context.Context, name string) (*models.JobRun, error)
+	RunTask(ctx context.Context, name, target string, task Task) (*models.JobRun, error)
+	GetRun(ctx context.Context, id uuid.UUID) (*models.JobRun, error)
+	GetRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error)
+	GetLatestRun(ctx context.Context, name, target string) (*models.JobRun, error)
+}
@@ -0,0 +1,344 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var tracer = otel.Tracer("nonza/backend/internal/service/jobs")
+
+// bookkeepingTimeout bounds recording a run's outcome and releasing its lease,
+// which must happen even when the run itself was cancelled by shutdown
+const bookkeepingTimeout = 5 * time.Second
+
+// errLeaseLost cancels a run whose lease expired or went to another instance, which may now be running the job too
+var errLeaseLost = errors.New("job lease lost")
+
+type jobsService struct {
+	repo     repository.JobRuns
+	locker   Locker
+	lockTTL  time.Duration
+	instance string
+	cron     *cron.Cron
+	log      *slog.Logger
+
+	// ctx is the parent of every run; Stop cancels it
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.RWMutex
+	jobs     map[string]Job
+	stopping bool
+	running  sync.WaitGroup // background runs started by Trigger and RunTask
+}
+
+func (s *jobsService) Register(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[job.Name]; exists {
+		return fmt.Errorf("job %s already registered", job.Name)
+	}
+
+	if job.Schedule != "" {
+		if _, err := s.cron.AddFunc(job.Schedule, func() { s.runScheduled(job) }); err != nil {
+			return fmt.Errorf("invalid schedule for job %s: %w", job.Name, err)
+		}
+	}
+
+	s.jobs[job.Name] = job
+	s.log.Info("Registered job", slog.String("job", job.Name), slog.String("schedule", job.Schedule))
+	return nil
+}
+
+func (s *jobsService) Start() {
+	s.cron.Start()
+	s.log.Info("Scheduler started", slog.String("instance", s.instance))
+}
+
+// Stop stops scheduling and waits for running jobs to finish. Runs still going when ctx
+// expires are cancelled; Stop then waits for them to record their outcome and release their lease.
+func (s *jobsService) Stop(ctx context.Context) {
+	s.mu.Lock()
+	s.stopping = true
+	s.mu.Unlock()
+
+	scheduled := s.cron.Stop()
+	done := make(chan struct{})
+	go func() {
+		<-scheduled.Done()
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.log.Info("Scheduler stopped")
+	case <-ctx.Done():
+		s.log.Warn("Cancelling jobs still running at shutdown")
+		s.cancel()
+		select {
+		case <-done:
+		case <-time.After(bookkeepingTimeout):
+			s.log.Error("Jobs did not stop after cancellation")
+		}
+	}
+	s.cancel()
+}
+
+func (s *jobsService) List() []JobInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := make([]JobInfo, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		infos = append(infos, JobInfo{Name: job.Name, Schedule: job.Schedule})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Trigger starts a job outside its schedule. The run executes in the background;
+// the returned record can be polled through GetRun.
+func (s *jobsService) Trigger(ctx context.Context, name string) (*models.JobRun, error) {
+	s.mu.RLock()
+	job, ok := s.jobs[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	return s.start(ctx, job.Name, nil, models.JobTriggerManual, job.task())
+}
+
+// RunTask starts a one-off task in the background. Runs of the same name and target
+// are mutually exclusive across instances.
+func (s *jobsService) RunTask(ctx context.Context, name, target string, task Task) (*models.JobRun, error) {
+	return s.start(ctx, name, &target, models.JobTriggerManual, task)
+}
+
+func (s *jobsService) GetRun(ctx context.Context, id uuid.UUID) (*models.JobRun, error) {
+	run, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRunNotFound
+	}
+	return run, err
+}
+
+func (s *jobsService) GetRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	s.mu.RLock()
+	_, ok := s.jobs[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return s.repo.GetByJobName(ctx, name, limit)
+}
+
+func (s *jobsService) GetLatestRun(ctx context.Context, name, target string) (*models.JobRun, error) {
+	run, err := s.repo.GetLatestByTarget(ctx, name, target)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRunNotFound
+	}
+	return run, err
+}
+
+func (job Job) task() Task {
+	return func(ctx context.Context, _ func(int)) (int, error) { return job.Run(ctx) }
+}
+
+func (s *jobsService) runScheduled(job Job) {
+	run, err := s.begin(s.ctx, job.Name, nil, models.JobTriggerSchedule)
+	if err == ErrJobLocked {
+		s.log.Debug("Skipping job: lease held by another instance", slog.String("job", job.Name))
+		return
+	}
+	if err != nil {
+		s.log.Error("Failed to start job", slog.String("job", job.Name), slog.Any("error", err))
+		return
+	}
+	s.finish(s.ctx, run, job.task())
+}
+
+func (s *jobsService) start(ctx context.Context, name string, target *string, trigger models.JobTrigger, task Task) (*models.JobRun, error) {
+	// Registering with the WaitGroup under the lock keeps Add ordered before Stop's Wait
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		return nil, ErrStopped
+	}
+	s.running.Add(1)
+	s.mu.Unlock()
+
+	run, err := s.begin(ctx, name, target, trigger)
+	if err != nil {
+		s.running.Done()
+		return nil, err
+	}
+
+	started := *run
+	go func() {
+		defer s.running.Done()
+		s.finish(ctx, run, task)
+	}()
+	return &started, nil
+}
+
+// begin takes the job lease and records the run as started
+func (s *jobsService) begin(ctx context.Context, name string, target *string, trigger models.JobTrigger) (*models.JobRun, error) {
+	lock := lockName(name, target)
+	acquired, err := s.locker.AcquireLock(ctx, lock, s.instance, s.lockTTL)
+	if err != nil {
+		return nil, fmt.Errorf("acquire lock: %w", err)
+	}
+	if !acquired {
+		return nil, ErrJobLocked
+	}
+
+	run := &models.JobRun{
+		JobName:   name,
+		Target:    target,
+		Trigger:   trigger,
+		Instance:  s.instance,
+		Status:    models.JobRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.repo.Create(ctx, run); err != nil {
+		s.release(lock)
+		return nil, fmt.Errorf("record job run: %w", err)
+	}
+	return run, nil
+}
+
+// finish executes the task while renewing the lease, then records the outcome and releases the lease.
+// The run outlives the request that triggered it, so it gets its own trace linked to the caller's span
+// and is cancelled by Stop rather than by the caller.
+func (s *jobsService) finish(caller context.Context, run *models.JobRun, task Task) {
+	lock := lockName(run.JobName, run.Target)
+	defer s.release(lock)
+
+	ctx, span := tracer.Start(s.ctx, "job "+run.JobName,
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(caller)),
+		trace.WithAttributes(
+			attribute.String("job.name", run.JobName),
+			attribute.String("job.run_id", run.ID.String()),
+			attribute.String("job.trigger", string(run.Trigger)),
+		),
+	)
+	defer span.End()
+
+	ctx, cancelRun := context.WithCancelCause(ctx)
+	defer cancelRun(nil)
+	stopRenew := make(chan struct{})
+	go s.renew(ctx, lock, stopRenew, cancelRun)
+
+	log := s.log.With(slog.String("job", run.JobName), slog.String("run_id", run.ID.String()))
+	log.Info("Running job", slog.String("trigger", string(run.Trigger)))
+	affected, err := s.execute(ctx, run, task)
+	close(stopRenew)
+	if cause := context.Cause(ctx); err != nil && errors.Is(cause, errLeaseLost) {
+		err = fmt.Errorf("%w: %w", errLeaseLost, err)
+	}
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.RoomsAffected = affected
+	if err != nil {
+		msg := err.Error()
+		run.Error = &msg
+		run.Status = models.JobRunStatusFailed
+		span.RecordError(err)
+		span.SetStatus(codes.Error, msg)
+		log.Error("Job failed", slog.Duration("duration", finishedAt.Sub(run.StartedAt)), slog.Any("error", err))
+	} else {
+		run.Status = models.JobRunStatusSucceeded
+		span.SetAttributes(attribute.Int("job.rooms_affected", affected))
+		log.Info("Job finished", slog.Duration("duration", finishedAt.Sub(run.StartedAt)), slog.Int("rooms_affected", affected))
+	}
+
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), bookkeepingTimeout)
+	defer cancel()
+	if err := s.repo.Update(recordCtx, run); err != nil {
+		log.Error("Failed to record job outcome", slog.Any("error", err))
+	}
+}
+
+func (s *jobsService) execute(ctx context.Context, run *models.JobRun, task Task) (affected int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	report := func(roomsAffected int) {
+		if err := s.repo.UpdateProgress(ctx, run.ID, roomsAffected); err != nil {
+			s.log.Warn("Failed to record job progress", slog.String("run_id", run.ID.String()), slog.Any("error", err))
+		}
+	}
+	return task(ctx, report)
+}
+
+// renew extends the lease until stop is closed. When the lease is lost, or can't be extended
+// before it may have run out, the run is cancelled: another instance can take the lease then.
+func (s *jobsService) renew(ctx context.Context, lock string, stop <-chan struct{}, cancelRun context.CancelCauseFunc) {
+	interval := s.lockTTL / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := s.locker.ExtendLock(ctx, lock, s.instance, s.lockTTL)
+			switch {
+			case err != nil && time.Since(renewed)+interval < s.lockTTL:
+				s.log.Warn("Failed to extend job lease, retrying", slog.String("lock", lock), slog.Any("error", err))
+			case err != nil:
+				s.log.Error("Failed to extend job lease, cancelling the run", slog.String("lock", lock), slog.Any("error", err))
+				cancelRun(errLeaseLost)
+				return
+			case !ok:
+				s.log.Error("Job lease lost while running, cancelling the run", slog.String("lock", lock))
+				cancelRun(errLeaseLost)
+				return
+			default:
+				renewed = time.Now()
+			}
+		}
+	}
+}
+
+func (s *jobsService) release(lock string) {
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+	if err := s.locker.ReleaseLock(ctx, lock, s.instance); err != nil {
+		s.log.Warn("Failed to release job lease", slog.String("lock", lock), slog.Any("error", err))
+	}
+}
+
+func lockName(jobName string, target *string) string {
+	if target != nil {
+		return "job:" + jobName + ":" + *target
+	}
+	return "job:" + jobName
+}
@@ -0,0 +1,212 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"nonza/backend/internal/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memLocker struct {
+	mu     sync.Mutex
+	owners map[string]string
+}
+
+func (l *memLocker) AcquireLock(_ context.Context, name, owner string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, held := l.owners[name]; held {
+		return false, nil
+	}
+	l.owners[name] = owner
+	return true, nil
+}
+
+func (l *memLocker) ExtendLock(_ context.Context, name, owner string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.owners[name] == owner, nil
+}
+
+func (l *memLocker) ReleaseLock(_ context.Context, name, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.owners[name] == owner {
+		delete(l.owners, name)
+	}
+	return nil
+}
+
+type memJobRuns struct {
+	mu   sync.Mutex
+	runs map[uuid.UUID]models.JobRun
+}
+
+func (r *memJobRuns) Create(_ context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.ID = uuid.New()
+	r.runs[run.ID] = *run
+	return nil
+}
+
+func (r *memJobRuns) GetByID(_ context.Context, id uuid.UUID) (*models.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &run, nil
+}
+
+func (r *memJobRuns) GetByJobName(context.Context, string, int) ([]models.JobRun, error) {
+	return nil, nil
+}
+
+func (r *memJobRuns) GetLatestByTarget(context.Context, string, string) (*models.JobRun, error) {
+	return nil, nil
+}
+
+func (r *memJobRuns) UpdateProgress(context.Context, uuid.UUID, int) error { return nil }
+
+func (r *memJobRuns) Update(_ context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[run.ID] = *run
+	return nil
+}
+
+// A second trigger must be rejected while the first run holds the lease, then succeed after it.
+func TestTrigger_LeaseIsExclusive(t *testing.T) {
+	locker := &memLocker{owners: make(map[string]string)}
+	repo := &memJobRuns{runs: make(map[uuid.UUID]models.JobRun)}
+	svc := NewJobsService(repo, locker, time.Minute, slog.Default())
+
+	release := make(chan struct{})
+	if err := svc.Register(Job{Name: "test", Run: func(context.Context) (int, error) {
+		<-release
+		return 3, nil
+	}}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	run, err := svc.Trigger(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("first trigger: %v", err)
+	}
+
+	if _, err := svc.Trigger(context.Background(), "test"); !errors.Is(err, ErrJobLocked) {
+		t.Fatalf("second trigger while running: got %v, want ErrJobLocked", err)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for {
+		stored, _ := repo.GetByID(context.Background(), run.ID)
+		if stored.Status == models.JobRunStatusSucceeded {
+			if stored.RoomsAffected != 3 {
+				t.Errorf("rooms affected: got %d, want 3", stored.RoomsAffected)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run did not finish, status %q", stored.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Lease is released after the run is recorded
+	for time.Now().Before(deadline) {
+		if _, err := svc.Trigger(context.Background(), "test"); err == nil {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("lease was not released after the run finished")
+}
+
+func TestTrigger_UnknownJob(t *testing.T) {
+	svc := NewJobsService(&memJobRuns{runs: make(map[uuid.UUID]models.JobRun)}, &memLocker{owners: make(map[string]string)}, time.Minute, slog.Default())
+	if _, err := svc.Trigger(context.Background(), "missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("got %v, want ErrJobNotFound", err)
+	}
+}
+
+// Stop must cancel a run that outlives the shutdown deadline and still record its outcome.
+func TestStop_CancelsRunsPastDeadline(t *testing.T) {
+	repo := &memJobRuns{runs: make(map[uuid.UUID]models.JobRun)}
+	svc := NewJobsService(repo, &memLocker{owners: make(map[string]string)}, time.Minute, slog.Default())
+
+	started := make(chan struct{})
+	if err := svc.Register(Job{Name: "slow", Run: func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	run, err := svc.Trigger(context.Background(), "slow")
+	if err != nil {
+		t.Fatalf("trigger: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	svc.Stop(ctx)
+
+	stored, _ := repo.GetByID(context.Background(), run.ID)
+	if stored.Status != models.JobRunStatusFailed {
+		t.Errorf("status after Stop: got %q, want %q", stored.Status, models.JobRunStatusFailed)
+	}
+
+	if _, err := svc.Trigger(context.Background(), "slow"); !errors.Is(err, ErrStopped) {
+		t.Errorf("trigger after Stop: got %v, want ErrStopped", err)
+	}
+}
+
+// A run whose lease goes to another instance must stop rather than run alongside it.
+func TestRun_CancelledWhenLeaseLost(t *testing.T) {
+	locker := &memLocker{owners: make(map[string]string)}
+	repo := &memJobRuns{runs: make(map[uuid.UUID]models.JobRun)}
+	svc := NewJobsService(repo, locker, 30*time.Millisecond, slog.Default())
+
+	started, stopped := make(chan struct{}), make(chan struct{})
+	if err := svc.Register(Job{Name: "long", Run: func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+		return 0, ctx.Err()
+	}}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	run, err := svc.Trigger(context.Background(), "long")
+	if err != nil {
+		t.Fatalf("trigger: %v", err)
+	}
+	<-started
+	locker.mu.Lock()
+	locker.owners["job:long"] = "other-instance"
+	locker.mu.Unlock()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("run kept going after its lease was taken")
+	}
+	svc.Stop(context.Background())
+
+	stored, _ := repo.GetByID(context.Background(), run.ID)
+	if stored.Status != models.JobRunStatusFailed || stored.Error == nil || !strings.Contains(*stored.Error, "lease lost") {
+		t.Errorf("run recorded as %q (%v), want failed for the lost lease", stored.Status, stored.Error)
+	}
+}
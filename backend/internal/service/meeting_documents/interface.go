@@ -1,14 +1,15 @@
 package meeting_documents
 
 import (
+	"context"
 	"nonza/backend/internal/models"
 
 	"github.com/google/uuid"
 )
 
 type MeetingDocuments interface {
-	Create(roomID uuid.UUID, title, content string, createdBy *string) (*models.MeetingDocument, error)
-	GetByRoomID(roomID uuid.UUID) (*models.MeetingDocument, error)
-	Update(roomID uuid.UUID, title, content string) (*models.MeetingDocument, error)
-	IncrementVersion(roomID uuid.UUID) error
+	Create(ctx context.Context, roomID uuid.UUID, title, content string, createdBy *string) (*models.MeetingDocument, error)
+	GetByRoomID(ctx context.Context, roomID uuid.UUID) (*models.MeetingDocument, error)
+	Update(ctx context.Context, roomID uuid.UUID, title, content string) (*models.MeetingDocument, error)
+	IncrementVersion(ctx context.Context, roomID uuid.UUID) error
 }
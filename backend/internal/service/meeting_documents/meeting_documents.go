@@ -1,6 +1,7 @@
 package meeting_documents
 
 import (
+	"context"
 	"nonza/backend/internal/models"
 	"nonza/backend/internal/repository"
 
@@ -11,7 +12,7 @@ type meetingDocumentsService struct {
 	repo repository.MeetingDocuments
 }
 
-func (s *meetingDocumentsService) Create(roomID uuid.UUID, title, content string, createdBy *string) (*models.MeetingDocument, error) {
+func (s *meetingDocumentsService) Create(ctx context.Context, roomID uuid.UUID, title, content string, createdBy *string) (*models.MeetingDocument, error) {
 	doc := &models.MeetingDocument{
 		RoomID:    roomID,
 		Title:     title,
@@ -20,19 +21,19 @@ func (s *meetingDocumentsService) Create(roomID uuid.UUID, title, content string
 		CreatedBy: createdBy,
 	}
 
-	if err := s.repo.Create(doc); err != nil {
+	if err := s.repo.Create(ctx, doc); err != nil {
 		return nil, err
 	}
 
 	return doc, nil
 }
 
-func (s *meetingDocumentsService) GetByRoomID(roomID uuid.UUID) (*models.MeetingDocument, error) {
-	return s.repo.GetByRoomID(roomID)
+func (s *meetingDocumentsService) GetByRoomID(ctx context.Context, roomID uuid.UUID) (*models.MeetingDocument, error) {
+	return s.repo.GetByRoomID(ctx, roomID)
 }
 
-func (s *meetingDocumentsService) Update(roomID uuid.UUID, title, content string) (*models.MeetingDocument, error) {
-	doc, err := s.repo.GetByRoomID(roomID)
+func (s *meetingDocumentsService) Update(ctx context.Context, roomID uuid.UUID, title, content string) (*models.MeetingDocument, error) {
+	doc, err := s.repo.GetByRoomID(ctx, roomID)
 	if err != nil {
 		return nil, err
 	}
@@ -40,13 +41,13 @@ func (s *meetingDocumentsService) Update(roomID uuid.UUID, title, content string
 	doc.Title = title
 	doc.Content = content
 
-	if err := s.repo.Update(doc); err != nil {
+	if err := s.repo.Update(ctx, doc); err != nil {
 		return nil, err
 	}
 
 	return doc, nil
 }
 
-func (s *meetingDocumentsService) IncrementVersion(roomID uuid.UUID) error {
-	return s.repo.IncrementVersion(roomID)
+func (s *meetingDocumentsService) IncrementVersion(ctx context.Context, roomID uuid.UUID) error {
+	return s.repo.IncrementVersion(ctx, roomID)
 }
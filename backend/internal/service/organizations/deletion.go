@@ -0,0 +1,108 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+	"nonza/backend/internal/service/domainevents"
+	"nonza/backend/internal/service/jobs"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeletionJobName is the job name organization deletions are recorded under
+const DeletionJobName = "delete_organization"
+
+const (
+	deletionBatchSize = 100
+	deletionReason    = "organization_deleted"
+)
+
+// RequestDeletion marks the organization for deletion and starts removing its data in the background.
+// Calling it again for an organization whose deletion failed resumes the work.
+func (s *organizationsService) RequestDeletion(ctx context.Context, id uuid.UUID) (*models.JobRun, error) {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.MarkForDeletion(ctx, id, time.Now()); err != nil {
+		return nil, fmt.Errorf("mark organization for deletion: %w", err)
+	}
+
+	run, err := s.jobs.RunTask(ctx, DeletionJobName, id.String(), func(ctx context.Context, report func(int)) (int, error) {
+		return s.deleteOrganization(ctx, id, report)
+	})
+	if errors.Is(err, jobs.ErrJobLocked) {
+		return nil, ErrDeletionInProgress
+	}
+	return run, err
+}
+
+func (s *organizationsService) GetDeletionStatus(ctx context.Context, id uuid.UUID) (*models.JobRun, error) {
+	run, err := s.jobs.GetLatestRun(ctx, DeletionJobName, id.String())
+	if errors.Is(err, jobs.ErrRunNotFound) {
+		return nil, ErrDeletionNotFound
+	}
+	return run, err
+}
+
+// deleteOrganization deletes the organization's rooms batch by batch, then the organization
+// itself. Each room's room.deleted and room.closed events commit with its deletion; the hubs
+// disconnect its clients and a subscriber ends its call, as for a room deleted on its own.
+func (s *organizationsService) deleteOrganization(ctx context.Context, id uuid.UUID, report func(int)) (int, error) {
+	deleted := 0
+	for {
+		rooms, err := s.roomsRepo.GetBatchByOrganizationID(ctx, id, deletionBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("load rooms: %w", err)
+		}
+		if len(rooms) == 0 {
+			break
+		}
+
+		var n int64
+		err = s.bus.Transaction(ctx, func(tx *repository.Repositories) ([]domainevents.Event, error) {
+			ids := make([]uuid.UUID, len(rooms))
+			events := make([]domainevents.Event, 0, 2*len(rooms))
+			for i, room := range rooms {
+				ids[i] = room.ID
+				event, err := domainevents.NewEvent(domainevents.RoomDeleted, room.ID, id, domainevents.RoomDeletedPayload{
+					OrganizationID: id.String(),
+					Name:           room.Name,
+					LiveKitRoom:    room.LiveKitRoomName,
+					Reason:         deletionReason,
+				})
+				if err != nil {
+					return nil, err
+				}
+				closed, err := domainevents.NewEvent(domainevents.RoomClosed, room.ID, id, domainevents.ClosedPayload{Reason: deletionReason})
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, event, closed)
+			}
+
+			var err error
+			if n, err = tx.Rooms.DeletePermanently(ctx, ids); err != nil {
+				return nil, fmt.Errorf("delete rooms: %w", err)
+			}
+			return events, nil
+		})
+		if err != nil {
+			return deleted, err
+		}
+		deleted += int(n)
+		report(deleted)
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return deleted, fmt.Errorf("delete organization: %w", err)
+	}
+
+	s.log.Info("Deleted organization", slog.String("organization_id", id.String()), slog.Int("rooms", deleted))
+	return deleted, nil
+}
@@ -1,7 +1,18 @@
 package organizations
 
-import "nonza/backend/internal/repository"
+import (
+	"log/slog"
+	"nonza/backend/internal/repository"
+	"nonza/backend/internal/service/domainevents"
+	"nonza/backend/internal/service/jobs"
+)
 
-func NewOrganizationsService(repo repository.Organizations) Organizations {
-	return &organizationsService{repo: repo}
+func NewOrganizationsService(repo repository.Organizations, roomsRepo repository.Rooms, jobs jobs.Jobs, bus domainevents.Bus, log *slog.Logger) Organizations {
+	return &organizationsService{
+		repo:      repo,
+		roomsRepo: roomsRepo,
+		jobs:      jobs,
+		bus:       bus,
+		log:       log.With(slog.String("component", "organizations")),
+	}
 }
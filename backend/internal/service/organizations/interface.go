@@ -1,14 +1,34 @@
 package organizations
 
 import (
+	"context"
+	"nonza/backend/internal/apperrors"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrOrganizationNotFound = apperrors.NotFound("organization_not_found", "organization not found")
+	ErrDeletionNotFound     = apperrors.NotFound("organization_deletion_not_found", "organization deletion not found")
+	ErrOrganizationDeleting = apperrors.Conflict("organization_deleting", "organization is being deleted")
+	ErrDeletionInProgress   = apperrors.Conflict("organization_deletion_in_progress", "organization deletion is already in progress")
+	ErrSlugTaken            = apperrors.Conflict("organization_slug_taken", "slug is already used by another organization")
+)
+
+// SortFields are the fields organization listings can be sorted by
+var SortFields = pagination.Fields{"created_at": pagination.KindTime, "name": pagination.KindString}
+
 type Organizations interface {
-	Create(name, description string) (*models.Organization, error)
-	GetByID(id uuid.UUID) (*models.Organization, error)
-	Update(id uuid.UUID, name, description string) (*models.Organization, error)
-	Delete(id uuid.UUID) error
+	// Create creates an organization; an empty slug leaves it without one
+	Create(ctx context.Context, name, description, slug string) (*models.Organization, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	// GetByRef returns the organization a vanity link names, by slug or by ID; moved reports a former slug
+	GetByRef(ctx context.Context, ref string) (org *models.Organization, moved bool, err error)
+	// List returns a page of the organizations matching the filter and the cursor of the next page, empty on the last one
+	List(ctx context.Context, filter models.OrganizationFilter, page pagination.Page) ([]models.Organization, string, error)
+	Update(ctx context.Context, id uuid.UUID, name, description string, slug *string, archiveRetentionDays *int, wsLimits *models.WSLimits, shortCodes *models.ShortCodeFormat) (*models.Organization, error)
+	RequestDeletion(ctx context.Context, id uuid.UUID) (*models.JobRun, error)
+	GetDeletionStatus(ctx context.Context, id uuid.UUID) (*models.JobRun, error)
 }
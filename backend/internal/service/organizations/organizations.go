@@ -1,56 +1,114 @@
 package organizations
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
 	"nonza/backend/internal/repository"
+	"nonza/backend/internal/service/domainevents"
+	"nonza/backend/internal/service/jobs"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type organizationsService struct {
-	repo repository.Organizations
+	repo      repository.Organizations
+	roomsRepo repository.Rooms
+	jobs      jobs.Jobs
+	bus       domainevents.Bus
+	log       *slog.Logger
 }
 
-func (s *organizationsService) Create(name, description string) (*models.Organization, error) {
-	log.Printf("[OrganizationsService] Create called: name=%s, description=%s", name, description)
-	
+func (s *organizationsService) Create(ctx context.Context, name, description, slug string) (*models.Organization, error) {
 	org := &models.Organization{
 		Name:        name,
 		Description: description,
 		Settings:    make(models.JSONB),
 	}
+	if slug != "" {
+		if err := s.checkSlug(ctx, org, slug); err != nil {
+			return nil, err
+		}
+		org.Slug = &slug
+	}
 
-	log.Printf("[OrganizationsService] Calling repository.Create for organization: name=%s", name)
-	if err := s.repo.Create(org); err != nil {
-		log.Printf("[OrganizationsService] Repository.Create failed: %v", err)
+	if err := s.repo.Create(ctx, org); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrSlugTaken
+		}
 		return nil, err
 	}
 
-	log.Printf("[OrganizationsService] Successfully created organization: id=%s, name=%s", org.ID, org.Name)
+	s.log.Info("Created organization", slog.String("organization_id", org.ID.String()))
 	return org, nil
 }
 
-func (s *organizationsService) GetByID(id uuid.UUID) (*models.Organization, error) {
-	return s.repo.GetByID(id)
+func (s *organizationsService) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	org, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrganizationNotFound
+	}
+	return org, err
 }
 
-func (s *organizationsService) Update(id uuid.UUID, name, description string) (*models.Organization, error) {
-	org, err := s.repo.GetByID(id)
+func (s *organizationsService) List(ctx context.Context, filter models.OrganizationFilter, page pagination.Page) ([]models.Organization, string, error) {
+	orgs, err := s.repo.List(ctx, filter, page.Lookahead())
 	if err != nil {
+		return nil, "", err
+	}
+	orgs, next := pagination.Cut(orgs, page, func(o *models.Organization) pagination.Position {
+		if page.Sort.Field == "name" {
+			return pagination.Position{Value: o.Name, ID: o.ID}
+		}
+		return pagination.Position{Value: o.CreatedAt, ID: o.ID}
+	})
+	return orgs, next, nil
+}
+
+// Update replaces the organization's settings. Links with a slug it no longer has redirect to
+// its current one until another organization takes the former slug.
+func (s *organizationsService) Update(ctx context.Context, id uuid.UUID, name, description string, slug *string, archiveRetentionDays *int, wsLimits *models.WSLimits, shortCodes *models.ShortCodeFormat) (*models.Organization, error) {
+	org, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if org.DeletionRequestedAt != nil {
+		return nil, ErrOrganizationDeleting
+	}
+
+	if slug != nil {
+		if err := s.checkSlug(ctx, org, *slug); err != nil {
+			return nil, err
+		}
+	}
+	if _, _, err := ShortCodeGenerator(shortCodes); err != nil {
 		return nil, err
 	}
 
 	org.Name = name
 	org.Description = description
+	org.ArchiveRetentionDays = archiveRetentionDays
+	org.WSLimits = wsLimits
+	org.ShortCodes = shortCodes
 
-	if err := s.repo.Update(org); err != nil {
+	err = s.bus.Transaction(ctx, func(tx *repository.Repositories) ([]domainevents.Event, error) {
+		if err := setSlug(ctx, tx, org, slug); err != nil {
+			return nil, err
+		}
+		if err := tx.Organizations.Update(ctx, org); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return nil, ErrSlugTaken
+			}
+			return nil, err
+		}
+		return nil, nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return org, nil
 }
-
-func (s *organizationsService) Delete(id uuid.UUID) error {
-	return s.repo.Delete(id)
-}
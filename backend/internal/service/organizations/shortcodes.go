@@ -0,0 +1,46 @@
+package organizations
+
+import (
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/models"
+	"nonza/backend/pkg/room"
+)
+
+// ShortCodeGenerator returns the generator of an organization's short code format, nil for
+// the default one. Returns the strategy it uses, for metrics, and a field error of a bad format.
+func ShortCodeGenerator(format *models.ShortCodeFormat) (room.CodeGenerator, string, error) {
+	if format == nil {
+		return room.DefaultCodes, models.ShortCodeRandom, nil
+	}
+
+	var (
+		generator room.CodeGenerator
+		err       error
+	)
+	switch format.Strategy {
+	case models.ShortCodeRandom:
+		alphabet, length := format.Alphabet, format.Length
+		if alphabet == "" {
+			alphabet = room.DefaultAlphabet
+		}
+		if length == 0 {
+			length = room.DefaultLength
+		}
+		generator, err = room.NewRandomCodes(alphabet, length)
+	case models.ShortCodeWords:
+		words, count := format.Words, format.WordCount
+		if len(words) == 0 {
+			words = room.DefaultWords
+		}
+		if count == 0 {
+			count = room.DefaultWordCount
+		}
+		generator, err = room.NewWordCodes(words, count)
+	default:
+		return nil, "", apperrors.InvalidField("short_codes.strategy", "oneof", "must be random or words")
+	}
+	if err != nil {
+		return nil, "", apperrors.InvalidField("short_codes", "invalid", err.Error())
+	}
+	return generator, format.Strategy, nil
+}
@@ -0,0 +1,95 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+	"nonza/backend/pkg/room"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ValidateSlug checks the slug of an organization or a room, reporting a bad one as an error
+// of the slug field
+func ValidateSlug(slug string) error {
+	err := room.ValidateSlug(slug)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, room.ErrSlugLength):
+		return apperrors.InvalidField("slug", "length", err.Error())
+	case errors.Is(err, room.ErrSlugReserved):
+		return apperrors.InvalidField("slug", "reserved", err.Error())
+	default:
+		return apperrors.InvalidField("slug", "format", err.Error())
+	}
+}
+
+// GetByRef returns the organization a vanity link names, by slug or by ID. moved reports a
+// slug the organization had before and has changed since.
+func (s *organizationsService) GetByRef(ctx context.Context, ref string) (*models.Organization, bool, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		org, err := s.GetByID(ctx, id)
+		return org, false, err
+	}
+	org, err := s.repo.GetBySlug(ctx, ref)
+	if err == nil {
+		return org, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	redirect, err := s.repo.GetSlugRedirect(ctx, ref)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	org, err = s.GetByID(ctx, redirect.OrganizationID)
+	if err != nil {
+		return nil, false, err
+	}
+	return org, true, nil
+}
+
+// checkSlug validates a slug the organization is to have and that no other organization has it.
+// A slug other organizations used to have may be taken over.
+func (s *organizationsService) checkSlug(ctx context.Context, org *models.Organization, slug string) error {
+	if err := ValidateSlug(slug); err != nil {
+		return err
+	}
+	other, err := s.repo.GetBySlug(ctx, slug)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil
+	case err != nil:
+		return err
+	case other.ID != org.ID:
+		return ErrSlugTaken
+	}
+	return nil
+}
+
+// setSlug gives the organization the slug, or takes its slug away when slug is nil. The former
+// slug is kept as a redirect to the organization, and a redirect of the new one is dropped.
+func setSlug(ctx context.Context, tx *repository.Repositories, org *models.Organization, slug *string) error {
+	if slug != nil {
+		if err := tx.Organizations.DeleteSlugRedirect(ctx, *slug); err != nil {
+			return fmt.Errorf("drop slug redirect: %w", err)
+		}
+	}
+	if org.Slug != nil && (slug == nil || *slug != *org.Slug) {
+		redirect := &models.OrganizationSlugRedirect{Slug: *org.Slug, OrganizationID: org.ID}
+		if err := tx.Organizations.SaveSlugRedirect(ctx, redirect); err != nil {
+			return fmt.Errorf("keep former slug: %w", err)
+		}
+	}
+	org.Slug = slug
+	return nil
+}
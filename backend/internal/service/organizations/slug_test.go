@@ -0,0 +1,94 @@
+package organizations
+
+import (
+	"context"
+	"testing"
+
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+	"nonza/backend/internal/service/domainevents"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryOrganizations is the part of the organizations repository slugs use, holding one organization
+type memoryOrganizations struct {
+	repository.Organizations
+	org       models.Organization
+	redirects map[string]uuid.UUID
+}
+
+func (r *memoryOrganizations) GetByID(context.Context, uuid.UUID) (*models.Organization, error) {
+	org := r.org
+	return &org, nil
+}
+
+func (r *memoryOrganizations) GetBySlug(_ context.Context, slug string) (*models.Organization, error) {
+	if r.org.Slug == nil || *r.org.Slug != slug {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.GetByID(context.Background(), r.org.ID)
+}
+
+func (r *memoryOrganizations) GetSlugRedirect(_ context.Context, slug string) (*models.OrganizationSlugRedirect, error) {
+	orgID, ok := r.redirects[slug]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.OrganizationSlugRedirect{Slug: slug, OrganizationID: orgID}, nil
+}
+
+func (r *memoryOrganizations) SaveSlugRedirect(_ context.Context, redirect *models.OrganizationSlugRedirect) error {
+	r.redirects[redirect.Slug] = redirect.OrganizationID
+	return nil
+}
+
+func (r *memoryOrganizations) DeleteSlugRedirect(_ context.Context, slug string) error {
+	delete(r.redirects, slug)
+	return nil
+}
+
+func (r *memoryOrganizations) Update(_ context.Context, org *models.Organization) error {
+	r.org = *org
+	return nil
+}
+
+// directBus runs transactions straight against the repositories
+type directBus struct {
+	domainevents.Bus
+	repos *repository.Repositories
+}
+
+func (b *directBus) Transaction(_ context.Context, fn func(tx *repository.Repositories) ([]domainevents.Event, error)) error {
+	_, err := fn(b.repos)
+	return err
+}
+
+func TestUpdateSlugRedirectsFormerOne(t *testing.T) {
+	slug := "acme"
+	repo := &memoryOrganizations{org: models.Organization{ID: uuid.New(), Slug: &slug}, redirects: map[string]uuid.UUID{}}
+	service := &organizationsService{repo: repo, bus: &directBus{repos: &repository.Repositories{Organizations: repo}}}
+	ctx := context.Background()
+
+	renamed := "acme-corp"
+	if _, err := service.Update(ctx, repo.org.ID, "Acme", "", &renamed, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	org, moved, err := service.GetByRef(ctx, "acme")
+	if err != nil || !moved || *org.Slug != renamed {
+		t.Fatalf("former slug: org=%v moved=%v err=%v, want a move to %s", org, moved, err, renamed)
+	}
+	if _, moved, err := service.GetByRef(ctx, renamed); err != nil || moved {
+		t.Fatalf("current slug: moved=%v err=%v", moved, err)
+	}
+
+	// Taking the former slug back drops its redirect
+	if _, err := service.Update(ctx, repo.org.ID, "Acme", "", &slug, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := repo.redirects[slug]; ok {
+		t.Error("the organization's own slug still redirects")
+	}
+}
@@ -0,0 +1,17 @@
+package roomevents
+
+import (
+	"log/slog"
+	"time"
+)
+
+func NewRoomEventsService(stream Stream, retention time.Duration, log *slog.Logger) RoomEvents {
+	return &roomEventsService{
+		stream:    stream,
+		retention: retention,
+		log:       log.With(slog.String("component", "room_events")),
+		rooms:     make(map[string]*tailedRoom),
+		wake:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+}
@@ -0,0 +1,86 @@
+package roomevents
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/repository/redis"
+	"nonza/backend/internal/service/domainevents"
+)
+
+// Event types published on a room's event stream: the domain events of the room (see Forward)
+// and room.expiring, published directly by the expiry warning job
+const (
+	RoomCreated       = domainevents.RoomCreated
+	RoomUpdated       = domainevents.RoomUpdated
+	RoomDeleted       = domainevents.RoomDeleted
+	ParticipantJoined = domainevents.ParticipantJoined
+	ParticipantLeft   = domainevents.ParticipantLeft
+	DocumentUpdated   = domainevents.DocumentUpdated
+	RoomExpiring      = "room.expiring"
+	RoomExpired       = domainevents.RoomExpired
+	RoomClosed        = domainevents.RoomClosed
+)
+
+// StreamedEvents are the domain events Forward puts on room streams
+var StreamedEvents = []string{RoomCreated, RoomUpdated, RoomDeleted, ParticipantJoined, ParticipantLeft, DocumentUpdated, RoomExpired, RoomClosed}
+
+var (
+	ErrInvalidEventID = apperrors.InvalidField("last_event_id", "invalid_event_id", "invalid event id")
+	ErrStopped        = apperrors.Unavailable("shutting_down", "room events service is shutting down")
+)
+
+// Event is a published room event. ID orders the events of a room and is what a
+// subscriber passes back to resume after it.
+type Event struct {
+	ID     string
+	RoomID string
+	Type   string
+	Data   json.RawMessage
+}
+
+// Time returns when the event was published, taken from its ID
+func (e Event) Time() time.Time {
+	ms, _, _ := parseID(e.ID)
+	return time.UnixMilli(int64(ms))
+}
+
+// Subscription delivers a room's events. Backlog holds the events after the ID the subscriber
+// resumed from and comes before anything on Events. Events is closed when the subscriber falls
+// too far behind or the service stops; the subscriber should resume from the last ID it saw.
+type Subscription struct {
+	Backlog []Event
+	Events  <-chan Event
+
+	close func()
+}
+
+// Close stops delivery to the subscription
+func (s *Subscription) Close() {
+	s.close()
+}
+
+// Stream stores room events and waits for new ones
+type Stream interface {
+	AppendRoomEvent(ctx context.Context, roomID, eventType string, data []byte, limit int64, ttl time.Duration) (string, error)
+	MarkRoomEventOnce(ctx context.Context, roomID, key string, ttl time.Duration) (bool, error)
+	LastRoomEventID(ctx context.Context, roomID string) (string, error)
+	RoomEventsBetween(ctx context.Context, roomID, after, upTo string) ([]redis.RoomEvent, error)
+	ReadRoomEvents(ctx context.Context, after map[string]string, block time.Duration) ([]redis.RoomEvent, error)
+}
+
+// RoomEvents is the bus for room events (participants, document changes, expiry). Events are
+// kept in Redis for a while, so subscribers on any instance see them and can resume after a reconnect.
+type RoomEvents interface {
+	Publish(ctx context.Context, roomID, eventType string, data any) error
+	// PublishOnce publishes the event unless one with the same key was published for the room within ttl
+	PublishOnce(ctx context.Context, roomID, key string, ttl time.Duration, eventType string, data any) error
+	// Forward is the event bus subscriber that publishes domain events on their room's stream
+	Forward(ctx context.Context, event domainevents.Event) error
+	// Subscribe delivers the room's events published from now on, preceded by those after lastEventID if set
+	Subscribe(ctx context.Context, roomID, lastEventID string) (*Subscription, error)
+	Start()
+	Stop()
+}
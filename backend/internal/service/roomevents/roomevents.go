@@ -0,0 +1,278 @@
+package roomevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nonza/backend/internal/repository/redis"
+	"nonza/backend/internal/service/domainevents"
+)
+
+const (
+	// streamLength is about how many events each room keeps for subscribers resuming after a reconnect
+	streamLength = 1000
+
+	// tailBlock is how long one read of the tailed streams waits for events. A room that gets its
+	// first subscriber on this instance while a read is waiting is tailed from the next read on.
+	tailBlock = 2 * time.Second
+
+	// subscriberBuffer is how many live events a subscriber may lag behind before it is dropped
+	subscriberBuffer = 64
+
+	// retryDelay is how long the tail waits after a failed read
+	retryDelay = time.Second
+)
+
+type roomEventsService struct {
+	stream    Stream
+	retention time.Duration
+	log       *slog.Logger
+
+	mu      sync.Mutex
+	rooms   map[string]*tailedRoom // Rooms with subscribers on this instance
+	stopped bool
+
+	wake    chan struct{} // Signals the idle tail that a room was added
+	started atomic.Bool
+	cancel  context.CancelFunc // Stops the tail; set by Start
+	done    chan struct{}      // Closed when the tail has returned
+}
+
+// tailedRoom is a room whose stream is read for its subscribers on this instance
+type tailedRoom struct {
+	cursor string // ID of the last event read from the room's stream
+	subs   map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	ch    chan Event
+	after string // events up to this ID came from the backlog (or predate the subscription)
+}
+
+func (s *roomEventsService) Publish(ctx context.Context, roomID, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+	if _, err := s.stream.AppendRoomEvent(ctx, roomID, eventType, payload, streamLength, s.retention); err != nil {
+		return fmt.Errorf("append %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+func (s *roomEventsService) PublishOnce(ctx context.Context, roomID, key string, ttl time.Duration, eventType string, data any) error {
+	first, err := s.stream.MarkRoomEventOnce(ctx, roomID, key, ttl)
+	if err != nil {
+		return fmt.Errorf("mark %s event: %w", eventType, err)
+	}
+	if !first {
+		return nil
+	}
+	return s.Publish(ctx, roomID, eventType, data)
+}
+
+// Forward publishes a domain event on its room's stream. A retried event may show up twice.
+func (s *roomEventsService) Forward(ctx context.Context, event domainevents.Event) error {
+	return s.Publish(ctx, event.RoomID.String(), event.Type, event.Data)
+}
+
+func (s *roomEventsService) Subscribe(ctx context.Context, roomID, lastEventID string) (*Subscription, error) {
+	if lastEventID != "" {
+		if _, _, ok := parseID(lastEventID); !ok {
+			return nil, ErrInvalidEventID
+		}
+	}
+
+	latest, err := s.stream.LastRoomEventID(ctx, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("get last room event: %w", err)
+	}
+
+	// Live delivery starts after latest; the backlog covers what came before it
+	sub := &subscriber{ch: make(chan Event, subscriberBuffer), after: latest}
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return nil, ErrStopped
+	}
+	room := s.rooms[roomID]
+	if room == nil {
+		room = &tailedRoom{cursor: latest, subs: make(map[*subscriber]struct{})}
+		s.rooms[roomID] = room
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	room.subs[sub] = struct{}{}
+	s.mu.Unlock()
+
+	subscription := &Subscription{
+		Events: sub.ch,
+		close:  func() { s.unsubscribe(roomID, sub) },
+	}
+
+	if lastEventID != "" && after(latest, lastEventID) {
+		events, err := s.stream.RoomEventsBetween(ctx, roomID, lastEventID, latest)
+		if err != nil {
+			subscription.Close()
+			return nil, fmt.Errorf("read room events: %w", err)
+		}
+		subscription.Backlog = make([]Event, len(events))
+		for i, e := range events {
+			subscription.Backlog[i] = toEvent(e)
+		}
+	}
+
+	return subscription, nil
+}
+
+func (s *roomEventsService) unsubscribe(roomID string, sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if room := s.rooms[roomID]; room != nil {
+		s.dropLocked(roomID, room, sub)
+	}
+}
+
+// dropLocked removes a subscriber and stops tailing its room if it was the last one. The caller holds s.mu.
+func (s *roomEventsService) dropLocked(roomID string, room *tailedRoom, sub *subscriber) {
+	if _, ok := room.subs[sub]; !ok {
+		return
+	}
+	delete(room.subs, sub)
+	close(sub.ch)
+	if len(room.subs) == 0 {
+		delete(s.rooms, roomID)
+	}
+}
+
+// Start begins tailing the streams of rooms that have subscribers
+func (s *roomEventsService) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.started.Store(true)
+	go s.tail(ctx)
+}
+
+// Stop ends the tail and closes every subscription
+func (s *roomEventsService) Stop() {
+	if s.started.Load() {
+		s.cancel()
+		<-s.done
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	for roomID, room := range s.rooms {
+		for sub := range room.subs {
+			s.dropLocked(roomID, room, sub)
+		}
+	}
+}
+
+// tail reads the streams of all rooms with subscribers on this instance and fans their events out
+func (s *roomEventsService) tail(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		s.mu.Lock()
+		cursors := make(map[string]string, len(s.rooms))
+		for roomID, room := range s.rooms {
+			cursors[roomID] = room.cursor
+		}
+		s.mu.Unlock()
+
+		if len(cursors) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.wake:
+			}
+			continue
+		}
+
+		events, err := s.stream.ReadRoomEvents(ctx, cursors, tailBlock)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.log.Warn("Failed to read room events", slog.Any("error", err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+		s.deliver(events)
+	}
+}
+
+// deliver hands events to the subscribers of their rooms. A subscriber whose buffer is full is
+// dropped rather than allowed to hold up the others; it resumes from the last event it got.
+func (s *roomEventsService) deliver(events []redis.RoomEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range events {
+		room := s.rooms[e.RoomID]
+		if room == nil {
+			continue
+		}
+		if after(e.ID, room.cursor) {
+			room.cursor = e.ID
+		}
+
+		event := toEvent(e)
+		for sub := range room.subs {
+			if !after(e.ID, sub.after) {
+				continue
+			}
+			select {
+			case sub.ch <- event:
+			default:
+				s.log.Debug("Dropping slow room event subscriber", slog.String("room_id", e.RoomID))
+				s.dropLocked(e.RoomID, room, sub)
+			}
+		}
+	}
+}
+
+func toEvent(e redis.RoomEvent) Event {
+	return Event{ID: e.ID, RoomID: e.RoomID, Type: e.Type, Data: json.RawMessage(e.Data)}
+}
+
+// parseID splits a stream ID "<ms>-<n>" into its parts
+func parseID(id string) (ms, n uint64, ok bool) {
+	msPart, nPart, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	n, err = strconv.ParseUint(nPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, n, true
+}
+
+// after reports whether event ID a comes after b
+func after(a, b string) bool {
+	aMs, aN, _ := parseID(a)
+	bMs, bN, _ := parseID(b)
+	return aMs > bMs || (aMs == bMs && aN > bN)
+}
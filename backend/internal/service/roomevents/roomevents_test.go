@@ -0,0 +1,145 @@
+package roomevents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"nonza/backend/internal/repository/redis"
+)
+
+// memoryStream is a Stream kept in memory; ReadRoomEvents polls instead of blocking
+type memoryStream struct {
+	mu     sync.Mutex
+	events map[string][]redis.RoomEvent
+	next   uint64
+}
+
+func (m *memoryStream) AppendRoomEvent(_ context.Context, roomID, eventType string, data []byte, _ int64, _ time.Duration) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.next++
+	id := fmt.Sprintf("1-%d", m.next)
+	m.events[roomID] = append(m.events[roomID], redis.RoomEvent{ID: id, RoomID: roomID, Type: eventType, Data: data})
+	return id, nil
+}
+
+func (m *memoryStream) MarkRoomEventOnce(context.Context, string, string, time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (m *memoryStream) LastRoomEventID(_ context.Context, roomID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if events := m.events[roomID]; len(events) > 0 {
+		return events[len(events)-1].ID, nil
+	}
+	return "0-0", nil
+}
+
+func (m *memoryStream) RoomEventsBetween(_ context.Context, roomID, from, upTo string) ([]redis.RoomEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []redis.RoomEvent
+	for _, e := range m.events[roomID] {
+		if after(e.ID, from) && !after(e.ID, upTo) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryStream) ReadRoomEvents(ctx context.Context, cursors map[string]string, _ time.Duration) ([]redis.RoomEvent, error) {
+	for {
+		m.mu.Lock()
+		var out []redis.RoomEvent
+		for roomID, cursor := range cursors {
+			for _, e := range m.events[roomID] {
+				if after(e.ID, cursor) {
+					out = append(out, e)
+				}
+			}
+		}
+		m.mu.Unlock()
+		if len(out) > 0 {
+			return out, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case e, ok := <-sub.Events:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event delivered")
+	}
+	return Event{}
+}
+
+func TestSubscribeResumesAfterLastEventID(t *testing.T) {
+	stream := &memoryStream{events: make(map[string][]redis.RoomEvent)}
+	svc := NewRoomEventsService(stream, time.Hour, slog.New(slog.DiscardHandler))
+	svc.Start()
+	defer svc.Stop()
+	ctx := context.Background()
+
+	for _, user := range []string{"a", "b", "c"} {
+		if err := svc.Publish(ctx, "room", ParticipantJoined, map[string]string{"user_id": user}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sub, err := svc.Subscribe(ctx, "room", "1-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	if len(sub.Backlog) != 2 || sub.Backlog[0].ID != "1-2" || sub.Backlog[1].ID != "1-3" {
+		t.Fatalf("backlog = %+v, want events 1-2 and 1-3", sub.Backlog)
+	}
+
+	if err := svc.Publish(ctx, "room", ParticipantLeft, map[string]string{"user_id": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if e := receive(t, sub); e.ID != "1-4" || e.Type != ParticipantLeft {
+		t.Errorf("live event = %+v, want participant.left 1-4 without repeating the backlog", e)
+	}
+}
+
+func TestSubscribeRejectsInvalidEventID(t *testing.T) {
+	svc := NewRoomEventsService(&memoryStream{events: make(map[string][]redis.RoomEvent)}, time.Hour, slog.New(slog.DiscardHandler))
+	if _, err := svc.Subscribe(context.Background(), "room", "yesterday"); err != ErrInvalidEventID {
+		t.Errorf("err = %v, want ErrInvalidEventID", err)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1700000000000-1", "1700000000000-0", true},
+		{"1700000000001-0", "1700000000000-9", true},
+		{"1700000000000-0", "1700000000000-0", false},
+		{"0-0", "1700000000000-0", false},
+	}
+	for _, tt := range tests {
+		if got := after(tt.a, tt.b); got != tt.want {
+			t.Errorf("after(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package rooms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+	"nonza/backend/internal/service/domainevents"
+	"nonza/backend/internal/service/roomevents"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job names the archival tasks are registered under in the jobs service
+const (
+	ArchiveJobName       = "archive_expired_rooms"
+	PurgeJobName         = "purge_archived_rooms"
+	ExpiryWarningJobName = "warn_expiring_rooms"
+)
+
+// errNotArchived rolls back the archiving of a room another run archived first
+var errNotArchived = errors.New("room is no longer active")
+
+// ArchiveExpired moves expired rooms to the archived state. The Y.js document of each room
+// is snapshotted to Postgres before it is removed from Redis; a room whose snapshot fails
+// stays active and is retried on the next run. Returns the number of rooms archived.
+func (s *roomsService) ArchiveExpired(ctx context.Context) (int, error) {
+	expiredRooms, err := s.repo.GetExpired(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("get expired rooms: %w", err)
+	}
+
+	if len(expiredRooms) == 0 {
+		return 0, nil
+	}
+
+	s.log.Info("Archiving expired rooms", slog.Int("count", len(expiredRooms)))
+
+	archived := 0
+	var lastErr error
+	for _, room := range expiredRooms {
+		err := s.archive(ctx, room)
+		if errors.Is(err, errNotArchived) {
+			continue
+		}
+		if err != nil {
+			s.log.Error("Failed to archive room", slog.String("room_id", room.ID.String()), slog.Any("error", err))
+			lastErr = err
+			continue
+		}
+		archived++
+	}
+
+	if archived == 0 && lastErr != nil {
+		return 0, lastErr
+	}
+	return archived, nil
+}
+
+func (s *roomsService) archive(ctx context.Context, room models.Room) error {
+	state, err := s.documents.GetDocumentState(ctx, room.ID.String())
+	if err != nil {
+		return fmt.Errorf("load document state: %w", err)
+	}
+
+	// The snapshot, the archived state and their events commit together; the Redis copy of the
+	// document is dropped by the room.expired subscriber (DiscardDocument) once they have
+	return s.bus.Transaction(ctx, func(tx *repository.Repositories) ([]domainevents.Event, error) {
+		// Archiving first locks the room: a concurrent run, e.g. one triggered by an admin, waits
+		// here and finds the room archived, so it neither overwrites the snapshot with the state
+		// it read before nor announces the room again
+		archived, err := tx.Rooms.Archive(ctx, room.ID, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("archive room: %w", err)
+		}
+		if !archived {
+			return nil, errNotArchived
+		}
+
+		var events []domainevents.Event
+		if state != nil {
+			snapshot := &models.DocumentSnapshot{
+				RoomID: room.ID,
+				State:  state,
+				Size:   len(state),
+			}
+			if err := tx.Snapshots.Save(ctx, snapshot); err != nil {
+				return nil, fmt.Errorf("snapshot document: %w", err)
+			}
+			event, err := domainevents.NewEvent(domainevents.DocumentSnapshotted, room.ID, room.OrganizationID,
+				domainevents.DocumentSnapshottedPayload{SnapshotID: snapshot.ID.String(), Size: snapshot.Size})
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+
+		event, err := domainevents.NewEvent(domainevents.RoomExpired, room.ID, room.OrganizationID,
+			domainevents.ExpiryPayload{ExpiresAt: *room.ExpiresAt})
+		if err != nil {
+			return nil, err
+		}
+		return append(events, event), nil
+	})
+}
+
+// DiscardDocument is the room.expired subscriber that removes the archived room's document from
+// Redis: the snapshot is the source of truth now
+func (s *roomsService) DiscardDocument(ctx context.Context, event domainevents.Event) error {
+	return s.documents.DeleteDocumentState(ctx, event.RoomID.String())
+}
+
+// WarnExpiring publishes room.expiring for active rooms that expire within the warning window.
+// A room is announced once per expiry time however often the job runs, and again if its
+// expiry changes. Returns the number of rooms in the window whose warning went out (now or earlier).
+func (s *roomsService) WarnExpiring(ctx context.Context) (int, error) {
+	rooms, err := s.repo.GetExpiringBefore(ctx, time.Now().Add(s.expiryWarning))
+	if err != nil {
+		return 0, fmt.Errorf("get expiring rooms: %w", err)
+	}
+
+	warned := 0
+	var lastErr error
+	for _, room := range rooms {
+		key := fmt.Sprintf("%s:%d", roomevents.RoomExpiring, room.ExpiresAt.Unix())
+		ttl := time.Until(*room.ExpiresAt) + s.expiryWarning
+		err := s.events.PublishOnce(ctx, room.ID.String(), key, ttl, roomevents.RoomExpiring, domainevents.ExpiryPayload{ExpiresAt: *room.ExpiresAt})
+		if err != nil {
+			s.log.Error("Failed to publish room expiry warning", slog.String("room_id", room.ID.String()), slog.Any("error", err))
+			lastErr = err
+			continue
+		}
+		warned++
+	}
+
+	if warned == 0 && lastErr != nil {
+		return 0, lastErr
+	}
+	return warned, nil
+}
+
+// PurgeArchived permanently deletes archived rooms whose retention window has passed.
+// Returns the number of rooms deleted.
+func (s *roomsService) PurgeArchived(ctx context.Context) (int, error) {
+	rooms, err := s.repo.GetPurgeable(ctx, s.defaultRetentionDays, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("get purgeable rooms: %w", err)
+	}
+
+	if len(rooms) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, len(rooms))
+	for i, room := range rooms {
+		ids[i] = room.ID
+	}
+
+	deleted, err := s.repo.DeletePermanently(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("delete archived rooms: %w", err)
+	}
+
+	return int(deleted), nil
+}
@@ -0,0 +1,72 @@
+package rooms
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// archivingRooms archives its single room the way Postgres does: only while it is active
+type archivingRooms struct {
+	memoryRooms
+}
+
+func (r *archivingRooms) GetExpired(context.Context) ([]models.Room, error) {
+	return []models.Room{r.room}, nil
+}
+
+func (r *archivingRooms) Archive(_ context.Context, _ uuid.UUID, at time.Time) (bool, error) {
+	if r.room.Status != models.RoomStatusActive {
+		return false, nil
+	}
+	r.room.Status, r.room.ArchivedAt = models.RoomStatusArchived, &at
+	return true, nil
+}
+
+type savedSnapshots struct {
+	repository.DocumentSnapshots
+	saved int
+}
+
+func (s *savedSnapshots) Save(context.Context, *models.DocumentSnapshot) error {
+	s.saved++
+	return nil
+}
+
+type storedDocument struct {
+	DocumentStore
+}
+
+func (storedDocument) GetDocumentState(context.Context, string) ([]byte, error) {
+	return []byte{1, 2, 3}, nil
+}
+
+func TestArchiveExpiredSkipsRoomArchivedMeanwhile(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Minute)
+	repo := &archivingRooms{memoryRooms{room: models.Room{ID: uuid.New(), Status: models.RoomStatusActive, IsTemporary: true, ExpiresAt: &expiresAt}}}
+	snapshots := &savedSnapshots{}
+	bus := &recordingBus{repos: &repository.Repositories{Rooms: repo, Snapshots: snapshots}}
+	service := &roomsService{repo: repo, documents: storedDocument{}, bus: bus, log: slog.New(slog.DiscardHandler)}
+
+	// Both runs listed the room while it was active
+	room := repo.room
+	if err := service.archive(context.Background(), room); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.archive(context.Background(), room); err != errNotArchived {
+		t.Fatalf("second run: %v, want errNotArchived", err)
+	}
+	if n, err := service.ArchiveExpired(context.Background()); err != nil || n != 0 {
+		t.Fatalf("a later run archived %d rooms (err %v), want none", n, err)
+	}
+
+	if snapshots.saved != 1 || len(bus.events) != 2 {
+		t.Errorf("%d snapshots and %d events, want one snapshot with its document.snapshotted and room.expired", snapshots.saved, len(bus.events))
+	}
+}
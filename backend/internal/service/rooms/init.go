@@ -1,12 +1,26 @@
 package rooms
 
 import (
+	"log/slog"
 	"nonza/backend/internal/repository"
+	"nonza/backend/internal/service/domainevents"
+	"nonza/backend/internal/service/roomevents"
+	"time"
 )
 
-func NewRoomsService(repo repository.Rooms, orgRepo repository.Organizations) Rooms {
+func NewRoomsService(repo repository.Rooms, orgRepo repository.Organizations, participants repository.Participants, snapshots repository.DocumentSnapshots, documents DocumentStore, presence PresenceStore, media MediaRooms, bus domainevents.Bus, events roomevents.RoomEvents, defaultRetentionDays int, expiryWarning time.Duration, log *slog.Logger) Rooms {
 	return &roomsService{
-		repo:    repo,
-		orgRepo: orgRepo,
+		repo:                 repo,
+		orgRepo:              orgRepo,
+		participants:         participants,
+		snapshots:            snapshots,
+		documents:            documents,
+		presence:             presence,
+		media:                media,
+		bus:                  bus,
+		events:               events,
+		defaultRetentionDays: defaultRetentionDays,
+		expiryWarning:        expiryWarning,
+		log:                  log.With(slog.String("component", "rooms")),
 	}
 }
@@ -1,19 +1,94 @@
 package rooms
 
 import (
+	"context"
+	"nonza/backend/internal/apperrors"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
+	"nonza/backend/internal/repository/redis"
+	"nonza/backend/internal/service/domainevents"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrRoomNotFound = apperrors.NotFound("room_not_found", "room not found")
+	ErrRoomArchived = apperrors.Gone("room_archived", "room is archived")
+	// ErrRoomUnavailable is a room whose organization is being deleted
+	ErrRoomUnavailable = apperrors.Gone("room_unavailable", "room is no longer available")
+	// ErrShortCodesExhausted is a room for which no free short code was drawn: the organization's
+	// short code format is close to full
+	ErrShortCodesExhausted = apperrors.Unavailable("short_codes_exhausted", "no free short code for the room, try again or widen the organization's short code format")
+	ErrDocumentNotFound    = apperrors.NotFound("document_not_found", "room has no document")
+	ErrSlugTaken           = apperrors.Conflict("room_slug_taken", "slug is already used by another room of the organization")
+	ErrExpiryInPast        = apperrors.InvalidField("expires_at", "future", "must be in the future")
+	// ErrPermanentExpiry is an expiry given for a room that isn't (or stops being) temporary
+	ErrPermanentExpiry = apperrors.InvalidField("expires_at", "temporary_only", "only temporary rooms expire")
+)
+
+var (
+	// SortFields are the fields room listings can be sorted by
+	SortFields = pagination.Fields{"created_at": pagination.KindTime, "name": pagination.KindString}
+	// ParticipantSortFields are the fields participant listings can be sorted by
+	ParticipantSortFields = pagination.Fields{"joined_at": pagination.KindTime}
+)
+
 type Rooms interface {
-	Create(orgID uuid.UUID, name string, roomType models.RoomType, isTemporary bool, expiresIn *time.Duration, e2eeEnabled bool) (*models.Room, error)
-	GetByID(id uuid.UUID) (*models.Room, error)
-	GetByShortCode(shortCode string) (*models.Room, error)
-	GetByOrganizationID(orgID uuid.UUID) ([]models.Room, error)
-	Update(room *models.Room) error
-	Delete(id uuid.UUID) error
-	DeleteExpired() error
-	GetExpired() ([]models.Room, error)
+	// Create creates a room. Without a slug, a permanent room gets one derived from its name.
+	Create(ctx context.Context, orgID uuid.UUID, name, slug string, roomType models.RoomType, isTemporary bool, expiresIn *time.Duration, e2eeEnabled bool) (*models.Room, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Room, error)
+	GetPresence(ctx context.Context, id uuid.UUID) ([]redis.PresenceEntry, error)
+	// GetDocument returns the Y.js state of the room's document: the live one of an active room,
+	// the snapshot taken when it expired of an archived one
+	GetDocument(ctx context.Context, id uuid.UUID) ([]byte, error)
+	GetByShortCode(ctx context.Context, shortCode string) (*models.Room, error)
+	// ResolveSlug returns the room of the organization with the slug; moved reports a slug the room had before
+	ResolveSlug(ctx context.Context, orgID uuid.UUID, slug string) (room *models.Room, moved bool, err error)
+	// List returns a page of the rooms matching the filter and the cursor of the next page, empty on the last one
+	List(ctx context.Context, filter models.RoomFilter, page pagination.Page) ([]models.Room, string, error)
+	// ListParticipants returns a page of the participants matching the filter and the cursor of the next page
+	ListParticipants(ctx context.Context, filter models.ParticipantFilter, page pagination.Page) ([]models.Participant, string, error)
+	// Update applies the changes to an active room and announces them to its clients and the media server
+	Update(ctx context.Context, id uuid.UUID, changes RoomChanges) (*models.Room, error)
+	// Delete permanently deletes a room, disconnecting everyone in it
+	Delete(ctx context.Context, id uuid.UUID) error
+	GetExpired(ctx context.Context) ([]models.Room, error)
+	ArchiveExpired(ctx context.Context) (int, error)
+	WarnExpiring(ctx context.Context) (int, error)
+	PurgeArchived(ctx context.Context) (int, error)
+	// DiscardDocument handles room.expired on the event bus
+	DiscardDocument(ctx context.Context, event domainevents.Event) error
+	// SyncMediaRoom handles room.updated on the event bus
+	SyncMediaRoom(ctx context.Context, event domainevents.Event) error
+	// TeardownRoom handles room.deleted on the event bus
+	TeardownRoom(ctx context.Context, event domainevents.Event) error
+}
+
+// RoomChanges are the settings Update changes; nil fields keep their value. Making a room
+// permanent clears its expiry and gives it a slug if it has none; an empty Slug removes it.
+type RoomChanges struct {
+	Name        *string
+	Slug        *string
+	RoomType    *models.RoomType
+	IsTemporary *bool
+	ExpiresAt   *time.Time
+	E2EEEnabled *bool
+}
+
+// DocumentStore holds collaborative document state outside Postgres
+type DocumentStore interface {
+	GetDocumentState(ctx context.Context, roomID string) ([]byte, error)
+	DeleteDocumentState(ctx context.Context, roomID string) error
+}
+
+// PresenceStore holds who is connected to each room's document channel, across instances
+type PresenceStore interface {
+	GetPresence(ctx context.Context, roomID string) ([]redis.PresenceEntry, error)
+}
+
+// MediaRooms manages rooms on the WebRTC server
+type MediaRooms interface {
+	DeleteRoom(ctx context.Context, roomName string) error
+	UpdateRoomMetadata(ctx context.Context, roomName, metadata string) error
 }
@@ -0,0 +1,250 @@
+package rooms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+	"nonza/backend/internal/service/domainevents"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// deletionReason is sent to clients of a room that is deleted
+const deletionReason = "room_deleted"
+
+// Update applies the changes to an active room. The change and its room.updated event commit
+// together; connected clients and the LiveKit room hear about it from the event's subscribers.
+// Changes that leave the room as it was publish nothing.
+func (s *roomsService) Update(ctx context.Context, id uuid.UUID, changes RoomChanges) (*models.Room, error) {
+	room, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if room.Status == models.RoomStatusArchived {
+		return nil, ErrRoomArchived
+	}
+
+	var changed []string
+	if changes.Name != nil && *changes.Name != room.Name {
+		room.Name = *changes.Name
+		changed = append(changed, "name")
+	}
+	// The slug is changed in the transaction, where it is checked against the organization's other rooms
+	newSlug := changes.Slug != nil && *changes.Slug != slugOf(room)
+	if newSlug {
+		changed = append(changed, "slug")
+	}
+	if changes.RoomType != nil && *changes.RoomType != room.RoomType {
+		room.RoomType = *changes.RoomType
+		changed = append(changed, "room_type")
+	}
+	becamePermanent := false
+	if changes.IsTemporary != nil && *changes.IsTemporary != room.IsTemporary {
+		room.IsTemporary = *changes.IsTemporary
+		becamePermanent = !room.IsTemporary
+		changed = append(changed, "is_temporary")
+		// A permanent room doesn't expire
+		if !room.IsTemporary && room.ExpiresAt != nil {
+			room.ExpiresAt = nil
+			changed = append(changed, "expires_at")
+		}
+	}
+	if changes.ExpiresAt != nil {
+		if !room.IsTemporary {
+			return nil, ErrPermanentExpiry
+		}
+		if !changes.ExpiresAt.After(time.Now()) {
+			return nil, ErrExpiryInPast
+		}
+		if room.ExpiresAt == nil || !room.ExpiresAt.Equal(*changes.ExpiresAt) {
+			expiresAt := *changes.ExpiresAt
+			room.ExpiresAt = &expiresAt
+			changed = append(changed, "expires_at")
+		}
+	}
+	if changes.E2EEEnabled != nil && *changes.E2EEEnabled != e2eeEnabled(room) {
+		if err := setE2EE(ctx, room, *changes.E2EEEnabled); err != nil {
+			return nil, err
+		}
+		changed = append(changed, "e2ee_enabled")
+	}
+
+	if len(changed) == 0 {
+		return room, nil
+	}
+
+	err = s.bus.Transaction(ctx, func(tx *repository.Repositories) ([]domainevents.Event, error) {
+		switch {
+		case newSlug:
+			if err := setSlug(ctx, tx, room, *changes.Slug); err != nil {
+				return nil, err
+			}
+		case becamePermanent && room.Slug == nil:
+			if err := deriveSlug(ctx, tx, room); err != nil {
+				return nil, err
+			}
+			if room.Slug != nil {
+				changed = append(changed, "slug")
+			}
+		}
+
+		if err := tx.Rooms.Update(ctx, room); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return nil, ErrSlugTaken
+			}
+			return nil, fmt.Errorf("update room: %w", err)
+		}
+		event, err := domainevents.NewEvent(domainevents.RoomUpdated, room.ID, room.OrganizationID, domainevents.RoomUpdatedPayload{
+			Name:        room.Name,
+			Slug:        room.Slug,
+			RoomType:    string(room.RoomType),
+			IsTemporary: room.IsTemporary,
+			ExpiresAt:   room.ExpiresAt,
+			E2EEEnabled: e2eeEnabled(room),
+			Changed:     changed,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []domainevents.Event{event}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// slugOf returns the room's slug, empty if it has none
+func slugOf(room *models.Room) string {
+	if room.Slug == nil {
+		return ""
+	}
+	return *room.Slug
+}
+
+// e2eeEnabled reports whether the room's media is end-to-end encrypted
+func e2eeEnabled(room *models.Room) bool {
+	enabled, _ := room.Settings["e2ee_enabled"].(bool)
+	return enabled
+}
+
+// setE2EE turns end-to-end encryption on with a new key, or off dropping the key. Participants
+// pick the change up with their next token.
+func setE2EE(ctx context.Context, room *models.Room, enabled bool) error {
+	if room.Settings == nil {
+		room.Settings = make(models.JSONB)
+	}
+	if !enabled {
+		delete(room.Settings, "e2ee_enabled")
+		delete(room.Settings, "encryption_key")
+		return nil
+	}
+	key, err := generateE2EEKey(ctx)
+	if err != nil {
+		return err
+	}
+	room.Settings["e2ee_enabled"] = true
+	room.Settings["encryption_key"] = key
+	return nil
+}
+
+// Delete permanently deletes a room in any status with its documents and participants. Its
+// live participants are kicked by the hubs and its state outside Postgres dropped by TeardownRoom.
+func (s *roomsService) Delete(ctx context.Context, id uuid.UUID) error {
+	room, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.bus.Transaction(ctx, func(tx *repository.Repositories) ([]domainevents.Event, error) {
+		if _, err := tx.Rooms.DeletePermanently(ctx, []uuid.UUID{room.ID}); err != nil {
+			return nil, fmt.Errorf("delete room: %w", err)
+		}
+		event, err := domainevents.NewEvent(domainevents.RoomDeleted, room.ID, room.OrganizationID, domainevents.RoomDeletedPayload{
+			OrganizationID: room.OrganizationID.String(),
+			Name:           room.Name,
+			LiveKitRoom:    room.LiveKitRoomName,
+			Reason:         deletionReason,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// room.closed goes out once here rather than from each hub that disconnects clients
+		closed, err := domainevents.NewEvent(domainevents.RoomClosed, room.ID, room.OrganizationID, domainevents.ClosedPayload{Reason: deletionReason})
+		if err != nil {
+			return nil, err
+		}
+		return []domainevents.Event{event, closed}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.log.Info("Deleted room", slog.String("room_id", room.ID.String()))
+	return nil
+}
+
+// TeardownRoom is the room.deleted subscriber, for rooms deleted on their own and with their
+// organization, that ends the room's LiveKit call and drops its document from Redis. Both steps
+// are harmless to repeat, so a failed one fails the handler and the bus runs them again. The
+// room's clients are disconnected by the hub of each instance (see websocket.Hub.Broadcast).
+func (s *roomsService) TeardownRoom(ctx context.Context, event domainevents.Event) error {
+	var payload domainevents.RoomDeletedPayload
+	if err := json.Unmarshal(event.Data, &payload); err != nil {
+		return fmt.Errorf("decode room.deleted: %w", err)
+	}
+	roomID := event.RoomID.String()
+
+	var errs []error
+	if payload.LiveKitRoom != "" {
+		if err := s.media.DeleteRoom(ctx, payload.LiveKitRoom); err != nil {
+			errs = append(errs, fmt.Errorf("end LiveKit room: %w", err))
+		}
+	}
+	if err := s.documents.DeleteDocumentState(ctx, roomID); err != nil {
+		errs = append(errs, fmt.Errorf("delete room document: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// mediaMetadata is the LiveKit room metadata: what clients in the call show about the room
+type mediaMetadata struct {
+	Name        string     `json:"name"`
+	Slug        *string    `json:"slug,omitempty"`
+	RoomType    string     `json:"room_type"`
+	IsTemporary bool       `json:"is_temporary"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	E2EEEnabled bool       `json:"e2ee_enabled"`
+}
+
+// SyncMediaRoom is the room.updated subscriber that copies the room's settings into the
+// metadata of its LiveKit room, which participants in the call receive as a metadata change.
+// It reads the room rather than the event, so a late redelivery can't roll the metadata back.
+func (s *roomsService) SyncMediaRoom(ctx context.Context, event domainevents.Event) error {
+	room, err := s.GetByID(ctx, event.RoomID)
+	if errors.Is(err, ErrRoomNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	metadata, err := json.Marshal(mediaMetadata{
+		Name:        room.Name,
+		Slug:        room.Slug,
+		RoomType:    string(room.RoomType),
+		IsTemporary: room.IsTemporary,
+		ExpiresAt:   room.ExpiresAt,
+		E2EEEnabled: e2eeEnabled(room),
+	})
+	if err != nil {
+		return err
+	}
+	return s.media.UpdateRoomMetadata(ctx, room.LiveKitRoomName, string(metadata))
+}
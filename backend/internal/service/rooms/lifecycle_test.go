@@ -0,0 +1,246 @@
+package rooms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+	"nonza/backend/internal/service/domainevents"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRooms is the part of the rooms repository Update uses, holding a single room
+type memoryRooms struct {
+	repository.Rooms
+	room      models.Room
+	redirects map[string]uuid.UUID
+	updates   int
+}
+
+func (r *memoryRooms) GetByID(_ context.Context, id uuid.UUID) (*models.Room, error) {
+	room := r.room
+	room.Settings = models.JSONB{}
+	for k, v := range r.room.Settings {
+		room.Settings[k] = v
+	}
+	return &room, nil
+}
+
+func (r *memoryRooms) GetBySlug(_ context.Context, orgID uuid.UUID, slug string) (*models.Room, error) {
+	if r.room.OrganizationID != orgID || r.room.Slug == nil || *r.room.Slug != slug {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.GetByID(context.Background(), r.room.ID)
+}
+
+func (r *memoryRooms) GetSlugRedirect(_ context.Context, orgID uuid.UUID, slug string) (*models.RoomSlugRedirect, error) {
+	roomID, ok := r.redirects[slug]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.RoomSlugRedirect{OrganizationID: orgID, Slug: slug, RoomID: roomID}, nil
+}
+
+func (r *memoryRooms) SaveSlugRedirect(_ context.Context, redirect *models.RoomSlugRedirect) error {
+	r.redirects[redirect.Slug] = redirect.RoomID
+	return nil
+}
+
+func (r *memoryRooms) DeleteSlugRedirect(_ context.Context, _ uuid.UUID, slug string) error {
+	delete(r.redirects, slug)
+	return nil
+}
+
+func (r *memoryRooms) Update(_ context.Context, room *models.Room) error {
+	r.room = *room
+	r.updates++
+	return nil
+}
+
+// recordingBus runs transactions against the repository and keeps their events
+type recordingBus struct {
+	domainevents.Bus
+	repos  *repository.Repositories
+	events []domainevents.Event
+}
+
+func (b *recordingBus) Transaction(_ context.Context, fn func(tx *repository.Repositories) ([]domainevents.Event, error)) error {
+	events, err := fn(b.repos)
+	if err != nil {
+		return err
+	}
+	b.events = append(b.events, events...)
+	return nil
+}
+
+func newUpdateService(room models.Room) (*roomsService, *memoryRooms, *recordingBus) {
+	repo := &memoryRooms{room: room, redirects: map[string]uuid.UUID{}}
+	bus := &recordingBus{repos: &repository.Repositories{Rooms: repo}}
+	return &roomsService{repo: repo, bus: bus, log: slog.New(slog.DiscardHandler)}, repo, bus
+}
+
+func TestUpdateExtendsExpiryAndAnnouncesIt(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	service, repo, bus := newUpdateService(models.Room{
+		ID:          uuid.New(),
+		Name:        "standup",
+		IsTemporary: true,
+		ExpiresAt:   &expiresAt,
+		Status:      models.RoomStatusActive,
+	})
+
+	later := expiresAt.Add(time.Hour)
+	name := "standup"
+	room, err := service.Update(context.Background(), repo.room.ID, RoomChanges{Name: &name, ExpiresAt: &later})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !room.ExpiresAt.Equal(later) || !repo.room.ExpiresAt.Equal(later) {
+		t.Fatalf("expires_at = %v, want %v", repo.room.ExpiresAt, later)
+	}
+	if len(bus.events) != 1 || bus.events[0].Type != domainevents.RoomUpdated {
+		t.Fatalf("events = %+v, want one room.updated", bus.events)
+	}
+	var payload domainevents.RoomUpdatedPayload
+	if err := json.Unmarshal(bus.events[0].Data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Changed) != 1 || payload.Changed[0] != "expires_at" {
+		t.Errorf("changed = %v, want [expires_at]", payload.Changed)
+	}
+
+	// The same settings again change nothing and announce nothing
+	if _, err := service.Update(context.Background(), repo.room.ID, RoomChanges{ExpiresAt: &later}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.updates != 1 || len(bus.events) != 1 {
+		t.Errorf("a no-op update was saved or announced: %d updates, %d events", repo.updates, len(bus.events))
+	}
+}
+
+func TestUpdateMakesRoomPermanent(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	service, repo, _ := newUpdateService(models.Room{ID: uuid.New(), IsTemporary: true, ExpiresAt: &expiresAt, Status: models.RoomStatusActive})
+
+	permanent := false
+	room, err := service.Update(context.Background(), repo.room.ID, RoomChanges{IsTemporary: &permanent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if room.IsTemporary || room.ExpiresAt != nil {
+		t.Errorf("room is still temporary: is_temporary=%v expires_at=%v", room.IsTemporary, room.ExpiresAt)
+	}
+
+	// A permanent room can't be given an expiry
+	_, err = service.Update(context.Background(), repo.room.ID, RoomChanges{ExpiresAt: &expiresAt})
+	if !errors.Is(err, ErrPermanentExpiry) {
+		t.Errorf("err = %v, want ErrPermanentExpiry", err)
+	}
+}
+
+func TestUpdateRejects(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name string
+		room models.Room
+		want error
+	}{
+		{"archived room", models.Room{IsTemporary: true, Status: models.RoomStatusArchived}, ErrRoomArchived},
+		{"expiry in the past", models.Room{IsTemporary: true, Status: models.RoomStatusActive}, ErrExpiryInPast},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo, bus := newUpdateService(tt.room)
+			_, err := service.Update(context.Background(), repo.room.ID, RoomChanges{ExpiresAt: &past})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if repo.updates != 0 || len(bus.events) != 0 {
+				t.Error("a rejected update was saved or announced")
+			}
+		})
+	}
+}
+
+func TestUpdateSlugRedirectsFormerOne(t *testing.T) {
+	slug := "standup"
+	service, repo, _ := newUpdateService(models.Room{ID: uuid.New(), OrganizationID: uuid.New(), Slug: &slug, Status: models.RoomStatusActive})
+	ctx := context.Background()
+
+	renamed := "weekly-standup"
+	if _, err := service.Update(ctx, repo.room.ID, RoomChanges{Slug: &renamed}); err != nil {
+		t.Fatal(err)
+	}
+
+	room, moved, err := service.ResolveSlug(ctx, repo.room.OrganizationID, "standup")
+	if err != nil || !moved || *room.Slug != renamed {
+		t.Fatalf("former slug: room=%v moved=%v err=%v, want a move to %s", room, moved, err, renamed)
+	}
+	if _, moved, err := service.ResolveSlug(ctx, repo.room.OrganizationID, renamed); err != nil || moved {
+		t.Fatalf("current slug: moved=%v err=%v", moved, err)
+	}
+
+	// Taking the former slug back drops its redirect
+	if _, err := service.Update(ctx, repo.room.ID, RoomChanges{Slug: &slug}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := repo.redirects[slug]; ok {
+		t.Error("the room's own slug still redirects")
+	}
+
+	invalid := "Weekly Standup"
+	if _, err := service.Update(ctx, repo.room.ID, RoomChanges{Slug: &invalid}); err == nil {
+		t.Error("an invalid slug was accepted")
+	}
+}
+
+// teardownRecorder stands in for LiveKit and Redis of a room's teardown
+type teardownRecorder struct {
+	DocumentStore
+	mediaErr  error
+	deleted   []string
+	documents []string
+}
+
+func (r *teardownRecorder) DeleteRoom(_ context.Context, roomName string) error {
+	r.deleted = append(r.deleted, roomName)
+	return r.mediaErr
+}
+
+func (r *teardownRecorder) UpdateRoomMetadata(context.Context, string, string) error { return nil }
+
+func (r *teardownRecorder) DeleteDocumentState(_ context.Context, roomID string) error {
+	r.documents = append(r.documents, roomID)
+	return nil
+}
+
+func TestTeardownRoomFailsForRetry(t *testing.T) {
+	recorder := &teardownRecorder{mediaErr: errors.New("livekit unavailable")}
+	service := &roomsService{media: recorder, documents: recorder, log: slog.New(slog.DiscardHandler)}
+	event, err := domainevents.NewEvent(domainevents.RoomDeleted, uuid.New(), uuid.New(), domainevents.RoomDeletedPayload{
+		LiveKitRoom: "room-1",
+		Reason:      "organization_deleted",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.TeardownRoom(context.Background(), event); err == nil {
+		t.Fatal("a failed LiveKit call was swallowed, so the bus won't retry it")
+	}
+	recorder.mediaErr = nil
+	if err := service.TeardownRoom(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recorder.deleted) != 2 || recorder.deleted[1] != "room-1" || len(recorder.documents) != 2 {
+		t.Errorf("teardown steps: livekit %v, documents %v; want each run on both attempts", recorder.deleted, recorder.documents)
+	}
+}
@@ -1,35 +1,61 @@
 package rooms
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"log/slog"
+	"nonza/backend/internal/metrics"
 	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
 	"nonza/backend/internal/repository"
+	"nonza/backend/internal/repository/redis"
+	"nonza/backend/internal/service/domainevents"
+	"nonza/backend/internal/service/organizations"
+	"nonza/backend/internal/service/roomevents"
 	"nonza/backend/pkg/room"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"gorm.io/gorm"
 )
 
 const e2eeKeySize = 32
 
+// maxShortCodeAttempts bounds the short codes drawn for a new room before creation fails. With
+// the smallest code space an organization may configure, running out takes millions of rooms.
+const maxShortCodeAttempts = 10
+
+var tracer = otel.Tracer("nonza/backend/internal/service/rooms")
+
 type roomsService struct {
-	repo    repository.Rooms
-	orgRepo repository.Organizations
+	repo                 repository.Rooms
+	orgRepo              repository.Organizations
+	participants         repository.Participants
+	snapshots            repository.DocumentSnapshots
+	documents            DocumentStore
+	presence             PresenceStore
+	media                MediaRooms
+	bus                  domainevents.Bus
+	events               roomevents.RoomEvents // for room.expiring, which is no domain event
+	defaultRetentionDays int
+	expiryWarning        time.Duration // how long before expiry room.expiring is published
+	log                  *slog.Logger
 }
 
-func (s *roomsService) Create(orgID uuid.UUID, name string, roomType models.RoomType, isTemporary bool, expiresIn *time.Duration, e2eeEnabled bool) (*models.Room, error) {
-	if _, err := s.orgRepo.GetByID(orgID); err != nil {
-		return nil, fmt.Errorf("organization not found: %w", err)
+func (s *roomsService) Create(ctx context.Context, orgID uuid.UUID, name, slug string, roomType models.RoomType, isTemporary bool, expiresIn *time.Duration, e2eeEnabled bool) (*models.Room, error) {
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, organizations.ErrOrganizationNotFound
+		}
+		return nil, fmt.Errorf("load organization: %w", err)
 	}
-
-	shortCode := room.GenerateShortCode()
-
-	existingRoom, err := s.repo.GetByShortCode(shortCode)
-	for err == nil && existingRoom != nil {
-		shortCode = room.GenerateShortCode()
-		existingRoom, err = s.repo.GetByShortCode(shortCode)
+	if org.DeletionRequestedAt != nil {
+		return nil, organizations.ErrOrganizationDeleting
 	}
 
 	livekitRoomName := fmt.Sprintf("room-%s", uuid.New().String())
@@ -42,56 +68,187 @@ func (s *roomsService) Create(orgID uuid.UUID, name string, roomType models.Room
 
 	settings := make(models.JSONB)
 	if e2eeEnabled {
-		keyBytes := make([]byte, e2eeKeySize)
-		if _, err := rand.Read(keyBytes); err != nil {
-			return nil, fmt.Errorf("generate E2EE key: %w", err)
+		key, err := generateE2EEKey(ctx)
+		if err != nil {
+			return nil, err
 		}
 		settings["e2ee_enabled"] = true
-		settings["encryption_key"] = base64.StdEncoding.EncodeToString(keyBytes)
+		settings["encryption_key"] = key
 	}
 
 	newRoom := &models.Room{
 		OrganizationID:  orgID,
 		Name:            name,
-		ShortCode:       &shortCode,
 		RoomType:        roomType,
 		IsTemporary:     isTemporary,
 		ExpiresAt:       expiresAt,
 		LiveKitRoomName: livekitRoomName,
 		Settings:        settings,
+		Status:          models.RoomStatusActive,
 	}
 
-	if err := s.repo.Create(newRoom); err != nil {
-		return nil, err
+	codes, strategy, err := organizations.ShortCodeGenerator(org.ShortCodes)
+	if err != nil {
+		return nil, fmt.Errorf("short codes of organization %s: %w", orgID, err)
+	}
+	for attempt := 0; attempt < maxShortCodeAttempts; attempt++ {
+		shortCode, err := codes.Generate()
+		if err != nil {
+			return nil, err
+		}
+		if room.ContainsBlockedWord(shortCode) {
+			metrics.ShortCodeAttempts.WithLabelValues(strategy, metrics.ShortCodeBlocked).Inc()
+			continue
+		}
+
+		// The code is claimed by inserting the room: a unique violation means another room has
+		// it, and the transaction, aborted by the violation, is run again with a new one
+		newRoom.ID, newRoom.Slug, newRoom.ShortCode = uuid.Nil, nil, &shortCode
+		err = s.insert(ctx, newRoom, slug)
+		var violation *models.UniqueViolation
+		if errors.As(err, &violation) {
+			switch {
+			case violation.Field == "short_code":
+				metrics.ShortCodeAttempts.WithLabelValues(strategy, metrics.ShortCodeCollision).Inc()
+				continue
+			case violation.Field == "slug" && slug == "":
+				// A room created meanwhile took the slug derived from the name; the next attempt
+				// sees it and derives another
+				continue
+			case violation.Field == "slug":
+				return nil, ErrSlugTaken
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		metrics.ShortCodeAttempts.WithLabelValues(strategy, metrics.ShortCodeCreated).Inc()
+		return newRoom, nil
+	}
+
+	s.log.Error("No free short code for a new room", slog.String("organization_id", orgID.String()), slog.String("strategy", strategy))
+	return nil, ErrShortCodesExhausted
+}
+
+// insert saves a new room, giving it the slug or, for a permanent room, one derived from its
+// name, and publishes room.created. A unique violation is returned as it is, for Create to
+// draw a new short code or derive a new slug.
+func (s *roomsService) insert(ctx context.Context, newRoom *models.Room, slug string) error {
+	return s.bus.Transaction(ctx, func(tx *repository.Repositories) ([]domainevents.Event, error) {
+		var err error
+		switch {
+		case slug != "":
+			err = setSlug(ctx, tx, newRoom, slug)
+		case !newRoom.IsTemporary:
+			err = deriveSlug(ctx, tx, newRoom)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := tx.Rooms.Create(ctx, newRoom); err != nil {
+			return nil, err
+		}
+		event, err := domainevents.NewEvent(domainevents.RoomCreated, newRoom.ID, newRoom.OrganizationID, domainevents.RoomCreatedPayload{
+			OrganizationID: newRoom.OrganizationID.String(),
+			Name:           newRoom.Name,
+			Slug:           newRoom.Slug,
+			ShortCode:      *newRoom.ShortCode,
+			ExpiresAt:      newRoom.ExpiresAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []domainevents.Event{event}, nil
+	})
+}
+
+func generateE2EEKey(ctx context.Context) (string, error) {
+	_, span := tracer.Start(ctx, "rooms.generateE2EEKey")
+	defer span.End()
+
+	keyBytes := make([]byte, e2eeKeySize)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return "", fmt.Errorf("generate E2EE key: %w", err)
 	}
+	return base64.StdEncoding.EncodeToString(keyBytes), nil
+}
 
-	return newRoom, nil
+func (s *roomsService) GetByID(ctx context.Context, id uuid.UUID) (*models.Room, error) {
+	return notFound(s.repo.GetByID(ctx, id))
 }
 
-func (s *roomsService) GetByID(id uuid.UUID) (*models.Room, error) {
-	return s.repo.GetByID(id)
+// GetPresence returns the users connected to the room's document channel
+func (s *roomsService) GetPresence(ctx context.Context, id uuid.UUID) ([]redis.PresenceEntry, error) {
+	return s.presence.GetPresence(ctx, id.String())
 }
 
-func (s *roomsService) GetByShortCode(shortCode string) (*models.Room, error) {
-	return s.repo.GetByShortCode(shortCode)
+// GetDocument reads a room's document from Redis, or from its snapshot when Redis has none:
+// an archived room's Redis copy is dropped on archiving, and an active room's may be lost with
+// a Redis that doesn't persist
+func (s *roomsService) GetDocument(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	room, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.Status != models.RoomStatusArchived {
+		state, err := s.documents.GetDocumentState(ctx, room.ID.String())
+		if err != nil {
+			return nil, fmt.Errorf("load document state: %w", err)
+		}
+		if state != nil {
+			return state, nil
+		}
+	}
+
+	snapshot, err := s.snapshots.GetByRoomID(ctx, room.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load document snapshot: %w", err)
+	}
+	return snapshot.State, nil
 }
 
-func (s *roomsService) GetByOrganizationID(orgID uuid.UUID) ([]models.Room, error) {
-	return s.repo.GetByOrganizationID(orgID)
+func (s *roomsService) GetByShortCode(ctx context.Context, shortCode string) (*models.Room, error) {
+	return notFound(s.repo.GetByShortCode(ctx, shortCode))
 }
 
-func (s *roomsService) Update(room *models.Room) error {
-	return s.repo.Update(room)
+// notFound reports a room lookup that found nothing as ErrRoomNotFound
+func notFound(room *models.Room, err error) (*models.Room, error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoomNotFound
+	}
+	return room, err
 }
 
-func (s *roomsService) Delete(id uuid.UUID) error {
-	return s.repo.Delete(id)
+func (s *roomsService) List(ctx context.Context, filter models.RoomFilter, page pagination.Page) ([]models.Room, string, error) {
+	rooms, err := s.repo.List(ctx, filter, page.Lookahead())
+	if err != nil {
+		return nil, "", err
+	}
+	rooms, next := pagination.Cut(rooms, page, func(r *models.Room) pagination.Position {
+		if page.Sort.Field == "name" {
+			return pagination.Position{Value: r.Name, ID: r.ID}
+		}
+		return pagination.Position{Value: r.CreatedAt, ID: r.ID}
+	})
+	return rooms, next, nil
 }
 
-func (s *roomsService) DeleteExpired() error {
-	return s.repo.DeleteExpired()
+func (s *roomsService) ListParticipants(ctx context.Context, filter models.ParticipantFilter, page pagination.Page) ([]models.Participant, string, error) {
+	participants, err := s.participants.List(ctx, filter, page.Lookahead())
+	if err != nil {
+		return nil, "", err
+	}
+	participants, next := pagination.Cut(participants, page, func(p *models.Participant) pagination.Position {
+		return pagination.Position{Value: p.JoinedAt, ID: p.ID}
+	})
+	return participants, next, nil
 }
 
-func (s *roomsService) GetExpired() ([]models.Room, error) {
-	return s.repo.GetExpired()
+func (s *roomsService) GetExpired(ctx context.Context) ([]models.Room, error) {
+	return s.repo.GetExpired(ctx)
 }
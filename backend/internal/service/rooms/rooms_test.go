@@ -0,0 +1,110 @@
+package rooms
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// takenCodes is a rooms repository whose first inserts collide on the short code
+type takenCodes struct {
+	repository.Rooms
+	collisions int
+	codes      []string
+	racedSlug  string
+	slugTaken  bool
+}
+
+func (r *takenCodes) Create(_ context.Context, room *models.Room) error {
+	r.codes = append(r.codes, *room.ShortCode)
+	if len(r.codes) <= r.collisions {
+		return &models.UniqueViolation{Field: "short_code", Constraint: "idx_rooms_short_code"}
+	}
+	if room.Slug != nil && r.racedSlug == *room.Slug && !r.slugTaken {
+		// Another room was created with the slug between the check and the insert
+		r.slugTaken = true
+		return &models.UniqueViolation{Field: "slug", Constraint: "idx_rooms_organization_slug"}
+	}
+	room.ID = uuid.New()
+	return nil
+}
+
+func (r *takenCodes) GetBySlug(_ context.Context, _ uuid.UUID, slug string) (*models.Room, error) {
+	if r.slugTaken && slug == r.racedSlug {
+		return &models.Room{ID: uuid.New(), Slug: &slug}, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *takenCodes) GetSlugRedirect(context.Context, uuid.UUID, string) (*models.RoomSlugRedirect, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *takenCodes) DeleteSlugRedirect(context.Context, uuid.UUID, string) error { return nil }
+
+type oneOrganization struct {
+	repository.Organizations
+	org models.Organization
+}
+
+func (r *oneOrganization) GetByID(context.Context, uuid.UUID) (*models.Organization, error) {
+	return &r.org, nil
+}
+
+func newCreateService(collisions int, format *models.ShortCodeFormat) (*roomsService, *takenCodes) {
+	repo := &takenCodes{collisions: collisions}
+	bus := &recordingBus{repos: &repository.Repositories{Rooms: repo}}
+	orgs := &oneOrganization{org: models.Organization{ID: uuid.New(), ShortCodes: format}}
+	return &roomsService{repo: repo, orgRepo: orgs, bus: bus, log: slog.New(slog.DiscardHandler)}, repo
+}
+
+func TestCreateDrawsAgainOnCollision(t *testing.T) {
+	service, repo := newCreateService(2, &models.ShortCodeFormat{Strategy: models.ShortCodeWords, WordCount: 4})
+
+	created, err := service.Create(context.Background(), uuid.New(), "standup", "", models.RoomTypeRoundTable, true, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.codes) != 3 || *created.ShortCode != repo.codes[2] {
+		t.Fatalf("inserted %v, created with %s; want the third code", repo.codes, *created.ShortCode)
+	}
+}
+
+func TestCreateGivesUpWhenCodesRunOut(t *testing.T) {
+	service, repo := newCreateService(maxShortCodeAttempts, nil)
+
+	_, err := service.Create(context.Background(), uuid.New(), "standup", "", models.RoomTypeRoundTable, true, nil, false)
+	if !errors.Is(err, ErrShortCodesExhausted) {
+		t.Fatalf("err = %v, want ErrShortCodesExhausted", err)
+	}
+	if len(repo.codes) > maxShortCodeAttempts {
+		t.Errorf("%d inserts, want at most %d", len(repo.codes), maxShortCodeAttempts)
+	}
+}
+
+func TestCreateDerivesAnotherSlugAfterRace(t *testing.T) {
+	service, repo := newCreateService(0, nil)
+	repo.racedSlug = "standup"
+
+	created, err := service.Create(context.Background(), uuid.New(), "Standup", "", models.RoomTypeRoundTable, false, nil, false)
+	if err != nil {
+		t.Fatalf("err = %v, want the room created with another slug", err)
+	}
+	if created.Slug == nil || *created.Slug != "standup-2" {
+		t.Errorf("slug = %v, want standup-2", created.Slug)
+	}
+
+	// A slug the caller chose is reported as taken
+	service, repo = newCreateService(0, nil)
+	repo.racedSlug = "standup"
+	if _, err := service.Create(context.Background(), uuid.New(), "Standup", "standup", models.RoomTypeRoundTable, false, nil, false); !errors.Is(err, ErrSlugTaken) {
+		t.Errorf("err = %v, want ErrSlugTaken", err)
+	}
+}
@@ -0,0 +1,114 @@
+package rooms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository"
+	"nonza/backend/internal/service/organizations"
+	"nonza/backend/pkg/room"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxSlugSuffix bounds the numbered variants tried when a room's derived slug is taken
+const maxSlugSuffix = 9
+
+// ResolveSlug returns the room of the organization with the slug. moved reports that the room
+// had the slug before and now goes by another, or by none.
+func (s *roomsService) ResolveSlug(ctx context.Context, orgID uuid.UUID, slug string) (*models.Room, bool, error) {
+	found, err := s.repo.GetBySlug(ctx, orgID, slug)
+	if err == nil {
+		return found, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	redirect, err := s.repo.GetSlugRedirect(ctx, orgID, slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	found, err = s.GetByID(ctx, redirect.RoomID)
+	if err != nil {
+		return nil, false, err
+	}
+	return found, true, nil
+}
+
+// setSlug gives the room a slug chosen by a user, or takes its slug away when slug is empty.
+// The former slug is kept as a redirect to the room. A slug other rooms used to have is
+// taken over: links with it lead to this room from now on.
+func setSlug(ctx context.Context, tx *repository.Repositories, r *models.Room, slug string) error {
+	if slug != "" {
+		if err := organizations.ValidateSlug(slug); err != nil {
+			return err
+		}
+		other, err := tx.Rooms.GetBySlug(ctx, r.OrganizationID, slug)
+		if err == nil && other.ID != r.ID {
+			return ErrSlugTaken
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("check slug: %w", err)
+		}
+		if err := tx.Rooms.DeleteSlugRedirect(ctx, r.OrganizationID, slug); err != nil {
+			return fmt.Errorf("drop slug redirect: %w", err)
+		}
+	}
+
+	if r.Slug != nil && *r.Slug != slug && r.ID != uuid.Nil {
+		redirect := &models.RoomSlugRedirect{OrganizationID: r.OrganizationID, Slug: *r.Slug, RoomID: r.ID}
+		if err := tx.Rooms.SaveSlugRedirect(ctx, redirect); err != nil {
+			return fmt.Errorf("keep former slug: %w", err)
+		}
+	}
+
+	if slug == "" {
+		r.Slug = nil
+	} else {
+		r.Slug = &slug
+	}
+	return nil
+}
+
+// deriveSlug gives a permanent room a slug made from its name: the name itself, or if that is
+// taken by a room or a redirect, the name with -2 to -9 appended. A room whose name makes no
+// valid slug, or whose variants are all taken, is left without one.
+func deriveSlug(ctx context.Context, tx *repository.Repositories, r *models.Room) error {
+	base := room.Slugify(r.Name)
+	for n := 1; n <= maxSlugSuffix; n++ {
+		candidate := base
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, n)
+		}
+		if room.ValidateSlug(candidate) != nil {
+			return nil
+		}
+
+		free, err := slugFree(ctx, tx, r.OrganizationID, candidate)
+		if err != nil {
+			return err
+		}
+		if free {
+			r.Slug = &candidate
+			return nil
+		}
+	}
+	return nil
+}
+
+// slugFree reports whether no room of the organization has or had the slug
+func slugFree(ctx context.Context, tx *repository.Repositories, orgID uuid.UUID, slug string) (bool, error) {
+	if _, err := tx.Rooms.GetBySlug(ctx, orgID, slug); !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	if _, err := tx.Rooms.GetSlugRedirect(ctx, orgID, slug); !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	return true, nil
+}
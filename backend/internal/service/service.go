@@ -1,26 +1,60 @@
 package service
 
 import (
+	"log/slog"
 	"nonza/backend/internal/repository"
+	"nonza/backend/internal/repository/redis"
+	"nonza/backend/internal/service/audit"
+	"nonza/backend/internal/service/domainevents"
+	"nonza/backend/internal/service/idempotency"
+	"nonza/backend/internal/service/jobs"
 	"nonza/backend/internal/service/meeting_documents"
 	"nonza/backend/internal/service/organizations"
+	"nonza/backend/internal/service/roomevents"
 	"nonza/backend/internal/service/rooms"
+	"nonza/backend/internal/service/webhooks"
+	"time"
 )
 
 type Services struct {
 	Organizations    organizations.Organizations
 	Rooms            rooms.Rooms
 	MeetingDocuments meeting_documents.MeetingDocuments
+	Jobs             jobs.Jobs
+	RoomEvents       roomevents.RoomEvents
+	Events           domainevents.Bus
+	Webhooks         webhooks.Webhooks
+	Audit            audit.Audit
+	Idempotency      idempotency.Idempotency
 }
 
 type Deps struct {
-	Repositories *repository.Repositories
+	Repositories         *repository.Repositories
+	Redis                *redis.Client
+	LiveKit              rooms.MediaRooms
+	JobLockTTL           time.Duration
+	ArchiveRetentionDays int           // default for organizations without their own retention
+	ExpiryWarning        time.Duration // how long before a room expires room.expiring is published
+	RoomEvents           roomevents.RoomEvents
+	Events               domainevents.Bus
+	Webhooks             webhooks.Webhooks
+	IdempotencyTTL       time.Duration // how long responses are kept for retries with the same Idempotency-Key
+	IdempotencyClaimTTL  time.Duration // how long a key stays claimed by a request that never completes
+	Logger               *slog.Logger
 }
 
 func NewServices(deps Deps) *Services {
+	jobsService := jobs.NewJobsService(deps.Repositories.JobRuns, deps.Redis, deps.JobLockTTL, deps.Logger)
+
 	return &Services{
-		Organizations:    organizations.NewOrganizationsService(deps.Repositories.Organizations),
-		Rooms:            rooms.NewRoomsService(deps.Repositories.Rooms, deps.Repositories.Organizations),
+		Organizations:    organizations.NewOrganizationsService(deps.Repositories.Organizations, deps.Repositories.Rooms, jobsService, deps.Events, deps.Logger),
+		Rooms:            rooms.NewRoomsService(deps.Repositories.Rooms, deps.Repositories.Organizations, deps.Repositories.Participants, deps.Repositories.Snapshots, deps.Redis, deps.Redis, deps.LiveKit, deps.Events, deps.RoomEvents, deps.ArchiveRetentionDays, deps.ExpiryWarning, deps.Logger),
 		MeetingDocuments: meeting_documents.NewMeetingDocumentsService(deps.Repositories.MeetingDocuments),
+		Jobs:             jobsService,
+		RoomEvents:       deps.RoomEvents,
+		Events:           deps.Events,
+		Webhooks:         deps.Webhooks,
+		Audit:            audit.NewAuditService(deps.Repositories.AuditEvents),
+		Idempotency:      idempotency.NewIdempotencyService(deps.Redis, deps.IdempotencyTTL, deps.IdempotencyClaimTTL),
 	}
 }
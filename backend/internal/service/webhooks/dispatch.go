@@ -0,0 +1,138 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"nonza/backend/internal/metrics"
+	"nonza/backend/internal/models"
+	"nonza/backend/pkg/webhook"
+	"sync"
+	"time"
+)
+
+const (
+	// batchSize is how many due deliveries one poll claims and sends concurrently
+	batchSize = 20
+
+	// responseBodyLimit is how much of an endpoint's response is kept in the delivery log
+	responseBodyLimit = 1024
+)
+
+// Start begins sending due deliveries from the outbox
+func (s *webhooksService) Start() {
+	s.dispatcher.Start()
+}
+
+// Stop ends the dispatcher and waits for the requests in flight. Their deliveries are
+// saved as attempted; unsent ones are picked up by another instance once their lease expires.
+func (s *webhooksService) Stop() {
+	s.dispatcher.Stop()
+}
+
+// sendClaimed sends a claimed batch of deliveries concurrently
+func (s *webhooksService) sendClaimed(ctx context.Context, deliveries []models.WebhookDelivery) {
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			s.attempt(context.WithoutCancel(ctx), delivery)
+			if err := s.repo.UpdateDelivery(context.WithoutCancel(ctx), delivery); err != nil {
+				s.log.Error("Failed to save webhook delivery", slog.String("delivery_id", delivery.ID.String()), slog.Any("error", err))
+			}
+		}(&deliveries[i])
+	}
+	wg.Wait()
+}
+
+// attempt sends the delivery to its endpoint and records the outcome on it: succeeded on a 2xx
+// response, otherwise pending with the next attempt scheduled, or failed once attempts run out
+func (s *webhooksService) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	now := time.Now()
+	delivery.LastAttemptAt = &now
+
+	if !delivery.Endpoint.Enabled {
+		s.fail(delivery, "endpoint disabled")
+		return
+	}
+
+	delivery.Attempts++
+	status, body, err := s.send(ctx, delivery)
+	delivery.ResponseStatus = nil
+	delivery.ResponseBody = nil
+	if status != 0 {
+		delivery.ResponseStatus = &status
+		delivery.ResponseBody = &body
+	}
+
+	if err == nil {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.LastError = nil
+		metrics.WebhookAttempts.WithLabelValues(metrics.WebhookDelivered).Inc()
+		return
+	}
+
+	if delivery.Attempts >= s.cfg.MaxAttempts {
+		s.fail(delivery, err.Error())
+		return
+	}
+
+	msg := err.Error()
+	delivery.LastError = &msg
+	delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+	metrics.WebhookAttempts.WithLabelValues(metrics.WebhookRetry).Inc()
+}
+
+func (s *webhooksService) fail(delivery *models.WebhookDelivery, reason string) {
+	delivery.Status = models.WebhookDeliveryFailed
+	delivery.LastError = &reason
+	metrics.WebhookAttempts.WithLabelValues(metrics.WebhookFailed).Inc()
+	s.log.Warn("Webhook delivery failed",
+		slog.String("delivery_id", delivery.ID.String()),
+		slog.String("endpoint_id", delivery.EndpointID.String()),
+		slog.Int("attempts", delivery.Attempts),
+		slog.String("reason", reason))
+}
+
+// send posts the signed payload. It returns the response status and the start of its body,
+// if the endpoint answered, and an error unless the status was 2xx.
+func (s *webhooksService) send(ctx context.Context, delivery *models.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Nonza-Webhooks/1.0")
+	req.Header.Set(webhook.EventHeader, delivery.EventType)
+	req.Header.Set(webhook.DeliveryHeader, delivery.ID.String())
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(delivery.Endpoint.Secret, time.Now(), delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, responseBodyLimit))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(body), fmt.Errorf("endpoint responded with %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// backoff returns the delay after the given failed attempt: RetryBase doubled for each
+// attempt before it, capped at RetryMax, plus up to 10% jitter so retries of a burst spread out
+func (s *webhooksService) backoff(attempts int) time.Duration {
+	delay := s.cfg.RetryMax
+	if shift := attempts - 1; shift < 32 {
+		if d := s.cfg.RetryBase << shift; d > 0 && d < delay {
+			delay = d
+		}
+	}
+	return delay + rand.N(delay/10+1)
+}
@@ -0,0 +1,92 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"nonza/backend/internal/apperrors"
+)
+
+var (
+	ErrInsecureURL = apperrors.InvalidField("url", "https", "must be an https URL")
+	ErrPrivateURL  = apperrors.InvalidField("url", "public", "must point to a public address")
+
+	// errPrivateAddress is a connection refused by the dialer
+	errPrivateAddress = errors.New("endpoint resolves to a non-public address")
+
+	// sharedAddressSpace is carrier-grade NAT space (RFC 6598), which net.IP doesn't count as private
+	sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+)
+
+// validateURL checks an endpoint URL when it is registered: https, or http too when local
+// addresses are allowed, and not a literal non-public address. Host names are checked again
+// on every request, after they resolve, by the dialer of newClient.
+func validateURL(raw string, allowLocal bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return apperrors.InvalidField("url", "url", "must be an absolute URL")
+	}
+	if u.Scheme != "https" && (!allowLocal || u.Scheme != "http") {
+		return ErrInsecureURL
+	}
+	if allowLocal {
+		return nil
+	}
+	if u.Hostname() == "localhost" {
+		return ErrPrivateURL
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !publicIP(ip) {
+		return ErrPrivateURL
+	}
+	return nil
+}
+
+// newClient returns the client requests to endpoints are sent with. Unless local addresses are
+// allowed it only connects to public addresses, whatever a host name resolves to, and goes
+// around any proxy of the environment. Redirects are reported as the responses they are
+// rather than followed with the signed body.
+func newClient(timeout time.Duration, allowLocal bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: timeout,
+	}
+	if !allowLocal {
+		dialer.Control = refusePrivate
+		transport.Proxy = nil
+	}
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// refusePrivate is the dialer's check of the address it is about to connect to, made after
+// DNS resolution so a public name can't lead to an internal address
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("%w: %s", errPrivateAddress, host)
+	}
+	return nil
+}
+
+// publicIP reports whether the address is routable on the internet: not loopback, private,
+// link-local (which includes cloud metadata services), shared, multicast or unspecified
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() && !sharedAddressSpace.Contains(ip)
+}
@@ -0,0 +1,35 @@
+package webhooks
+
+import (
+	"context"
+	"log/slog"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/poller"
+	"nonza/backend/internal/repository"
+	"time"
+)
+
+func NewWebhooksService(repo repository.Webhooks, roomsRepo repository.Rooms, orgRepo repository.Organizations, cfg Config, log *slog.Logger) Webhooks {
+	cfg = cfg.withDefaults()
+	s := &webhooksService{
+		repo:      repo,
+		roomsRepo: roomsRepo,
+		orgRepo:   orgRepo,
+		cfg:       cfg,
+		client:    newClient(cfg.Timeout, cfg.AllowLocal),
+		log:       log.With(slog.String("component", "webhooks")),
+	}
+	s.dispatcher = poller.New(poller.Config[models.WebhookDelivery]{
+		Name:      "webhook deliveries",
+		Interval:  cfg.PollInterval,
+		BatchSize: batchSize,
+		// The lease outlasts the request, so no other instance picks a delivery up while it is being sent
+		Lease: 2 * cfg.Timeout,
+		Claim: func(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+			return s.repo.ClaimDueDeliveries(ctx, now, limit, lease)
+		},
+		Handle: s.sendClaimed,
+		Log:    s.log,
+	})
+	return s
+}
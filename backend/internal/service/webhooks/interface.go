@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/service/domainevents"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEndpointNotFound = apperrors.NotFound("webhook_not_found", "webhook not found")
+	ErrDeliveryNotFound = apperrors.NotFound("webhook_delivery_not_found", "webhook delivery not found")
+	ErrEndpointDisabled = apperrors.Conflict("webhook_disabled", "webhook endpoint is disabled")
+	ErrUnknownEvent     = apperrors.Validation("unknown_event", "unknown webhook event type")
+)
+
+// EventTypes are the domain events endpoints can subscribe to
+var EventTypes = []string{
+	domainevents.RoomCreated,
+	domainevents.RoomUpdated,
+	domainevents.RoomDeleted,
+	domainevents.RoomExpired,
+	domainevents.ParticipantJoined,
+	domainevents.ParticipantLeft,
+	domainevents.DocumentUpdated,
+}
+
+type Webhooks interface {
+	// CreateEndpoint registers an endpoint with a new signing secret, which is only readable from the returned model
+	CreateEndpoint(ctx context.Context, orgID uuid.UUID, url string, events []string) (*models.WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, orgID, id uuid.UUID) (*models.WebhookEndpoint, error)
+	GetEndpoints(ctx context.Context, orgID uuid.UUID) ([]models.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, orgID, id uuid.UUID, url string, events []string, enabled bool) (*models.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, orgID, id uuid.UUID) error
+
+	// GetDeliveries returns the endpoint's delivery log, newest first
+	GetDeliveries(ctx context.Context, orgID, endpointID uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+	// Redeliver queues the delivery's event to be sent again as a new delivery
+	Redeliver(ctx context.Context, orgID, endpointID, deliveryID uuid.UUID) (*models.WebhookDelivery, error)
+
+	// HandleEvent is the event bus subscriber that queues deliveries of an event to the
+	// subscribed endpoints of its organization
+	HandleEvent(ctx context.Context, event domainevents.Event) error
+
+	Start()
+	Stop()
+}
+
+// Config controls delivery. Zero fields take the defaults.
+type Config struct {
+	PollInterval time.Duration // how often the outbox is checked for due deliveries
+	Timeout      time.Duration // per request
+	MaxAttempts  int           // attempts before a delivery is marked failed
+	RetryBase    time.Duration // delay after the first failed attempt; doubles with each one after it
+	RetryMax     time.Duration // longest delay between attempts
+	// AllowLocal lets endpoints use http and loopback or private addresses; for local development only
+	AllowLocal bool
+}
+
+var defaultConfig = Config{
+	PollInterval: 2 * time.Second,
+	Timeout:      10 * time.Second,
+	MaxAttempts:  8,
+	RetryBase:    30 * time.Second,
+	RetryMax:     6 * time.Hour,
+}
+
+// Payload is the JSON body of a webhook request
+type Payload struct {
+	ID             string          `json:"id"` // domain event ID, the same on redeliveries
+	Type           string          `json:"type"`
+	CreatedAt      time.Time       `json:"created_at"`
+	OrganizationID string          `json:"organization_id"`
+	RoomID         string          `json:"room_id"`
+	Data           json.RawMessage `json:"data"`
+}
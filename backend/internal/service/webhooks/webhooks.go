@@ -0,0 +1,261 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/poller"
+	"nonza/backend/internal/repository"
+	"nonza/backend/internal/service/domainevents"
+	"nonza/backend/internal/service/organizations"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const secretSize = 32
+
+type webhooksService struct {
+	repo       repository.Webhooks
+	roomsRepo  repository.Rooms
+	orgRepo    repository.Organizations
+	cfg        Config
+	client     *http.Client
+	log        *slog.Logger
+	dispatcher *poller.Poller[models.WebhookDelivery]
+}
+
+func (c Config) withDefaults() Config {
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaultConfig.PollInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultConfig.Timeout
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultConfig.MaxAttempts
+	}
+	if c.RetryBase <= 0 {
+		c.RetryBase = defaultConfig.RetryBase
+	}
+	if c.RetryMax <= 0 {
+		c.RetryMax = defaultConfig.RetryMax
+	}
+	return c
+}
+
+func (s *webhooksService) CreateEndpoint(ctx context.Context, orgID uuid.UUID, url string, events []string) (*models.WebhookEndpoint, error) {
+	if err := validateURL(url, s.cfg.AllowLocal); err != nil {
+		return nil, err
+	}
+	if err := validateEvents(events); err != nil {
+		return nil, err
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, organizations.ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	if org.DeletionRequestedAt != nil {
+		return nil, organizations.ErrOrganizationDeleting
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		OrganizationID: orgID,
+		URL:            url,
+		Secret:         secret,
+		Events:         events,
+		Enabled:        true,
+	}
+	if err := s.repo.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Created webhook endpoint", slog.String("organization_id", orgID.String()), slog.String("endpoint_id", endpoint.ID.String()))
+	return endpoint, nil
+}
+
+// GetEndpoint returns the organization's endpoint; endpoints of other organizations are not found
+func (s *webhooksService) GetEndpoint(ctx context.Context, orgID, id uuid.UUID) (*models.WebhookEndpoint, error) {
+	endpoint, err := s.repo.GetEndpoint(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, err
+	}
+	if endpoint.OrganizationID != orgID {
+		return nil, ErrEndpointNotFound
+	}
+	return endpoint, nil
+}
+
+func (s *webhooksService) GetEndpoints(ctx context.Context, orgID uuid.UUID) ([]models.WebhookEndpoint, error) {
+	return s.repo.GetEndpointsByOrganizationID(ctx, orgID)
+}
+
+func (s *webhooksService) UpdateEndpoint(ctx context.Context, orgID, id uuid.UUID, url string, events []string, enabled bool) (*models.WebhookEndpoint, error) {
+	if err := validateURL(url, s.cfg.AllowLocal); err != nil {
+		return nil, err
+	}
+	if err := validateEvents(events); err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.GetEndpoint(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.URL = url
+	endpoint.Events = events
+	endpoint.Enabled = enabled
+	if err := s.repo.UpdateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (s *webhooksService) DeleteEndpoint(ctx context.Context, orgID, id uuid.UUID) error {
+	if _, err := s.GetEndpoint(ctx, orgID, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteEndpoint(ctx, id)
+}
+
+func (s *webhooksService) GetDeliveries(ctx context.Context, orgID, endpointID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetEndpoint(ctx, orgID, endpointID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDeliveriesByEndpointID(ctx, endpointID, limit)
+}
+
+// Redeliver queues a new delivery with the original event ID and body. The original stays in the log as it was.
+func (s *webhooksService) Redeliver(ctx context.Context, orgID, endpointID, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	endpoint, err := s.GetEndpoint(ctx, orgID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.Enabled {
+		return nil, ErrEndpointDisabled
+	}
+
+	original, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	if original.EndpointID != endpointID {
+		return nil, ErrDeliveryNotFound
+	}
+
+	deliveries := []models.WebhookDelivery{{
+		EndpointID:     endpointID,
+		OrganizationID: orgID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}}
+	if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+// HandleEvent writes a pending delivery of the event for each enabled endpoint of its organization
+// that subscribed to it. The dispatcher sends them from the outbox.
+func (s *webhooksService) HandleEvent(ctx context.Context, event domainevents.Event) error {
+	if !slices.Contains(EventTypes, event.Type) {
+		return nil
+	}
+
+	orgID := event.OrganizationID
+	if orgID == uuid.Nil {
+		room, err := s.roomsRepo.GetByID(ctx, event.RoomID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // purged since; nobody to tell
+		}
+		if err != nil {
+			return fmt.Errorf("load room: %w", err)
+		}
+		orgID = room.OrganizationID
+	}
+
+	endpoints, err := s.repo.GetEndpointsByOrganizationID(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("load webhook endpoints: %w", err)
+	}
+
+	var payload []byte
+	var deliveries []models.WebhookDelivery
+	for _, endpoint := range endpoints {
+		if !endpoint.Enabled || !endpoint.Subscribed(event.Type) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(Payload{
+				ID:             event.ID.String(),
+				Type:           event.Type,
+				CreatedAt:      event.OccurredAt.UTC(),
+				OrganizationID: orgID.String(),
+				RoomID:         event.RoomID.String(),
+				Data:           event.Data,
+			})
+			if err != nil {
+				return fmt.Errorf("encode webhook payload: %w", err)
+			}
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			EndpointID:     endpoint.ID,
+			OrganizationID: orgID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  time.Now(),
+		})
+	}
+
+	if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return fmt.Errorf("queue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func validateEvents(events []string) error {
+	for _, e := range events {
+		if !slices.Contains(EventTypes, e) {
+			return ErrUnknownEvent.WithFields(apperrors.FieldError{Field: "events", Code: "unknown_event", Message: "unknown event type " + e})
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
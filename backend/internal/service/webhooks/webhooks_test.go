@@ -0,0 +1,135 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nonza/backend/internal/models"
+	"nonza/backend/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+func TestAttemptSignsAndRetries(t *testing.T) {
+	const secret = "whsec_test"
+	var failing atomic.Bool
+	failing.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := webhook.Verify(secret, r.Header.Get(webhook.SignatureHeader), body, time.Minute); err != nil {
+			t.Errorf("signature: %v", err)
+		}
+		if got := r.Header.Get(webhook.EventHeader); got != "room.created" {
+			t.Errorf("event header = %q, want room.created", got)
+		}
+		if failing.Load() {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	svc := NewWebhooksService(nil, nil, nil, Config{MaxAttempts: 3, RetryBase: time.Minute, RetryMax: time.Hour, AllowLocal: true},
+		slog.New(slog.DiscardHandler)).(*webhooksService)
+
+	delivery := &models.WebhookDelivery{
+		ID:        uuid.New(),
+		EventType: "room.created",
+		Payload:   []byte(`{"type":"room.created"}`),
+		Status:    models.WebhookDeliveryPending,
+		Endpoint:  models.WebhookEndpoint{URL: server.URL, Secret: secret, Enabled: true},
+	}
+
+	before := time.Now()
+	svc.attempt(context.Background(), delivery)
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 1 {
+		t.Fatalf("after a 503: status %s, attempts %d; want pending, 1", delivery.Status, delivery.Attempts)
+	}
+	if delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusServiceUnavailable {
+		t.Errorf("response status = %v, want 503", delivery.ResponseStatus)
+	}
+	if wait := delivery.NextAttemptAt.Sub(before); wait < time.Minute || wait > time.Minute+7*time.Second {
+		t.Errorf("next attempt in %s, want the 1m base delay plus at most 10%% jitter", wait)
+	}
+
+	svc.attempt(context.Background(), delivery)
+	if wait := delivery.NextAttemptAt.Sub(before); wait < 2*time.Minute {
+		t.Errorf("second retry in %s, want the delay doubled", wait)
+	}
+
+	failing.Store(false)
+	svc.attempt(context.Background(), delivery)
+	if delivery.Status != models.WebhookDeliverySucceeded || delivery.LastError != nil {
+		t.Errorf("after a 204: status %s, error %v; want succeeded without error", delivery.Status, delivery.LastError)
+	}
+}
+
+func TestAttemptGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	svc := NewWebhooksService(nil, nil, nil, Config{MaxAttempts: 2, AllowLocal: true}, slog.New(slog.DiscardHandler)).(*webhooksService)
+	delivery := &models.WebhookDelivery{
+		Status:   models.WebhookDeliveryPending,
+		Endpoint: models.WebhookEndpoint{URL: server.URL, Secret: "s", Enabled: true},
+	}
+
+	svc.attempt(context.Background(), delivery)
+	svc.attempt(context.Background(), delivery)
+	if delivery.Status != models.WebhookDeliveryFailed || delivery.Attempts != 2 {
+		t.Errorf("status %s, attempts %d; want failed after 2", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestPrivateAddressesAreRefused(t *testing.T) {
+	var reached atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached.Store(true)
+	}))
+	defer server.Close()
+
+	svc := NewWebhooksService(nil, nil, nil, Config{}, slog.New(slog.DiscardHandler)).(*webhooksService)
+	delivery := &models.WebhookDelivery{
+		Status:   models.WebhookDeliveryPending,
+		Endpoint: models.WebhookEndpoint{URL: server.URL, Secret: "s", Enabled: true},
+	}
+	svc.attempt(context.Background(), delivery)
+	if reached.Load() || delivery.ResponseStatus != nil {
+		t.Error("the dispatcher connected to a loopback address")
+	}
+
+	for _, url := range []string{
+		"http://example.com/hook",
+		"https://localhost/hook",
+		"https://127.0.0.1:6379/",
+		"https://169.254.169.254/latest/meta-data/",
+		"https://[::1]/hook",
+		"https://10.0.0.5/hook",
+	} {
+		if err := validateURL(url, false); err == nil {
+			t.Errorf("%s was accepted", url)
+		}
+	}
+	if err := validateURL("https://hooks.example.com/nonza", false); err != nil {
+		t.Errorf("a public https URL was refused: %v", err)
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	svc := &webhooksService{cfg: Config{RetryBase: time.Second, RetryMax: time.Minute}}
+	for _, attempts := range []int{7, 40, 100} {
+		if d := svc.backoff(attempts); d < time.Minute || d > time.Minute+6*time.Second {
+			t.Errorf("backoff(%d) = %s, want the 1m cap plus jitter", attempts, d)
+		}
+	}
+}
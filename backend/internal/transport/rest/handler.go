@@ -1,54 +1,70 @@
 package rest
 
 import (
+	"log/slog"
+	"net/http"
 	"strings"
 
 	"nonza/backend/internal/config"
-	"nonza/backend/internal/repository"
-	"nonza/backend/internal/repository/redis"
+	"nonza/backend/internal/health"
+	"nonza/backend/internal/logger"
+	"nonza/backend/internal/metrics"
 	"nonza/backend/internal/service"
+	"nonza/backend/internal/tracing"
 	v1 "nonza/backend/internal/transport/rest/v1"
 	"nonza/backend/internal/transport/websocket"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Handler struct {
 	services  *service.Services
 	wsHub     *websocket.Hub
 	wsHandler *websocket.Handler
+	health    *health.Checker
+	log       *slog.Logger
 }
 
-func NewHandler(services *service.Services, redisClient *redis.Client, roomsRepo repository.Rooms, documentTTL string) *Handler {
-	wsHub := websocket.NewHub(redisClient, roomsRepo)
+func NewHandler(services *service.Services, wsHub *websocket.Hub, checker *health.Checker, log *slog.Logger) *Handler {
 	wsHandler := websocket.NewHandler(wsHub)
 
-	// Start the hub
-	go wsHub.Run()
-
 	return &Handler{
 		services:  services,
 		wsHub:     wsHub,
 		wsHandler: wsHandler,
+		health:    checker,
+		log:       log,
 	}
 }
 
 func (h *Handler) InitRoutes(cfg *config.Config) *gin.Engine {
-	router := gin.Default()
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		// Probes and scrapes would drown out real traffic
+		switch r.URL.Path {
+		case "/healthz", "/readyz", "/metrics":
+			return false
+		}
+		return true
+	})))
+	router.Use(logger.GinMiddleware(h.log))
 
 	// CORS middleware
 	corsConfig := cors.Config{
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "accept", "origin", "Cache-Control", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "accept", "origin", "Cache-Control", "X-Requested-With", logger.RequestIDHeader, idempotencyKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", logger.RequestIDHeader, replayedHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
 
 	// In development, allow all origins
-	if cfg.Env == "local" || cfg.Debug {
+	if cfg.Env == "local" || (cfg.Debug != nil && *cfg.Debug) {
 		corsConfig.AllowOriginFunc = func(origin string) bool {
 			return true
 		}
@@ -71,22 +87,45 @@ func (h *Handler) InitRoutes(cfg *config.Config) *gin.Engine {
 	}
 
 	router.Use(cors.New(corsConfig))
-
-	api := router.Group("/api/v1")
+	router.Use(metrics.GinMiddleware())
+	// Innermost, so the logger and metrics see the status of the problem response
+	router.Use(problemDetails())
+	router.NoRoute(noRoute)
+
+	h.initHealthRoutes(router)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	api := router.Group("/api/v1",
+		requestTimeout(parseDuration(cfg.HTTPRequestTimeout, 30*time.Second)),
+		// Before the audit log, so a replayed response isn't recorded as another call
+		idempotent(h.services.Idempotency, h.log),
+		auditLog(h.services.Audit, cfg.AdminToken, h.log))
 	{
 		// Register organization rooms routes FIRST (more specific path) to avoid conflicts
 		// Must be before /organizations/:id routes
 		h.initOrganizationRoomsRoutes(api)
+		h.initOrganizationWebhooksRoutes(api)
 		// Then register general organizations routes
-		h.initOrganizationsRoutes(api)
+		h.initOrganizationsRoutes(api, cfg)
 		// Then register general rooms routes
 		h.initRoomsRoutes(api)
 		// Finally register tokens
 		h.initTokensRoutes(api, cfg)
+
+		h.initAdminRoutes(api, cfg)
+
+		h.initDocsRoutes(api)
+	}
+
+	// Event streams stay open for as long as the client listens, so they skip the request timeout
+	streams := router.Group("/api/v1")
+	{
+		h.initStreamRoutes(streams)
 	}
 
 	// WebSocket endpoint
 	router.GET("/ws", h.wsHandler.HandleWebSocket)
+	router.GET("/ws/schema", h.wsHandler.HandleSchema)
 
 	return router
 }
@@ -110,18 +149,37 @@ func (h *Handler) initOrganizationRoomsRoutes(api *gin.RouterGroup) {
 	{
 		orgRooms.POST("", roomHandler.Create)
 		orgRooms.GET("", roomHandler.GetByOrganizationID)
+		orgRooms.GET("/archived", roomHandler.GetArchivedByOrganizationID)
 	}
 }
 
-func (h *Handler) initOrganizationsRoutes(api *gin.RouterGroup) {
+func (h *Handler) initOrganizationWebhooksRoutes(api *gin.RouterGroup) {
+	webhookHandler := v1.NewWebhooksHandler(h.services)
+
+	webhooks := api.Group("/org/:id/webhooks")
+	{
+		webhooks.POST("", webhookHandler.Create)
+		webhooks.GET("", webhookHandler.List)
+		webhooks.GET("/:webhookID", webhookHandler.GetByID)
+		webhooks.PUT("/:webhookID", webhookHandler.Update)
+		webhooks.DELETE("/:webhookID", webhookHandler.Delete)
+		webhooks.GET("/:webhookID/deliveries", webhookHandler.GetDeliveries)
+		webhooks.POST("/:webhookID/deliveries/:deliveryID/redeliver", webhookHandler.Redeliver)
+	}
+}
+
+func (h *Handler) initOrganizationsRoutes(api *gin.RouterGroup, cfg *config.Config) {
 	orgHandler := v1.NewOrganizationsHandler(h.services)
 
 	orgs := api.Group("/organizations")
 	{
+		// The list of all organizations is as sensitive as the admin API and guarded the same way
+		orgs.GET("", adminAuth(cfg.AdminToken), orgHandler.List)
 		orgs.POST("", orgHandler.Create)
 		orgs.GET("/:id", orgHandler.GetByID)
 		orgs.PUT("/:id", orgHandler.Update)
 		orgs.DELETE("/:id", orgHandler.Delete)
+		orgs.GET("/:id/deletion", orgHandler.GetDeletionStatus)
 	}
 }
 
@@ -132,14 +190,56 @@ func (h *Handler) initRoomsRoutes(api *gin.RouterGroup) {
 	{
 		rooms.GET("/:shortCode", roomHandler.GetByShortCode)
 		rooms.GET("/id/:id", roomHandler.GetByID)
+		rooms.PATCH("/id/:id", roomHandler.Update)
+		rooms.DELETE("/id/:id", roomHandler.Delete)
+		rooms.GET("/id/:id/presence", roomHandler.GetPresence)
+		rooms.GET("/id/:id/document", roomHandler.GetDocument)
+		rooms.GET("/id/:id/participants", roomHandler.GetParticipants)
 	}
+
+	api.GET("/r/:org/:slug", roomHandler.ResolveSlug)
+}
+
+func (h *Handler) initStreamRoutes(api *gin.RouterGroup) {
+	roomHandler := v1.NewRoomsHandler(h.services)
+
+	api.GET("/rooms/:shortCode/events", roomHandler.Events)
 }
 
 func (h *Handler) initTokensRoutes(api *gin.RouterGroup, cfg *config.Config) {
-	tokenHandler := v1.NewTokensHandler(h.services, cfg, h.wsHub)
+	tokenHandler := v1.NewTokensHandler(h.services, cfg)
 
 	tokens := api.Group("/tokens")
 	{
 		tokens.POST("", tokenHandler.GenerateToken)
 	}
 }
+
+func (h *Handler) initAdminRoutes(api *gin.RouterGroup, cfg *config.Config) {
+	// The routes stay registered, so clients get 403 rather than 404 and the API matches its contract
+	if cfg.AdminToken == "" {
+		h.log.Warn("ADMIN_TOKEN is not set, admin API is disabled")
+	}
+
+	jobHandler := v1.NewJobsHandler(h.services)
+	auditHandler := v1.NewAuditHandler(h.services)
+
+	admin := api.Group("/admin", adminAuth(cfg.AdminToken))
+	{
+		admin.GET("/jobs", jobHandler.List)
+		admin.POST("/jobs/:name/run", jobHandler.Trigger)
+		admin.GET("/jobs/:name/runs", jobHandler.GetRuns)
+		admin.GET("/job-runs/:runID", jobHandler.GetRun)
+	}
+
+	// The audit log is as sensitive as the admin API and guarded the same way
+	api.GET("/org/:id/audit", adminAuth(cfg.AdminToken), auditHandler.List)
+}
+
+func parseDuration(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
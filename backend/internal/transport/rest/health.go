@@ -0,0 +1,25 @@
+package rest
+
+import (
+	"net/http"
+	"nonza/backend/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initHealthRoutes(router *gin.Engine) {
+	// Liveness: the process is up and serving HTTP
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+	})
+
+	// Readiness: dependencies are reachable and the server is not draining
+	router.GET("/readyz", func(c *gin.Context) {
+		report := h.health.Ready(c.Request.Context())
+		status := http.StatusOK
+		if report.Status != health.StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+}
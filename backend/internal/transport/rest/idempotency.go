@@ -0,0 +1,188 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/logger"
+	"nonza/backend/internal/service/idempotency"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// replayedHeader marks a response replayed from an earlier request with the same key
+	replayedHeader       = "Idempotent-Replayed"
+	maxIdempotencyKeyLen = 255
+	// maxIdempotentBodyBytes bounds the body buffered to fingerprint a request; API bodies are small JSON
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+var (
+	errInvalidIdempotencyKey = apperrors.InvalidField(idempotencyKeyHeader, "max", "must be 1 to 255 characters")
+	errBodyTooLarge          = apperrors.TooLarge("body_too_large", "request body exceeds 1 MiB")
+)
+
+// secretRoutes answer with credentials: a LiveKit token with the room's encryption key, a
+// webhook's signing secret. Their responses aren't stored, where a replay could hand out a key
+// that has since been rotated or revoked, so they ignore Idempotency-Key.
+var secretRoutes = map[string]bool{
+	"POST /api/v1/tokens":           true,
+	"POST /api/v1/org/:id/webhooks": true,
+}
+
+// idempotent makes mutating calls that carry an Idempotency-Key take effect once: a retry with
+// the same key gets the first call's response instead of being handled again. Keys are scoped
+// to the organization the call acts on. Failed calls aren't stored, so a retry after one is
+// handled again.
+func idempotent(keys idempotency.Idempotency, log *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if secretRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			_ = c.Error(errInvalidIdempotencyKey)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				_ = c.Error(errBodyTooLarge)
+			} else {
+				_ = c.Error(apperrors.Validation("invalid_request", "request body could not be read"))
+			}
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		scope := idempotencyScope(c)
+		fingerprint := requestFingerprint(c, body)
+
+		stored, err := keys.Begin(ctx, scope, key, fingerprint)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		if stored != nil {
+			c.Header(replayedHeader, "true")
+			c.Data(stored.Status, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		// The response is written; the key must be settled even if the client left or the request timed out
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		// Failures are answered by problemDetails after this returns, so they have written nothing
+		// yet. A response without a body, like c.Status(http.StatusNoContent), has only set its
+		// status: the header is written here so that it is stored and sent the same.
+		if len(c.Errors) == 0 && c.Writer.Status() < http.StatusInternalServerError {
+			c.Writer.WriteHeaderNow()
+			err = keys.Complete(ctx, scope, key, fingerprint, idempotency.Response{
+				Status:      c.Writer.Status(),
+				ContentType: c.Writer.Header().Get("Content-Type"),
+				Body:        writer.body.Bytes(),
+			})
+		} else {
+			err = keys.Release(ctx, scope, key)
+		}
+		if err != nil {
+			log.Error("Failed to settle idempotency key",
+				slog.String("scope", scope),
+				slog.String("request_id", logger.RequestID(c)),
+				slog.Any("error", err))
+		}
+	}
+}
+
+// idempotencyScope returns what the call acts on: the organization in the path or the room of
+// calls addressed by room ID. Calls without one, like creating an organization, share a scope.
+func idempotencyScope(c *gin.Context) string {
+	if orgID, ok := pathOrganization(c); ok {
+		return "org:" + orgID.String()
+	}
+	// The room rather than its organization, which would have to be looked up: after a
+	// deletion there is nothing to look up, and the retry must still find the first response
+	if c.FullPath() == "/api/v1/rooms/id/:id" {
+		if roomID, err := uuid.Parse(c.Param("id")); err == nil {
+			return "room:" + roomID.String()
+		}
+	}
+	return "global"
+}
+
+// pathOrganization returns the organization in the path of calls under /org/:id and /organizations/:id
+func pathOrganization(c *gin.Context) (uuid.UUID, bool) {
+	route := c.FullPath()
+	if !strings.HasPrefix(route, "/api/v1/org/") && !strings.HasPrefix(route, "/api/v1/organizations/") {
+		return uuid.Nil, false
+	}
+	orgID, err := uuid.Parse(c.Param("id"))
+	return orgID, err == nil
+}
+
+// requestFingerprint identifies a request by its method, path and body. JSON bodies are compared
+// by value, so key order and whitespace don't make a retry a different request.
+func requestFingerprint(c *gin.Context, body []byte) string {
+	var value any
+	if err := json.Unmarshal(body, &value); err == nil {
+		if canonical, err := json.Marshal(value); err == nil {
+			body = canonical
+		}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
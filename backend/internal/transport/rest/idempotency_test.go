@@ -0,0 +1,170 @@
+package rest
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/repository/redis"
+	"nonza/backend/internal/service/idempotency"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryKeys is an idempotency store kept in memory; records don't expire
+type memoryKeys struct {
+	mu      sync.Mutex
+	records map[string]redis.IdempotencyRecord
+}
+
+func (m *memoryKeys) ClaimIdempotencyKey(_ context.Context, scope, key, fingerprint string, _ time.Duration) (*redis.IdempotencyRecord, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if record, ok := m.records[scope+key]; ok {
+		return &record, false, nil
+	}
+	record := redis.IdempotencyRecord{Fingerprint: fingerprint}
+	m.records[scope+key] = record
+	return &record, true, nil
+}
+
+func (m *memoryKeys) SaveIdempotencyRecord(_ context.Context, scope, key string, record redis.IdempotencyRecord, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[scope+key] = record
+	return nil
+}
+
+func (m *memoryKeys) DeleteIdempotencyKey(_ context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, scope+key)
+	return nil
+}
+
+func TestIdempotentReplaysFirstResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := idempotency.NewIdempotencyService(&memoryKeys{records: map[string]redis.IdempotencyRecord{}}, time.Hour, time.Minute)
+
+	created := 0
+	fail := true
+	router := gin.New()
+	router.Use(problemDetails())
+	router.POST("/api/v1/org/:id/rooms", idempotent(keys, slog.New(slog.DiscardHandler)), func(c *gin.Context) {
+		if fail {
+			_ = c.Error(apperrors.Unavailable("shutting_down", "try again"))
+			return
+		}
+		created++
+		c.JSON(http.StatusCreated, gin.H{"n": created})
+	})
+
+	send := func(org, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/org/"+org+"/rooms", strings.NewReader(body))
+		req.Header.Set(idempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	const org = "7d6f2a2e-8c1b-4d3e-9f4a-0b5c6d7e8f90"
+
+	if w := send(org, "k1", `{"name":"standup"}`); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("failed call answered %d, want 503", w.Code)
+	}
+
+	fail = false
+	first := send(org, "k1", `{"name":"standup"}`)
+	if first.Code != http.StatusCreated || created != 1 {
+		t.Fatalf("retry after a failure answered %d after %d creations, want it handled again", first.Code, created)
+	}
+
+	// Same request with its keys reformatted
+	retry := send(org, "k1", `{ "name": "standup" }`)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || created != 1 {
+		t.Errorf("retry answered %d %s after %d creations, want the first response replayed", retry.Code, retry.Body, created)
+	}
+	if retry.Header().Get(replayedHeader) != "true" {
+		t.Errorf("replayed response lacks %s", replayedHeader)
+	}
+
+	if w := send(org, "k1", `{"name":"retro"}`); w.Code != http.StatusUnprocessableEntity || created != 1 {
+		t.Errorf("key reused for another body answered %d, want 422", w.Code)
+	}
+
+	// Keys are scoped to the organization
+	if w := send("0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d", "k1", `{"name":"standup"}`); w.Code != http.StatusCreated || created != 2 {
+		t.Errorf("same key in another organization answered %d after %d creations, want a new room", w.Code, created)
+	}
+}
+
+func TestIdempotentStoresResponsesWithoutBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := idempotency.NewIdempotencyService(&memoryKeys{records: map[string]redis.IdempotencyRecord{}}, time.Hour, time.Minute)
+
+	deleted := false
+	router := gin.New()
+	router.Use(problemDetails())
+	router.DELETE("/api/v1/rooms/id/:id", idempotent(keys, slog.New(slog.DiscardHandler)), func(c *gin.Context) {
+		if deleted {
+			_ = c.Error(apperrors.NotFound("room_not_found", "room not found"))
+			return
+		}
+		deleted = true
+		c.Status(http.StatusNoContent)
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/rooms/id/7d6f2a2e-8c1b-4d3e-9f4a-0b5c6d7e8f90", nil)
+		req.Header.Set(idempotencyKeyHeader, "k1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(); w.Code != http.StatusNoContent {
+		t.Fatalf("delete answered %d, want 204", w.Code)
+	}
+	retry := send()
+	if retry.Code != http.StatusNoContent || retry.Header().Get(replayedHeader) != "true" {
+		t.Errorf("retried delete answered %d (replayed %q), want the 204 replayed", retry.Code, retry.Header().Get(replayedHeader))
+	}
+}
+
+func TestIdempotentSkipsSecretRoutesAndBoundsBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := idempotency.NewIdempotencyService(&memoryKeys{records: map[string]redis.IdempotencyRecord{}}, time.Hour, time.Minute)
+
+	issued := 0
+	router := gin.New()
+	router.Use(problemDetails())
+	router.POST("/api/v1/tokens", idempotent(keys, slog.New(slog.DiscardHandler)), func(c *gin.Context) {
+		issued++
+		c.JSON(http.StatusOK, gin.H{"token": issued})
+	})
+	router.POST("/api/v1/organizations", idempotent(keys, slog.New(slog.DiscardHandler)), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(idempotencyKeyHeader, "k1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	send("/api/v1/tokens", `{"short_code":"abc"}`)
+	if retry := send("/api/v1/tokens", `{"short_code":"abc"}`); retry.Header().Get(replayedHeader) != "" || issued != 2 {
+		t.Errorf("token retried with the same key was replayed (%d issued), want a fresh token", issued)
+	}
+
+	if w := send("/api/v1/organizations", strings.Repeat("a", maxIdempotentBodyBytes+1)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized body answered %d, want 413", w.Code)
+	}
+}
@@ -0,0 +1,41 @@
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminAuth guards /admin routes with a static bearer token. Without a token configured the
+// routes exist but refuse every request.
+func adminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			_ = c.Error(errAdminDisabled)
+			c.Abort()
+			return
+		}
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			_ = c.Error(errUnauthorized)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestTimeout puts a deadline on the request context so database and Redis calls
+// made on its behalf are cancelled when the request takes too long
+func requestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,22 @@
+package rest
+
+import (
+	"net/http"
+
+	"nonza/backend/internal/openapi"
+	"nonza/backend/internal/transport/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) initDocsRoutes(api *gin.RouterGroup) {
+	// Built once: neither the document nor the websocket protocol change while the server runs
+	doc, err := openapi.Document(websocket.Schema())
+	if err != nil {
+		panic("openapi: " + err.Error())
+	}
+
+	api.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", doc)
+	})
+}
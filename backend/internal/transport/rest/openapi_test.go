@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"nonza/backend/internal/config"
+	"nonza/backend/internal/openapi"
+	"nonza/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiPrefix is where the document's server URL puts its paths
+const apiPrefix = "/api/v1"
+
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+// TestRoutesMatchOpenAPI fails when a route under /api/v1 is missing from the OpenAPI document,
+// or the document describes an operation the router doesn't serve
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(&service.Services{}, nil, nil, slog.New(slog.DiscardHandler))
+	// Without an admin token: the admin routes are served (and refused) all the same
+	router := h.InitRoutes(&config.Config{})
+
+	var routes []string
+	for _, r := range router.Routes() {
+		if path, ok := strings.CutPrefix(r.Path, apiPrefix); ok {
+			routes = append(routes, r.Method+" "+ginParam.ReplaceAllString(path, "{$1}"))
+		}
+	}
+
+	var doc struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openapi.Spec(), &doc); err != nil {
+		t.Fatalf("decode OpenAPI document: %v", err)
+	}
+	if len(doc.Servers) != 1 || doc.Servers[0].URL != apiPrefix {
+		t.Fatalf("servers %+v, want the single server %s", doc.Servers, apiPrefix)
+	}
+	var operations []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method != "parameters" {
+				operations = append(operations, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+
+	for _, route := range routes {
+		if !slices.Contains(operations, route) {
+			t.Errorf("route %s is not in the OpenAPI document", route)
+		}
+	}
+	for _, operation := range operations {
+		if !slices.Contains(routes, operation) {
+			t.Errorf("OpenAPI operation %s has no route", operation)
+		}
+	}
+}
+
+func TestOpenAPIDocumentHasWebsocketAppendix(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	(&Handler{}).initDocsRoutes(router.Group(apiPrefix))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, apiPrefix+"/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+
+	var doc struct {
+		OpenAPI   string `json:"openapi"`
+		Websocket struct {
+			Messages struct {
+				Defs map[string]any `json:"$defs"`
+			} `json:"messages"`
+		} `json:"x-websocket"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi %q, want a 3.x document", doc.OpenAPI)
+	}
+	if len(doc.Websocket.Messages.Defs) == 0 {
+		t.Errorf("x-websocket has no message definitions")
+	}
+}
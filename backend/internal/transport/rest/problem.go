@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:nonza:problem:"
+)
+
+var (
+	errInternal     = apperrors.New(apperrors.KindInternal, "internal_error", "internal server error")
+	errNotFound     = apperrors.NotFound("not_found", "resource not found")
+	errTimeout      = apperrors.Unavailable("timeout", "request took too long")
+	errNoRoute      = apperrors.NotFound("route_not_found", "no such endpoint")
+	errUnauthorized = apperrors.Unauthorized("unauthorized", "missing or invalid admin token")
+	// errAdminDisabled answers admin routes when no ADMIN_TOKEN is configured
+	errAdminDisabled = apperrors.Forbidden("admin_disabled", "admin API is disabled on this server")
+)
+
+// Problem is an RFC 7807 problem details document, extended with a stable error code,
+// the request ID and, for validation errors, the offending fields
+type Problem struct {
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Status    int                    `json:"status"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	Code      string                 `json:"code"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Errors    []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+// problemDetails answers requests whose handler recorded an error with c.Error, and wrote nothing
+// itself, with the problem document of the last error. Errors that are not apperrors are logged
+// and answered as internal errors, so storage messages never reach clients.
+func problemDetails() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		appErr := toAppError(err)
+		status := appErr.Kind.Status()
+		if status >= http.StatusInternalServerError {
+			logger.FromContext(c.Request.Context()).Error("Request failed", slog.String("code", appErr.Code), slog.Any("error", err))
+		}
+
+		c.Header("Content-Type", problemContentType)
+		c.JSON(status, Problem{
+			Type:      problemTypePrefix + appErr.Code,
+			Title:     http.StatusText(status),
+			Status:    status,
+			Detail:    appErr.Message,
+			Instance:  c.Request.URL.Path,
+			Code:      appErr.Code,
+			RequestID: logger.RequestID(c),
+			Errors:    appErr.Fields,
+		})
+	}
+}
+
+// responseStatus is the status the request is answered with, including a problem response
+// problemDetails is yet to write for it
+func responseStatus(c *gin.Context) int {
+	if !c.Writer.Written() && len(c.Errors) > 0 {
+		return toAppError(c.Errors.Last().Err).Kind.Status()
+	}
+	return c.Writer.Status()
+}
+
+func toAppError(err error) *apperrors.Error {
+	if appErr, ok := apperrors.As(err); ok {
+		return appErr
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return errTimeout
+	default:
+		return errInternal
+	}
+}
+
+func noRoute(c *gin.Context) {
+	_ = c.Error(errNoRoute)
+}
@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nonza/backend/internal/apperrors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func serveProblem(t *testing.T, err error) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(problemDetails())
+	router.GET("/fail", func(c *gin.Context) { _ = c.Error(err) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	var p Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode problem: %v; body %s", err, w.Body)
+	}
+	return w, p
+}
+
+func TestProblemDetails(t *testing.T) {
+	invalid := apperrors.InvalidField("name", "required", "is required")
+	w, p := serveProblem(t, fmt.Errorf("create room: %w", invalid))
+
+	if w.Code != http.StatusBadRequest || p.Status != http.StatusBadRequest {
+		t.Errorf("status %d, document status %d; want 400", w.Code, p.Status)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, problemContentType) {
+		t.Errorf("Content-Type %q, want %s", ct, problemContentType)
+	}
+	if p.Code != "invalid_request" || p.Type != problemTypePrefix+"invalid_request" || p.Instance != "/fail" {
+		t.Errorf("problem %+v", p)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Field != "name" {
+		t.Errorf("field errors %+v, want name", p.Errors)
+	}
+}
+
+func TestProblemDetailsHidesInternalErrors(t *testing.T) {
+	w, p := serveProblem(t, fmt.Errorf("insert room: %w", gorm.ErrInvalidDB))
+	if w.Code != http.StatusInternalServerError || p.Code != "internal_error" || strings.Contains(w.Body.String(), "insert room") {
+		t.Errorf("status %d, body %s; want a 500 internal_error without the cause", w.Code, w.Body)
+	}
+
+	w, p = serveProblem(t, fmt.Errorf("load: %w", gorm.ErrRecordNotFound))
+	if w.Code != http.StatusNotFound || p.Code != "not_found" {
+		t.Errorf("status %d, code %s; want 404 not_found", w.Code, p.Code)
+	}
+}
@@ -0,0 +1,174 @@
+package v1
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"nonza/backend/internal/apperrors"
+	auditDto "nonza/backend/internal/dto/audit"
+	pageDto "nonza/backend/internal/dto/pagination"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/pagination"
+	"nonza/backend/internal/service"
+	"nonza/backend/internal/service/audit"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	maxAuditLimit = 500
+
+	// auditTargetKey is the gin context key handlers leave the AuditTarget of their call under
+	auditTargetKey = "audit_target"
+)
+
+// AuditTarget is what a handler tells the audit middleware about the call it handled
+type AuditTarget struct {
+	OrganizationID uuid.UUID
+	Type           string
+	ID             string
+	// ActorType and ActorID override the actor the middleware derives from the request
+	ActorType string
+	ActorID   string
+	Diff      models.AuditDiff
+}
+
+func setAuditTarget(c *gin.Context, target AuditTarget) {
+	c.Set(auditTargetKey, target)
+}
+
+// GetAuditTarget returns the AuditTarget the handler of the call set, if any
+func GetAuditTarget(c *gin.Context) (AuditTarget, bool) {
+	value, ok := c.Get(auditTargetKey)
+	if !ok {
+		return AuditTarget{}, false
+	}
+	target, ok := value.(AuditTarget)
+	return target, ok
+}
+
+type AuditHandler struct {
+	Services *service.Services
+}
+
+func NewAuditHandler(services *service.Services) *AuditHandler {
+	return &AuditHandler{Services: services}
+}
+
+// List returns the organization's audit log, newest first, as a JSON page or, with format=csv,
+// as a CSV export of every matching event
+func (h *AuditHandler) List(c *gin.Context) {
+	orgID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	filter := models.AuditFilter{
+		OrganizationID: orgID,
+		Action:         c.Query("action"),
+		ActorID:        c.Query("actor"),
+		TargetType:     c.Query("target_type"),
+		TargetID:       c.Query("target_id"),
+	}
+	for param, bound := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := c.Query(param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				_ = c.Error(apperrors.InvalidField(param, "datetime", "must be an RFC 3339 time"))
+				return
+			}
+			*bound = &t
+		}
+	}
+
+	page, ok := parsePage(c, audit.SortFields, "-occurred_at", maxAuditLimit)
+	if !ok {
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		h.listJSON(c, filter, page)
+	case "csv":
+		page.Limit = maxAuditLimit
+		h.exportCSV(c, filter, page)
+	default:
+		_ = c.Error(apperrors.InvalidField("format", "oneof", "must be one of: json csv"))
+	}
+}
+
+func (h *AuditHandler) listJSON(c *gin.Context, filter models.AuditFilter, page pagination.Page) {
+	events, next, err := h.Services.Audit.List(c.Request.Context(), filter, page)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageDto.ToPage(events, next, auditDto.ToAuditEventResponse))
+}
+
+// exportCSV streams every event matching the filter from the page on, reading them page by page
+func (h *AuditHandler) exportCSV(c *gin.Context, filter models.AuditFilter, page pagination.Page) {
+	events, next, err := h.Services.Audit.List(c.Request.Context(), filter, page)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="audit-`+filter.OrganizationID.String()+`.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(auditDto.CSVHeader)
+	for {
+		for i := range events {
+			_ = w.Write(csvRecord(&events[i]))
+		}
+		w.Flush()
+		if next == "" || w.Error() != nil {
+			return
+		}
+
+		// Headers are sent, so a failure now can only cut the export short
+		page, err = pagination.Parse(page.Sort.String(), next, page.Limit, audit.SortFields, "")
+		if err == nil {
+			events, next, err = h.Services.Audit.List(c.Request.Context(), filter, page)
+		}
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+}
+
+func csvRecord(event *models.AuditEvent) []string {
+	orgID := ""
+	if event.OrganizationID != nil {
+		orgID = event.OrganizationID.String()
+	}
+	diff := ""
+	if len(event.Diff) > 0 {
+		if data, err := json.Marshal(event.Diff); err == nil {
+			diff = string(data)
+		}
+	}
+	return []string{
+		event.ID.String(),
+		event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		orgID,
+		event.ActorType,
+		event.ActorID,
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		strconv.Itoa(event.Status),
+		event.IP,
+		event.UserAgent,
+		event.RequestID,
+		diff,
+	}
+}
@@ -0,0 +1,103 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"nonza/backend/internal/apperrors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+var (
+	errInvalidBody    = apperrors.Validation("invalid_body", "request body is not valid JSON")
+	errInvalidRequest = apperrors.Validation("invalid_request", "request is invalid")
+)
+
+var jsonFieldNames sync.Once
+
+// bindJSON decodes and validates the request body into req. On failure it records a validation
+// error naming the offending fields by their JSON names and returns false.
+func bindJSON(c *gin.Context, req any) bool {
+	jsonFieldNames.Do(func() {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			v.RegisterTagNameFunc(func(field reflect.StructField) string {
+				name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+				if name == "-" {
+					return ""
+				}
+				return name
+			})
+		}
+	})
+
+	if err := c.ShouldBindJSON(req); err != nil {
+		_ = c.Error(bindingError(err))
+		return false
+	}
+	return true
+}
+
+func bindingError(err error) *apperrors.Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]apperrors.FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			fields[i] = apperrors.FieldError{Field: fieldPath(fe), Code: fe.Tag(), Message: ruleMessage(fe)}
+		}
+		return errInvalidRequest.WithFields(fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return errInvalidRequest.WithFields(apperrors.FieldError{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: "must be " + typeErr.Type.String(),
+		})
+	}
+
+	return errInvalidBody.Wrap(err)
+}
+
+// fieldPath is the field's JSON path without the request struct's name, e.g. "ws_limits.bytes_per_second"
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "url":
+		return "must be a URL"
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " rule"
+	}
+}
+
+// uuidParam parses a UUID path parameter. On failure it records a validation error and returns false.
+func uuidParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		_ = c.Error(apperrors.InvalidField(name, "uuid", "must be a UUID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,104 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	roomDto "nonza/backend/internal/dto/rooms"
+	"nonza/backend/internal/service/roomevents"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseKeepAlive is how often an idle event stream gets a comment line so proxies keep it open
+const sseKeepAlive = 15 * time.Second
+
+// presenceEvent is the first event of a fresh stream: who is in the room right now
+const presenceEvent = "presence"
+
+// Events streams a room's events as Server-Sent Events. A client that reconnects with
+// Last-Event-ID first gets the events it missed, as far back as they are kept; a fresh
+// stream starts with the room's current presence.
+func (h *RoomsHandler) Events(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	room, err := h.Services.Rooms.GetByShortCode(ctx, c.Param("shortCode"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	roomID := room.ID.String()
+
+	// EventSource sends Last-Event-ID itself when it reconnects; the query parameter lets a page resume after a reload
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	sub, err := h.Services.RoomEvents.Subscribe(ctx, roomID, lastEventID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	defer sub.Close()
+
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		_ = c.Error(fmt.Errorf("streaming is not supported: %w", err))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if lastEventID == "" {
+		if entries, err := h.Services.Rooms.GetPresence(ctx, room.ID); err == nil {
+			data, _ := json.Marshal(roomDto.ToPresenceResponse(roomID, entries))
+			writeSSE(c.Writer, "", presenceEvent, data)
+		}
+	}
+	for _, event := range sub.Backlog {
+		writeRoomEvent(c.Writer, event)
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped for falling behind, or shutting down: the client reconnects with Last-Event-ID
+				return
+			}
+			writeRoomEvent(c.Writer, event)
+		case <-keepAlive.C:
+			io.WriteString(c.Writer, ": keep-alive\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeRoomEvent(w io.Writer, event roomevents.Event) {
+	data, err := json.Marshal(roomDto.ToRoomEventResponse(event))
+	if err != nil {
+		return
+	}
+	writeSSE(w, event.ID, event.Type, data)
+}
+
+// writeSSE writes one event; data must be a single line, which JSON is
+func writeSSE(w io.Writer, id, event string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"net/http"
+	jobDto "nonza/backend/internal/dto/jobs"
+	"nonza/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultJobRunsLimit = 20
+	maxJobRunsLimit     = 100
+)
+
+type JobsHandler struct {
+	Services *service.Services
+}
+
+func NewJobsHandler(services *service.Services) *JobsHandler {
+	return &JobsHandler{Services: services}
+}
+
+func (h *JobsHandler) List(c *gin.Context) {
+	infos := h.Services.Jobs.List()
+
+	response := make([]jobDto.JobResponse, len(infos))
+	for i, info := range infos {
+		response[i] = jobDto.ToJobResponse(info)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *JobsHandler) Trigger(c *gin.Context) {
+	run, err := h.Services.Jobs.Trigger(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setAuditTarget(c, AuditTarget{Type: "job", ID: run.JobName})
+
+	c.JSON(http.StatusAccepted, jobDto.ToJobRunResponse(run))
+}
+
+func (h *JobsHandler) GetRuns(c *gin.Context) {
+	limit, ok := parseLimit(c, defaultJobRunsLimit, maxJobRunsLimit)
+	if !ok {
+		return
+	}
+
+	runs, err := h.Services.Jobs.GetRuns(c.Request.Context(), c.Param("name"), limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := make([]jobDto.JobRunResponse, len(runs))
+	for i := range runs {
+		response[i] = jobDto.ToJobRunResponse(&runs[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *JobsHandler) GetRun(c *gin.Context) {
+	id, ok := uuidParam(c, "runID")
+	if !ok {
+		return
+	}
+
+	run, err := h.Services.Jobs.GetRun(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, jobDto.ToJobRunResponse(run))
+}
@@ -1,13 +1,16 @@
 package v1
 
 import (
-	"log"
 	"net/http"
+	jobDto "nonza/backend/internal/dto/jobs"
 	orgDto "nonza/backend/internal/dto/organizations"
+	pageDto "nonza/backend/internal/dto/pagination"
+	"nonza/backend/internal/models"
 	"nonza/backend/internal/service"
+	"nonza/backend/internal/service/audit"
+	"nonza/backend/internal/service/organizations"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 type OrganizationsHandler struct {
@@ -19,76 +22,117 @@ func NewOrganizationsHandler(services *service.Services) *OrganizationsHandler {
 }
 
 func (h *OrganizationsHandler) Create(c *gin.Context) {
-	log.Printf("[OrganizationsHandler] POST /api/v1/organizations - received request from %s", c.ClientIP())
-	
 	var req orgDto.CreateOrganizationRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("[OrganizationsHandler] Failed to bind JSON: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	log.Printf("[OrganizationsHandler] Creating organization: name=%s, description=%s", req.Name, req.Description)
-	org, err := h.Services.Organizations.Create(req.Name, req.Description)
+	org, err := h.Services.Organizations.Create(c.Request.Context(), req.Name, req.Description, req.Slug)
 	if err != nil {
-		log.Printf("[OrganizationsHandler] Failed to create organization: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	log.Printf("[OrganizationsHandler] Successfully created organization: id=%s", org.ID)
-	c.JSON(http.StatusCreated, orgDto.ToOrganizationResponse(org))
+	response := orgDto.ToOrganizationResponse(org)
+	setAuditTarget(c, AuditTarget{OrganizationID: org.ID, Type: "organization", ID: org.ID.String(), Diff: audit.Diff(nil, response)})
+
+	c.JSON(http.StatusCreated, response)
 }
 
 func (h *OrganizationsHandler) GetByID(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	id, ok := uuidParam(c, "id")
+	if !ok {
 		return
 	}
 
-	org, err := h.Services.Organizations.GetByID(id)
+	org, err := h.Services.Organizations.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, orgDto.ToOrganizationResponse(org))
 }
 
-func (h *OrganizationsHandler) Update(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
+// List lists all organizations
+func (h *OrganizationsHandler) List(c *gin.Context) {
+	filter := models.OrganizationFilter{NamePrefix: c.Query("name")}
+	var ok bool
+	if filter.Deleting, ok = parseBoolQuery(c, "deleting"); !ok {
+		return
+	}
+	page, ok := parsePage(c, organizations.SortFields, "-created_at", maxPageLimit)
+	if !ok {
+		return
+	}
+
+	orgs, next, err := h.Services.Organizations.List(c.Request.Context(), filter, page)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageDto.ToPage(orgs, next, orgDto.ToOrganizationResponse))
+}
+
+func (h *OrganizationsHandler) Update(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
 		return
 	}
 
 	var req orgDto.UpdateOrganizationRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	org, err := h.Services.Organizations.Update(id, req.Name, req.Description)
+	before, err := h.Services.Organizations.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, orgDto.ToOrganizationResponse(org))
+	org, err := h.Services.Organizations.Update(c.Request.Context(), id, req.Name, req.Description, req.Slug, req.ArchiveRetentionDays, req.WSLimits.ToModel(), req.ShortCodes.ToModel())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := orgDto.ToOrganizationResponse(org)
+	setAuditTarget(c, AuditTarget{OrganizationID: org.ID, Type: "organization", ID: org.ID.String(),
+		Diff: audit.Diff(orgDto.ToOrganizationResponse(before), response)})
+
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *OrganizationsHandler) Delete(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	run, err := h.Services.Organizations.RequestDeletion(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		_ = c.Error(err)
 		return
 	}
 
-	if err := h.Services.Organizations.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	setAuditTarget(c, AuditTarget{OrganizationID: id, Type: "organization", ID: id.String()})
+
+	c.JSON(http.StatusAccepted, jobDto.ToJobRunResponse(run))
+}
+
+func (h *OrganizationsHandler) GetDeletionStatus(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	run, err := h.Services.Organizations.GetDeletionStatus(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusNoContent, nil)
+	c.JSON(http.StatusOK, jobDto.ToJobRunResponse(run))
 }
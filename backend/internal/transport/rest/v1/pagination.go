@@ -0,0 +1,57 @@
+package v1
+
+import (
+	"nonza/backend/internal/apperrors"
+	"nonza/backend/internal/pagination"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// parseLimit reads the limit query parameter, capped at maxLimit. On failure it records a validation error and returns false.
+func parseLimit(c *gin.Context, defaultLimit, maxLimit int) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return defaultLimit, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		_ = c.Error(apperrors.InvalidField("limit", "min", "must be a positive integer"))
+		return 0, false
+	}
+	return min(n, maxLimit), true
+}
+
+// parsePage reads the limit, sort and cursor query parameters of a listing. On failure it records a validation error and returns false.
+func parsePage(c *gin.Context, fields pagination.Fields, defaultSort string, maxLimit int) (pagination.Page, bool) {
+	limit, ok := parseLimit(c, defaultPageLimit, maxLimit)
+	if !ok {
+		return pagination.Page{}, false
+	}
+
+	page, err := pagination.Parse(c.Query("sort"), c.Query("cursor"), limit, fields, defaultSort)
+	if err != nil {
+		_ = c.Error(err)
+		return pagination.Page{}, false
+	}
+	return page, true
+}
+
+// parseBoolQuery reads an optional true/false query parameter. On failure it records a validation error and returns false.
+func parseBoolQuery(c *gin.Context, name string) (*bool, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		_ = c.Error(apperrors.InvalidField(name, "boolean", "must be true or false"))
+		return nil, false
+	}
+	return &v, true
+}
@@ -2,13 +2,16 @@ package v1
 
 import (
 	"net/http"
+	"net/url"
+	pageDto "nonza/backend/internal/dto/pagination"
 	roomDto "nonza/backend/internal/dto/rooms"
 	"nonza/backend/internal/models"
 	"nonza/backend/internal/service"
+	"nonza/backend/internal/service/audit"
+	"nonza/backend/internal/service/rooms"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 type RoomsHandler struct {
@@ -21,15 +24,13 @@ func NewRoomsHandler(services *service.Services) *RoomsHandler {
 
 func (h *RoomsHandler) Create(c *gin.Context) {
 	// Use "id" param (same as organizations routes) to avoid route conflict
-	orgID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+	orgID, ok := uuidParam(c, "id")
+	if !ok {
 		return
 	}
 
 	var req roomDto.CreateRoomRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -41,61 +42,253 @@ func (h *RoomsHandler) Create(c *gin.Context) {
 		}
 	}
 
-	room, err := h.Services.Rooms.Create(orgID, req.Name, models.RoomType(req.RoomType), req.IsTemporary, expiresIn, req.E2EEEnabled)
+	room, err := h.Services.Rooms.Create(c.Request.Context(), orgID, req.Name, req.Slug, models.RoomType(req.RoomType), req.IsTemporary, expiresIn, req.E2EEEnabled)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, roomDto.ToRoomResponse(room))
+	response := roomDto.ToRoomResponse(room)
+	setAuditTarget(c, AuditTarget{OrganizationID: orgID, Type: "room", ID: room.ID.String(), Diff: audit.Diff(nil, response)})
+
+	c.JSON(http.StatusCreated, response)
 }
 
 func (h *RoomsHandler) GetByShortCode(c *gin.Context) {
 	shortCode := c.Param("shortCode")
 
-	room, err := h.Services.Rooms.GetByShortCode(shortCode)
+	room, err := h.Services.Rooms.GetByShortCode(c.Request.Context(), shortCode)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, roomDto.ToRoomResponse(room))
 }
 
-func (h *RoomsHandler) GetByID(c *gin.Context) {
-	id, err := uuid.Parse(c.Param("id"))
+// ResolveSlug answers a vanity link /r/:org/:slug, where org is the organization's slug or ID.
+// A slug the room or its organization had before redirects to the current link.
+func (h *RoomsHandler) ResolveSlug(c *gin.Context) {
+	orgRef := c.Param("org")
+
+	org, orgMoved, err := h.Services.Organizations.GetByRef(c.Request.Context(), orgRef)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		_ = c.Error(err)
 		return
 	}
+	if orgMoved {
+		orgRef = org.ID.String()
+		if org.Slug != nil {
+			orgRef = *org.Slug
+		}
+	}
 
-	room, err := h.Services.Rooms.GetByID(id)
+	room, moved, err := h.Services.Rooms.ResolveSlug(c.Request.Context(), org.ID, c.Param("slug"))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
+		_ = c.Error(err)
+		return
+	}
+	if moved || orgMoved {
+		location := "/api/v1/rooms/id/" + room.ID.String()
+		if room.Slug != nil {
+			location = "/api/v1/r/" + url.PathEscape(orgRef) + "/" + *room.Slug
+		}
+		c.Redirect(http.StatusFound, location)
 		return
 	}
 
 	c.JSON(http.StatusOK, roomDto.ToRoomResponse(room))
 }
 
-func (h *RoomsHandler) GetByOrganizationID(c *gin.Context) {
-	// Use "id" param (same as organizations routes) to avoid route conflict
-	orgID, err := uuid.Parse(c.Param("id"))
+func (h *RoomsHandler) GetByID(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	room, err := h.Services.Rooms.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		_ = c.Error(err)
 		return
 	}
 
-	rooms, err := h.Services.Rooms.GetByOrganizationID(orgID)
+	c.JSON(http.StatusOK, roomDto.ToRoomResponse(room))
+}
+
+// Update changes the settings of an active room
+func (h *RoomsHandler) Update(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req roomDto.UpdateRoomRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	before, err := h.Services.Rooms.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	response := make([]roomDto.RoomResponse, len(rooms))
-	for i, r := range rooms {
-		response[i] = roomDto.ToRoomResponse(&r)
+	changes := rooms.RoomChanges{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		IsTemporary: req.IsTemporary,
+		ExpiresAt:   req.ExpiresAt,
+		E2EEEnabled: req.E2EEEnabled,
+	}
+	if req.RoomType != nil {
+		roomType := models.RoomType(*req.RoomType)
+		changes.RoomType = &roomType
+	}
+
+	room, err := h.Services.Rooms.Update(c.Request.Context(), id, changes)
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
 
+	response := roomDto.ToRoomResponse(room)
+	setAuditTarget(c, AuditTarget{OrganizationID: room.OrganizationID, Type: "room", ID: room.ID.String(),
+		Diff: audit.Diff(roomDto.ToRoomResponse(before), response)})
+
 	c.JSON(http.StatusOK, response)
 }
+
+// Delete permanently deletes a room
+func (h *RoomsHandler) Delete(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	room, err := h.Services.Rooms.GetByID(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := h.Services.Rooms.Delete(c.Request.Context(), id); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setAuditTarget(c, AuditTarget{OrganizationID: room.OrganizationID, Type: "room", ID: room.ID.String(),
+		Diff: audit.Diff(roomDto.ToRoomResponse(room), nil)})
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetPresence lists who is connected to the room's collaborative document
+func (h *RoomsHandler) GetPresence(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.Services.Rooms.GetByID(c.Request.Context(), id); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	entries, err := h.Services.Rooms.GetPresence(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roomDto.ToPresenceResponse(id.String(), entries))
+}
+
+// GetDocument answers the room's Y.js document state, which clients apply with Y.applyUpdate.
+// Archived rooms keep theirs, so notes of a meeting stay readable after it has expired.
+func (h *RoomsHandler) GetDocument(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	state, err := h.Services.Rooms.GetDocument(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/octet-stream", state)
+}
+
+// GetByOrganizationID lists the organization's active rooms
+func (h *RoomsHandler) GetByOrganizationID(c *gin.Context) {
+	h.list(c, models.RoomStatusActive)
+}
+
+// GetArchivedByOrganizationID lists the organization's archived rooms
+func (h *RoomsHandler) GetArchivedByOrganizationID(c *gin.Context) {
+	h.list(c, models.RoomStatusArchived)
+}
+
+func (h *RoomsHandler) list(c *gin.Context, status models.RoomStatus) {
+	// Use "id" param (same as organizations routes) to avoid route conflict
+	orgID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	filter := models.RoomFilter{
+		OrganizationID: orgID,
+		Status:         status,
+		RoomType:       models.RoomType(c.Query("room_type")),
+		NamePrefix:     c.Query("name"),
+	}
+	if filter.IsTemporary, ok = parseBoolQuery(c, "is_temporary"); !ok {
+		return
+	}
+	if filter.Expired, ok = parseBoolQuery(c, "expired"); !ok {
+		return
+	}
+	page, ok := parsePage(c, rooms.SortFields, "-created_at", maxPageLimit)
+	if !ok {
+		return
+	}
+
+	result, next, err := h.Services.Rooms.List(c.Request.Context(), filter, page)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageDto.ToPage(result, next, roomDto.ToRoomResponse))
+}
+
+// GetParticipants lists the room's participants
+func (h *RoomsHandler) GetParticipants(c *gin.Context) {
+	id, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.Services.Rooms.GetByID(c.Request.Context(), id); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filter := models.ParticipantFilter{RoomID: id, Role: models.ParticipantRole(c.Query("role"))}
+	if filter.Active, ok = parseBoolQuery(c, "active"); !ok {
+		return
+	}
+	page, ok := parsePage(c, rooms.ParticipantSortFields, "-joined_at", maxPageLimit)
+	if !ok {
+		return
+	}
+
+	participants, next, err := h.Services.Rooms.ListParticipants(c.Request.Context(), filter, page)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pageDto.ToPage(participants, next, roomDto.ToParticipantResponse))
+}
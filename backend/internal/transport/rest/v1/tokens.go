@@ -1,11 +1,15 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"nonza/backend/internal/config"
 	tokenDto "nonza/backend/internal/dto/tokens"
+	"nonza/backend/internal/models"
 	"nonza/backend/internal/service"
-	"nonza/backend/internal/transport/websocket"
+	"nonza/backend/internal/service/domainevents"
+	"nonza/backend/internal/service/organizations"
+	"nonza/backend/internal/service/rooms"
 	"nonza/backend/internal/webrtc/livekit"
 	"nonza/backend/internal/webrtc/turn"
 	"strings"
@@ -24,32 +28,41 @@ type TokensHandler struct {
 	Services *service.Services
 	Config   *config.Config
 	LiveKit  *livekit.Client
-	WSHub    interface {
-		BroadcastToRoom(roomID string, message interface{}) error
-	}
 }
 
-func NewTokensHandler(services *service.Services, cfg *config.Config, wsHub interface {
-	BroadcastToRoom(roomID string, message interface{}) error
-}) *TokensHandler {
+func NewTokensHandler(services *service.Services, cfg *config.Config) *TokensHandler {
 	return &TokensHandler{
 		Services: services,
 		Config:   cfg,
 		LiveKit:  livekit.NewClient(cfg),
-		WSHub:    wsHub,
 	}
 }
 
 func (h *TokensHandler) GenerateToken(c *gin.Context) {
 	var req tokenDto.GenerateTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	room, err := h.Services.Rooms.GetByShortCode(req.ShortCode)
+	room, err := h.Services.Rooms.GetByShortCode(c.Request.Context(), req.ShortCode)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
+		_ = c.Error(err)
+		return
+	}
+
+	if room.Status == models.RoomStatusArchived {
+		_ = c.Error(rooms.ErrRoomArchived)
+		return
+	}
+
+	// Organizations being deleted get no new tokens; their live sessions are ended by the deletion job
+	org, err := h.Services.Organizations.GetByID(c.Request.Context(), room.OrganizationID)
+	if err != nil && !errors.Is(err, organizations.ErrOrganizationNotFound) {
+		_ = c.Error(err)
+		return
+	}
+	if err != nil || org.DeletionRequestedAt != nil {
+		_ = c.Error(rooms.ErrRoomUnavailable)
 		return
 	}
 
@@ -59,12 +72,26 @@ func (h *TokensHandler) GenerateToken(c *gin.Context) {
 	}
 
 	token, err := h.LiveKit.GenerateAccessToken(
+		c.Request.Context(),
 		room.LiveKitRoomName,
 		participantID,
 		req.ParticipantName,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		_ = c.Error(err)
+		return
+	}
+
+	// The room's clients are told about the participant, and the token counted, by the event's subscribers
+	event, err := domainevents.NewEvent(domainevents.TokenIssued, room.ID, room.OrganizationID, domainevents.TokenIssuedPayload{
+		ParticipantID:   participantID,
+		ParticipantName: req.ParticipantName,
+	})
+	if err == nil {
+		err = h.Services.Events.Publish(c.Request.Context(), event)
+	}
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -87,6 +114,20 @@ func (h *TokensHandler) GenerateToken(c *gin.Context) {
 		}
 	}
 
+	// The key itself stays out of the audit log; that it was handed out is what matters
+	diff := models.AuditDiff{"participant_name": {New: req.ParticipantName}}
+	if response.EncryptionKey != "" {
+		diff["encryption_key"] = models.FieldChange{New: "disclosed"}
+	}
+	setAuditTarget(c, AuditTarget{
+		OrganizationID: room.OrganizationID,
+		Type:           "room",
+		ID:             room.ID.String(),
+		ActorType:      models.AuditActorParticipant,
+		ActorID:        participantID,
+		Diff:           diff,
+	})
+
 	// If TURNURL is unset, clients use LiveKit's built-in TURN from the join response.
 	if h.Config.TURNURL != "" && h.Config.TURNSecret != "" {
 		secret := normalizeTURNSecret(h.Config.TURNSecret)
@@ -104,19 +145,5 @@ func (h *TokensHandler) GenerateToken(c *gin.Context) {
 		}
 	}
 
-	// Optionally broadcast event about new participant joining
-	if h.WSHub != nil {
-		// Get room ID from room (you may need to adjust this based on your room model)
-		roomID := room.LiveKitRoomName // or room.ID.String() if you want to use UUID
-		h.WSHub.BroadcastToRoom(roomID, websocket.Message{
-			Type:   "participant_joining",
-			RoomID: roomID,
-			Payload: map[string]interface{}{
-				"participant_id":   participantID,
-				"participant_name": req.ParticipantName,
-			},
-		})
-	}
-
 	c.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,191 @@
+package v1
+
+import (
+	"net/http"
+	webhookDto "nonza/backend/internal/dto/webhooks"
+	"nonza/backend/internal/service"
+	"nonza/backend/internal/service/audit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+type WebhooksHandler struct {
+	Services *service.Services
+}
+
+func NewWebhooksHandler(services *service.Services) *WebhooksHandler {
+	return &WebhooksHandler{Services: services}
+}
+
+func (h *WebhooksHandler) Create(c *gin.Context) {
+	orgID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req webhookDto.CreateWebhookRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	endpoint, err := h.Services.Webhooks.CreateEndpoint(c.Request.Context(), orgID, req.URL, req.Events)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// The secret stays out of the audit log
+	setAuditTarget(c, AuditTarget{OrganizationID: orgID, Type: "webhook", ID: endpoint.ID.String(),
+		Diff: audit.Diff(nil, webhookDto.ToWebhookResponse(endpoint))})
+
+	c.JSON(http.StatusCreated, webhookDto.ToCreatedWebhookResponse(endpoint))
+}
+
+func (h *WebhooksHandler) List(c *gin.Context) {
+	orgID, ok := uuidParam(c, "id")
+	if !ok {
+		return
+	}
+
+	endpoints, err := h.Services.Webhooks.GetEndpoints(c.Request.Context(), orgID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := make([]webhookDto.WebhookResponse, len(endpoints))
+	for i := range endpoints {
+		response[i] = webhookDto.ToWebhookResponse(&endpoints[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *WebhooksHandler) GetByID(c *gin.Context) {
+	orgID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.Services.Webhooks.GetEndpoint(c.Request.Context(), orgID, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookDto.ToWebhookResponse(endpoint))
+}
+
+func (h *WebhooksHandler) Update(c *gin.Context) {
+	orgID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	var req webhookDto.UpdateWebhookRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	before, err := h.Services.Webhooks.GetEndpoint(c.Request.Context(), orgID, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	endpoint, err := h.Services.Webhooks.UpdateEndpoint(c.Request.Context(), orgID, id, req.URL, req.Events, *req.Enabled)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := webhookDto.ToWebhookResponse(endpoint)
+	setAuditTarget(c, AuditTarget{OrganizationID: orgID, Type: "webhook", ID: id.String(),
+		Diff: audit.Diff(webhookDto.ToWebhookResponse(before), response)})
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *WebhooksHandler) Delete(c *gin.Context) {
+	orgID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	before, err := h.Services.Webhooks.GetEndpoint(c.Request.Context(), orgID, id)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := h.Services.Webhooks.DeleteEndpoint(c.Request.Context(), orgID, id); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setAuditTarget(c, AuditTarget{OrganizationID: orgID, Type: "webhook", ID: id.String(),
+		Diff: audit.Diff(webhookDto.ToWebhookResponse(before), nil)})
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhooksHandler) GetDeliveries(c *gin.Context) {
+	orgID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	limit, ok := parseLimit(c, defaultDeliveriesLimit, maxDeliveriesLimit)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.Services.Webhooks.GetDeliveries(c.Request.Context(), orgID, id, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	response := make([]webhookDto.DeliveryResponse, len(deliveries))
+	for i := range deliveries {
+		response[i] = webhookDto.ToDeliveryResponse(&deliveries[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *WebhooksHandler) Redeliver(c *gin.Context) {
+	orgID, id, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	deliveryID, ok := uuidParam(c, "deliveryID")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.Services.Webhooks.Redeliver(c.Request.Context(), orgID, id, deliveryID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	setAuditTarget(c, AuditTarget{OrganizationID: orgID, Type: "webhook_delivery", ID: delivery.ID.String()})
+
+	c.JSON(http.StatusAccepted, webhookDto.ToDeliveryResponse(delivery))
+}
+
+// webhookParams parses the organization and webhook IDs of the path. On failure it records a validation error and returns false.
+func webhookParams(c *gin.Context) (orgID, id uuid.UUID, ok bool) {
+	if orgID, ok = uuidParam(c, "id"); !ok {
+		return
+	}
+	id, ok = uuidParam(c, "webhookID")
+	return
+}
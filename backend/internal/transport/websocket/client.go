@@ -1,12 +1,15 @@
 package websocket
 
 import (
-	"encoding/base64"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
+	"nonza/backend/internal/metrics"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -18,9 +21,6 @@ const (
 
 	// Send pings to peer with this period (must be less than pongWait)
 	pingPeriod = (pongWait * 9) / 10
-
-	// Maximum message size allowed from peer
-	maxMessageSize = 512 * 1024 // 512KB
 )
 
 // Client is a middleman between the websocket connection and the hub
@@ -30,20 +30,47 @@ type Client struct {
 	// The websocket connection
 	conn *websocket.Conn
 
-	// Buffered channel of outbound messages
-	send chan []byte
+	// Outbound frames, bounded by Config.SendQueueBytes; closed by the hub on unregister
+	queue *sendQueue
 
-	// Buffered channel of outbound binary messages
-	sendBinary chan []byte
+	// Set when queued document updates were dropped and the client was asked to resync;
+	// cleared by its next yjs_sync. A client that overflows again meanwhile is disconnected.
+	resyncing atomic.Bool
 
 	// Room ID this client is connected to
 	roomID string
 
 	// User/participant ID
 	userID string
+
+	// Address the client connected from, counted against Limits.MaxConnectionsPerIP
+	ip string
+
+	// Limits of the client's room and the rate limiters enforcing them; see applyLimits
+	limits      Limits
+	msgLimiter  *rate.Limiter
+	byteLimiter *rate.Limiter
+
+	// Logger carrying the request, room and user of this connection
+	log *slog.Logger
+
+	// Protocol version negotiated at connect
+	protocolVersion int
+
+	// Presence details announced at connect (name and role are the client's own claims)
+	displayName string
+	role        string
+	device      string
+	connectedAt time.Time
+	lastActive  atomic.Int64 // Unix nanos of the last message from the client
+
+	// Set when the client reconnected with the last sequence number it saw (?last_seq=);
+	// it is then resumed from lastSeq on registration instead of being sent the full document
+	resume  bool
+	lastSeq int64
 }
 
-// Message represents a WebSocket message
+// Message is an outbound WebSocket message; Payload is one of the payload types of the protocol registry
 type Message struct {
 	Type    string      `json:"type"`
 	RoomID  string      `json:"room_id,omitempty"`
@@ -54,88 +81,109 @@ type Message struct {
 // readPump pumps messages from the websocket connection to the hub
 func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		c.hub.leave(c)
 		c.conn.Close()
 	}()
 
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadLimit(int64(c.limits.MaxMessageBytes))
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
 		return nil
 	})
 
+	// Set once the client is being disconnected over a limit; its remaining messages are ignored
+	limited := false
+
 	for {
 		messageType, messageBytes, err := c.conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket error: %v", err)
+			if errors.Is(err, websocket.ErrReadLimit) {
+				// The connection has already been closed with 1009
+				metrics.WSLimitViolations.WithLabelValues(metrics.LimitMessageSize).Inc()
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				c.log.Warn("WebSocket read error", slog.Any("error", err))
 			}
 			break
 		}
-
-		// Handle binary messages (Y.js updates)
-		if messageType == websocket.BinaryMessage {
-			if c.roomID != "" {
-				c.hub.BroadcastBinaryToRoom(c.roomID, messageBytes, c)
-			}
+		if limited {
+			continue
+		}
+		if limit := c.allowMessage(len(messageBytes)); limit != "" {
+			c.closeForLimit(limit)
+			limited = true
 			continue
 		}
+		c.lastActive.Store(time.Now().UnixNano())
 
-		// Handle text messages (JSON)
-		// Check if message looks like binary data (starts with non-printable characters)
-		if len(messageBytes) > 0 && (messageBytes[0] < 32 && messageBytes[0] != 9 && messageBytes[0] != 10 && messageBytes[0] != 13) {
-			// This looks like binary data sent as text, treat it as binary
+		// Binary messages are raw Y.js updates: sequenced and logged like yjs_update, so clients
+		// resuming by last_seq don't miss them. Peers receive them as yjs_update.
+		if messageType == websocket.BinaryMessage {
+			metrics.WSBinaryMessages.Inc()
 			if c.roomID != "" {
-				c.hub.BroadcastBinaryToRoom(c.roomID, messageBytes, c)
+				c.hub.RelayDocumentUpdate(c.roomID, messageBytes, c)
 			}
 			continue
 		}
 
-		var message Message
-		if err := json.Unmarshal(messageBytes, &message); err != nil {
-			log.Printf("Error unmarshaling message (type: %d, length: %d, first byte: %x): %v", messageType, len(messageBytes), messageBytes[0], err)
+		// Text messages are JSON and must match the protocol registry
+		msgType, payload, err := decodeMessage(messageBytes)
+		if err != nil {
+			c.sendError(err)
 			continue
 		}
+		c.dispatch(msgType, payload)
+	}
+}
 
-		// Handle different message types
-		switch message.Type {
-		case "join_room":
-			c.handleJoinRoom(message)
-		case "leave_room":
-			c.handleLeaveRoom(message)
-		case "ping":
-			c.handlePing()
-		case "yjs_update":
-			// Handle Y.js update wrapped in JSON
-			if c.roomID != "" {
-				c.handleYjsUpdate(message)
-			}
-		case "yjs_sync":
-			// Handle Y.js sync request
-			if c.roomID != "" {
-				c.handleYjsSync(message)
-			}
-		case "yjs_full_state":
-			// Handle Y.js full document state (Y.encodeStateAsUpdate) — store and broadcast to room
-			if c.roomID != "" {
-				c.handleYjsFullState(message)
-			}
-		case "yjs_awareness":
-			// Handle Y.js awareness updates (cursor positions, user info) — broadcast only, don't store
-			if c.roomID != "" {
-				c.handleYjsAwareness(message)
-			}
-		default:
-			// Broadcast custom message to room
-			if c.roomID != "" {
-				c.hub.BroadcastToRoom(c.roomID, message)
-			}
-		}
+// dispatch runs the handler of a validated client message
+func (c *Client) dispatch(msgType string, payload any) {
+	if msgType != TypeJoinRoom && msgType != TypePing && c.roomID == "" {
+		// Everything else acts on the current room
+		return
+	}
+
+	switch msgType {
+	case TypeJoinRoom:
+		c.handleJoinRoom(payload.(*RoomPayload))
+	case TypeLeaveRoom:
+		c.handleLeaveRoom()
+	case TypePing:
+		c.handlePing()
+	case TypeYjsUpdate:
+		c.handleYjsUpdate(payload.(*UpdatePayload))
+	case TypeYjsSync:
+		c.handleYjsSync()
+	case TypeResume:
+		c.handleResume(payload.(*ResumePayload))
+	case TypeYjsFullState:
+		// Y.js full document state (Y.encodeStateAsUpdate) — store and broadcast to room
+		c.handleYjsFullState(payload.(*UpdatePayload))
+	case TypeYjsAwareness:
+		// Y.js awareness updates (cursor positions, user info) — broadcast only, don't store
+		c.handleYjsAwareness(payload.(*UpdatePayload))
+	}
+}
+
+// sendError reports a rejected message to the client in an error frame
+func (c *Client) sendError(err error) {
+	var perr *protocolError
+	if !errors.As(err, &perr) {
+		perr = &protocolError{code: ErrCodeMalformed, message: err.Error()}
 	}
+	metrics.WSRejectedMessages.WithLabelValues(perr.code).Inc()
+	c.log.Debug("Rejected client message", slog.String("code", perr.code), slog.String("type", perr.msgType), slog.String("reason", perr.message))
+
+	data, _ := json.Marshal(Message{
+		Type:    TypeError,
+		RoomID:  c.roomID,
+		Payload: ErrorPayload{Code: perr.code, Message: perr.message, Type: perr.msgType},
+	})
+	c.sendText(data)
 }
 
-// writePump pumps messages from the hub to the websocket connection
+// writePump pumps messages from the hub to the websocket connection.
+// Consecutive text messages are joined with newlines into a single frame.
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
@@ -145,56 +193,18 @@ func (c *Client) writePump() {
 
 	for {
 		select {
-		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				// The hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+		case <-c.queue.ready:
+			frames, closed, closeMsg := c.queue.take()
+			if err := c.writeFrames(frames); err != nil {
+				c.log.Warn("Failed to write to websocket", slog.Any("error", err))
 				return
 			}
-
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
+			if closed {
+				// The hub closed the queue
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				c.conn.WriteMessage(websocket.CloseMessage, closeMsg)
 				return
 			}
-			w.Write(message)
-
-			// Add queued messages to the current websocket message
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
-			}
-
-			if err := w.Close(); err != nil {
-				return
-			}
-
-		case binaryMessage, ok := <-c.sendBinary:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				// The hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
-
-			log.Printf("Sending binary Y.js update to client %s (size: %d bytes)", c.userID, len(binaryMessage))
-			if err := c.conn.WriteMessage(websocket.BinaryMessage, binaryMessage); err != nil {
-				log.Printf("Error sending binary message to client %s: %v", c.userID, err)
-				return
-			}
-			log.Printf("Successfully sent binary Y.js update to client %s", c.userID)
-
-			// Send any queued binary messages
-			n := len(c.sendBinary)
-			for i := 0; i < n; i++ {
-				queuedMsg := <-c.sendBinary
-				log.Printf("Sending queued binary Y.js update to client %s (size: %d bytes)", c.userID, len(queuedMsg))
-				if err := c.conn.WriteMessage(websocket.BinaryMessage, queuedMsg); err != nil {
-					log.Printf("Error sending queued binary message to client %s: %v", c.userID, err)
-					return
-				}
-			}
 
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
@@ -205,248 +215,142 @@ func (c *Client) writePump() {
 	}
 }
 
-// handleJoinRoom handles a join room message
-func (c *Client) handleJoinRoom(message Message) {
-	if roomID, ok := message.Payload.(map[string]interface{})["room_id"].(string); ok {
-		// Remove from old room
-		if c.roomID != "" && c.roomID != roomID {
-			c.hub.mu.Lock()
-			if roomClients, ok := c.hub.rooms[c.roomID]; ok {
-				delete(roomClients, c)
+// writeFrames writes frames in order, batching runs of text messages into one frame
+func (c *Client) writeFrames(frames []frame) error {
+	for i := 0; i < len(frames); i++ {
+		c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+		if frames[i].kind == websocket.BinaryMessage {
+			if err := c.conn.WriteMessage(websocket.BinaryMessage, frames[i].data); err != nil {
+				return err
 			}
-			c.hub.mu.Unlock()
+			c.log.Debug("Sent binary Y.js update", slog.Int("bytes", len(frames[i].data)))
+			continue
 		}
 
-		// Add to new room
-		c.roomID = roomID
-		c.hub.mu.Lock()
-		if c.hub.rooms[roomID] == nil {
-			c.hub.rooms[roomID] = make(map[*Client]bool)
+		w, err := c.conn.NextWriter(websocket.TextMessage)
+		if err != nil {
+			return err
+		}
+		w.Write(frames[i].data)
+		for i+1 < len(frames) && frames[i+1].kind == websocket.TextMessage {
+			i++
+			w.Write([]byte{'\n'})
+			w.Write(frames[i].data)
+		}
+		if err := w.Close(); err != nil {
+			return err
 		}
-		c.hub.rooms[roomID][c] = true
-		c.hub.mu.Unlock()
-
-		// Notify others in the room
-		c.hub.BroadcastToRoom(roomID, Message{
-			Type:   "user_joined",
-			RoomID: roomID,
-			UserID: c.userID,
-			Payload: map[string]interface{}{
-				"user_id": c.userID,
-				"room_id": roomID,
-			},
-		})
 	}
+	return nil
 }
 
-// handleLeaveRoom handles a leave room message
-func (c *Client) handleLeaveRoom(message Message) {
-	if c.roomID != "" {
-		roomID := c.roomID
-		c.roomID = ""
-
-		// Notify others in the room
-		c.hub.BroadcastToRoom(roomID, Message{
-			Type:   "user_left",
-			RoomID: roomID,
-			UserID: c.userID,
-			Payload: map[string]interface{}{
-				"user_id": c.userID,
-				"room_id": roomID,
-			},
-		})
+// handleJoinRoom handles a join room message
+func (c *Client) handleJoinRoom(payload *RoomPayload) {
+	if payload.RoomID != c.roomID {
+		c.hub.moveClient(c, payload.RoomID)
+		c.conn.SetReadLimit(int64(c.limits.MaxMessageBytes))
 	}
 }
 
+// handleLeaveRoom handles a leave room message
+func (c *Client) handleLeaveRoom() {
+	c.hub.moveClient(c, "")
+}
+
 // handlePing handles a ping message
 func (c *Client) handlePing() {
-	response := Message{
-		Type: "pong",
-	}
-	data, _ := json.Marshal(response)
-	c.send <- data
+	data, _ := json.Marshal(Message{Type: TypePong})
+	c.sendText(data)
 }
 
 // handleYjsUpdate handles Y.js update messages wrapped in JSON
-func (c *Client) handleYjsUpdate(message Message) {
-	log.Printf("Received Y.js update from client %s in room %s", c.userID, c.roomID)
-
-	// Extract base64 encoded update from payload
-	payload, ok := message.Payload.(map[string]interface{})
-	if !ok {
-		// Try to get update directly from message
-		if updateBase64, ok := message.Payload.(string); ok {
-			// Decode base64 to binary
-			updateBytes, err := base64.StdEncoding.DecodeString(updateBase64)
-			if err != nil {
-				log.Printf("Error decoding Y.js update: %v", err)
-				return
-			}
-			log.Printf("Broadcasting Y.js update (size: %d bytes) to room %s", len(updateBytes), c.roomID)
-			c.hub.BroadcastBinaryToRoom(c.roomID, updateBytes, c)
-			return
-		}
-		log.Printf("Invalid payload format for Y.js update: %T", message.Payload)
-		return
-	}
-
-	updateBase64, ok := payload["update"].(string)
-	if !ok {
-		log.Printf("Update field not found in payload, available keys: %v", getMapKeys(payload))
-		return
-	}
-
-	// Decode base64 to binary
-	updateBytes, err := base64.StdEncoding.DecodeString(updateBase64)
-	if err != nil {
-		log.Printf("Error decoding Y.js update: %v", err)
-		return
-	}
-
-	log.Printf("Broadcasting Y.js update (size: %d bytes) to room %s", len(updateBytes), c.roomID)
-	// Broadcast binary update to room (excluding sender)
-	c.hub.BroadcastBinaryToRoom(c.roomID, updateBytes, c)
-}
-
-// Helper function to get map keys for logging
-func getMapKeys(m map[string]interface{}) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	return keys
+func (c *Client) handleYjsUpdate(payload *UpdatePayload) {
+	c.log.Debug("Received Y.js update", slog.Int("bytes", len(payload.data)))
+	// Sequence the update and relay it to the room (excluding sender)
+	c.hub.RelayDocumentUpdate(c.roomID, payload.data, c)
 }
 
-// Helper function to get value length for logging
-func getValueLength(v interface{}) int {
-	if str, ok := v.(string); ok {
-		return len(str)
-	}
-	return 0
+// handleYjsSync handles Y.js sync requests
+func (c *Client) handleYjsSync() {
+	c.log.Debug("Received Y.js sync request")
+	c.resyncing.Store(false)
+	c.sendSyncAck(TypeYjsSyncAck, c.sync(0, false))
 }
 
-// Helper function to get first N characters of a string
-func getFirstChars(s string, n int) string {
-	if len(s) <= n {
-		return s
-	}
-	return s[:n]
+// handleResume handles a request for the updates after the last sequence number the client has seen
+func (c *Client) handleResume(payload *ResumePayload) {
+	c.log.Debug("Received resume request", slog.Int64("last_seq", payload.LastSeq))
+	c.resyncing.Store(false)
+	c.sendSyncAck(TypeResumeAck, c.sync(payload.LastSeq, true))
 }
 
-// handleYjsSync handles Y.js sync requests
-func (c *Client) handleYjsSync(message Message) {
-	log.Printf("Received Y.js sync request from client %s in room %s", c.userID, c.roomID)
+// sync sends the client the document state or updates it is missing
+func (c *Client) sync(lastSeq int64, resume bool) syncAck {
+	ctx, cancel := c.hub.operationContext()
+	defer cancel()
 
 	// Check if room has expired
-	if c.hub.isRoomExpired(c.roomID) {
-		log.Printf("Room %s has expired, not syncing document", c.roomID)
-		c.hub.redisClient.DeleteDocumentState(c.roomID)
-		c.sendSyncAck(false)
-		return
+	if c.hub.isRoomExpired(ctx, c.roomID) {
+		c.log.Info("Room has expired, not syncing document")
+		return syncAck{}
 	}
 
-	// Get document state from Redis
-	docState, err := c.hub.redisClient.GetDocumentState(c.roomID)
+	ack, err := c.hub.syncClient(ctx, c, lastSeq, resume)
 	if err != nil {
-		log.Printf("Error loading document state from Redis for room %s: %v", c.roomID, err)
-		c.sendSyncAck(false)
-		return
-	}
-
-	hasState := docState != nil && len(docState) > 0
-	if hasState {
-		select {
-		case c.sendBinary <- docState:
-			log.Printf("Sent document state to client %s (size: %d bytes)", c.userID, len(docState))
-		default:
-			log.Printf("Failed to send document state to client %s: channel full", c.userID)
-		}
+		c.log.Error("Failed to sync document state", slog.Any("error", err))
+		return syncAck{}
 	}
-
-	c.sendSyncAck(hasState)
+	return ack
 }
 
-// sendSyncAck sends sync acknowledgment message
-func (c *Client) sendSyncAck(synced bool) {
+// sendSyncAck sends a sync (yjs_sync_ack) or resume (resume_ack) acknowledgment
+func (c *Client) sendSyncAck(messageType string, ack syncAck) {
 	response := Message{
-		Type:   "yjs_sync_ack",
+		Type:   messageType,
 		RoomID: c.roomID,
-		Payload: map[string]interface{}{
-			"synced": synced,
+		Payload: SyncAckPayload{
+			Synced:    ack.HasState,
+			Seq:       ack.Seq,
+			Replayed:  ack.Replayed,
+			FullState: ack.FullState,
 		},
 	}
 	data, _ := json.Marshal(response)
-	c.send <- data
+	c.sendText(data)
 }
 
 // handleYjsFullState handles Y.js full document state (Y.encodeStateAsUpdate).
 // Stores it for new joiners and broadcasts to all in room (excluding sender).
-func (c *Client) handleYjsFullState(message Message) {
-	payload, ok := message.Payload.(map[string]interface{})
-	if !ok {
-		log.Printf("Invalid payload for yjs_full_state: %T", message.Payload)
+// The state includes every update up to payload.Seq, the last sequence number the client had seen.
+func (c *Client) handleYjsFullState(payload *UpdatePayload) {
+	c.log.Debug("Received Y.js full state", slog.Int("bytes", len(payload.data)), slog.Int64("seq", payload.Seq))
+	if len(payload.data) > c.limits.MaxDocumentBytes {
+		c.closeForLimit(metrics.LimitDocumentSize)
 		return
 	}
-	updateBase64, ok := payload["update"].(string)
-	if !ok {
-		log.Printf("Update field not found in yjs_full_state payload")
-		return
-	}
-	updateBytes, err := base64.StdEncoding.DecodeString(updateBase64)
-	if err != nil {
-		log.Printf("Error decoding yjs_full_state: %v", err)
-		return
-	}
-	log.Printf("Received Y.js full state from client %s in room %s (size: %d bytes)", c.userID, c.roomID, len(updateBytes))
 	// Store in Redis - this will also reset the TTL
-	c.hub.StoreRoomDocumentState(c.roomID, updateBytes)
-	c.hub.BroadcastBinaryToRoom(c.roomID, updateBytes, c)
+	c.hub.StoreRoomDocumentState(c.roomID, payload.data, payload.Seq)
+	c.hub.RelayDocumentUpdate(c.roomID, payload.data, c)
 }
 
 // handleYjsAwareness handles Y.js awareness updates (cursor positions, user info).
 // Broadcasts to room but does NOT store (awareness is ephemeral).
-func (c *Client) handleYjsAwareness(message Message) {
-	log.Printf("[handleYjsAwareness] Received yjs_awareness from client %s in room %s", c.userID, c.roomID)
-	log.Printf("[handleYjsAwareness] Message payload type: %T", message.Payload)
-
-	// Extract base64 encoded awareness update
-	var updateBase64 string
-	if updateStr, ok := message.Payload.(string); ok {
-		log.Printf("[handleYjsAwareness] Payload is string, length: %d", len(updateStr))
-		updateBase64 = updateStr
-	} else if payload, ok := message.Payload.(map[string]interface{}); ok {
-		log.Printf("[handleYjsAwareness] Payload is map, keys: %v", getMapKeys(payload))
-		updateValue, exists := payload["update"]
-		if !exists {
-			log.Printf("[handleYjsAwareness] Update field does not exist in payload map")
-		} else {
-			log.Printf("[handleYjsAwareness] Update field exists, type: %T, value length: %d", updateValue, getValueLength(updateValue))
-			if updateStr, ok := updateValue.(string); ok {
-				log.Printf("[handleYjsAwareness] Update is string, length: %d, first 50 chars: %q", len(updateStr), getFirstChars(updateStr, 50))
-				updateBase64 = updateStr
-			} else {
-				log.Printf("[handleYjsAwareness] Update is not a string, actual type: %T", updateValue)
-			}
-		}
-	} else {
-		log.Printf("[handleYjsAwareness] Unknown payload type: %T", message.Payload)
-	}
-
-	if updateBase64 == "" {
-		log.Printf("[handleYjsAwareness] Update field not found in yjs_awareness payload (empty after extraction)")
-		log.Printf("[handleYjsAwareness] Skipping empty awareness update")
+func (c *Client) handleYjsAwareness(payload *UpdatePayload) {
+	if len(payload.data) == 0 {
+		c.log.Debug("Skipping empty awareness update")
 		return
 	}
 
-	log.Printf("[handleYjsAwareness] Decoding base64 update, length: %d", len(updateBase64))
-	updateBytes, err := base64.StdEncoding.DecodeString(updateBase64)
-	if err != nil {
-		log.Printf("[handleYjsAwareness] Error decoding base64: %v", err)
-		return
-	}
-	log.Printf("[handleYjsAwareness] Successfully decoded, binary size: %d bytes", len(updateBytes))
-
-	log.Printf("Broadcasting Y.js awareness update from client %s in room %s (size: %d bytes)", c.userID, c.roomID, len(updateBytes))
+	c.log.Debug("Received Y.js awareness update", slog.Int("bytes", len(payload.data)))
 	// Broadcast awareness update as binary (don't store - awareness is ephemeral)
-	c.hub.BroadcastBinaryToRoom(c.roomID, updateBytes, c)
+	c.hub.BroadcastBinaryToRoom(c.roomID, payload.data, c)
+}
+
+// lastActiveAt returns when the client last sent a message (its connection time if it hasn't)
+func (c *Client) lastActiveAt() time.Time {
+	if nanos := c.lastActive.Load(); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return c.connectedAt
 }
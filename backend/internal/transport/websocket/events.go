@@ -0,0 +1,198 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"nonza/backend/internal/service/domainevents"
+
+	"github.com/google/uuid"
+)
+
+// documentEventInterval is the least time between two document.updated events of a room
+const documentEventInterval = 10 * time.Second
+
+// EventPublisher puts domain events on the event bus read by the SSE stream, webhooks and other subscribers
+type EventPublisher interface {
+	Publish(ctx context.Context, events ...domainevents.Event) error
+}
+
+// publishEvent publishes a domain event of the room in the background
+func (h *Hub) publishEvent(roomID, eventType string, data any) {
+	if h.events == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := h.operationContext()
+		defer cancel()
+		if err := h.publish(ctx, roomID, eventType, data); err != nil {
+			h.log.Warn("Failed to publish room event", slog.String("room_id", roomID), slog.String("type", eventType), slog.Any("error", err))
+		}
+	}()
+}
+
+func (h *Hub) publish(ctx context.Context, roomID, eventType string, data any) error {
+	roomUUID, err := uuid.Parse(roomID)
+	if err != nil {
+		return err
+	}
+	// The hub doesn't know the organization; subscribers that need it look the room up
+	event, err := domainevents.NewEvent(eventType, roomUUID, uuid.Nil, data)
+	if err != nil {
+		return err
+	}
+	return h.events.Publish(ctx, event)
+}
+
+// publishParticipantEvents announces the users a presence diff says joined or left the room
+func (h *Hub) publishParticipantEvents(roomID string, diff PresenceDiffPayload) {
+	for _, entry := range diff.Joined {
+		h.publishEvent(roomID, domainevents.ParticipantJoined, domainevents.ParticipantPayload{
+			UserID:      entry.UserID,
+			DisplayName: entry.DisplayName,
+			Role:        entry.Role,
+		})
+	}
+	for _, userID := range diff.Left {
+		h.publishEvent(roomID, domainevents.ParticipantLeft, domainevents.ParticipantPayload{UserID: userID})
+	}
+}
+
+// documentUpdated publishes document.updated for the first update of a burst: at most once per
+// documentEventInterval per room, across instances
+func (h *Hub) documentUpdated(roomID string, seq int64) {
+	if h.events == nil {
+		return
+	}
+	now := time.Now()
+	if last, ok := h.documentEvents.Load(roomID); ok && now.Sub(last.(time.Time)) < documentEventInterval {
+		return
+	}
+	h.documentEvents.Store(roomID, now)
+
+	go func() {
+		ctx, cancel := h.operationContext()
+		defer cancel()
+
+		if h.redisClient != nil {
+			first, err := h.redisClient.MarkRoomEventOnce(ctx, roomID, domainevents.DocumentUpdated, documentEventInterval)
+			if err != nil || !first {
+				if err != nil {
+					h.log.Warn("Failed to throttle document events", slog.String("room_id", roomID), slog.Any("error", err))
+				}
+				return
+			}
+		}
+
+		if err := h.publish(ctx, roomID, domainevents.DocumentUpdated, domainevents.DocumentUpdatedPayload{Seq: seq}); err != nil {
+			h.log.Warn("Failed to publish room event", slog.String("room_id", roomID), slog.String("type", domainevents.DocumentUpdated), slog.Any("error", err))
+		}
+	}()
+}
+
+// BroadcastEvents are the domain events the hub of every instance acts on for its own clients
+var BroadcastEvents = []string{domainevents.TokenIssued, domainevents.RoomUpdated, domainevents.RoomDeleted}
+
+// Broadcast is the event bus subscriber that hands BroadcastEvents to the hubs of all instances.
+// The outbox gives an event to one instance only, while the room's clients may be connected to
+// any of them, so the event goes on to every hub over Redis pub/sub.
+func (h *Hub) Broadcast(ctx context.Context, event domainevents.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return h.redisClient.PublishHubEvent(ctx, data)
+}
+
+// listenBroadcasts acts on the events handed out by Broadcast until the hub stops
+func (h *Hub) listenBroadcasts() {
+	ctx, cancel := context.WithCancel(h.ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-h.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for data := range h.redisClient.SubscribeHubEvents(ctx) {
+		var event domainevents.Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			h.log.Warn("Malformed hub event", slog.Any("error", err))
+			continue
+		}
+		if err := h.handleBroadcast(event); err != nil {
+			h.log.Warn("Failed to handle hub event", slog.String("type", event.Type),
+				slog.String("room_id", event.RoomID.String()), slog.Any("error", err))
+		}
+	}
+}
+
+func (h *Hub) handleBroadcast(event domainevents.Event) error {
+	switch event.Type {
+	case domainevents.TokenIssued:
+		return h.announceParticipant(event)
+	case domainevents.RoomUpdated:
+		return h.announceRoomUpdate(event)
+	case domainevents.RoomDeleted:
+		return h.closeDeletedRoom(event)
+	}
+	return nil
+}
+
+// announceParticipant tells the room's clients on this instance that a participant is about to
+// join the call
+func (h *Hub) announceParticipant(event domainevents.Event) error {
+	var payload domainevents.TokenIssuedPayload
+	if err := json.Unmarshal(event.Data, &payload); err != nil {
+		return err
+	}
+
+	// Websocket rooms are keyed by the room's UUID
+	roomID := event.RoomID.String()
+	return h.BroadcastToRoom(roomID, Message{
+		Type:   TypeParticipantJoining,
+		RoomID: roomID,
+		Payload: ParticipantJoiningPayload{
+			ParticipantID:   payload.ParticipantID,
+			ParticipantName: payload.ParticipantName,
+		},
+	})
+}
+
+// announceRoomUpdate tells the room's clients on this instance about the new settings, e.g.
+// that its expiry moved
+func (h *Hub) announceRoomUpdate(event domainevents.Event) error {
+	var payload domainevents.RoomUpdatedPayload
+	if err := json.Unmarshal(event.Data, &payload); err != nil {
+		return err
+	}
+
+	roomID := event.RoomID.String()
+	return h.BroadcastToRoom(roomID, Message{
+		Type:   TypeRoomUpdated,
+		RoomID: roomID,
+		Payload: RoomUpdatedPayload{
+			Name:        payload.Name,
+			Slug:        payload.Slug,
+			RoomType:    payload.RoomType,
+			IsTemporary: payload.IsTemporary,
+			ExpiresAt:   payload.ExpiresAt,
+			E2EEEnabled: payload.E2EEEnabled,
+			Changed:     payload.Changed,
+		},
+	})
+}
+
+// closeDeletedRoom disconnects the deleted room's clients on this instance
+func (h *Hub) closeDeletedRoom(event domainevents.Event) error {
+	var payload domainevents.RoomDeletedPayload
+	if err := json.Unmarshal(event.Data, &payload); err != nil {
+		return err
+	}
+	h.CloseRoom(event.RoomID.String(), payload.Reason)
+	return nil
+}
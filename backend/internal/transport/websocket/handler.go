@@ -2,8 +2,12 @@ package websocket
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
+
+	"nonza/backend/internal/logger"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -47,25 +51,75 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
+	// A reconnecting client passes the last update sequence number it saw to resume from there
+	var lastSeq int64
+	resume := false
+	if v := c.Query("last_seq"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "last_seq must be a non-negative integer"})
+			return
+		}
+		lastSeq, resume = n, true
+	}
+
+	subprotocol, version, ok := negotiateProtocol(c.Request)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":                  "unsupported protocol version",
+			"supported_subprotocols": supportedSubprotocols(),
+		})
+		return
+	}
+
+	if h.hub.closing.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+		return
+	}
+
+	limits := h.hub.roomLimits(c.Request.Context(), roomID)
+
 	// Upgrade connection to WebSocket
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	var responseHeader http.Header
+	if subprotocol != "" {
+		responseHeader = http.Header{"Sec-Websocket-Protocol": {subprotocol}}
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, responseHeader)
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		h.hub.log.Warn("WebSocket upgrade failed", slog.Any("error", err))
 		return
 	}
 
 	// Create new client
 	client := &Client{
-		hub:        h.hub,
-		conn:       conn,
-		send:       make(chan []byte, 256),
-		sendBinary: make(chan []byte, 256),
-		roomID:     roomID,
-		userID:     userID,
+		hub:             h.hub,
+		conn:            conn,
+		queue:           newSendQueue(h.hub.cfg.SendQueueBytes),
+		roomID:          roomID,
+		userID:          userID,
+		resume:          resume,
+		protocolVersion: version,
+		displayName:     normalizeDisplayName(c.Query("name")),
+		role:            normalizeRole(c.Query("role")),
+		device:          deviceFromUserAgent(c.Request.UserAgent()),
+		connectedAt:     time.Now(),
+		lastSeq:         lastSeq,
+		ip:              c.ClientIP(),
+		log: h.hub.log.With(
+			slog.String("request_id", logger.RequestID(c)),
+			slog.String("room_id", roomID),
+			slog.String("user_id", userID),
+		),
 	}
 
+	client.applyLimits(limits)
+
 	// Register client
-	client.hub.register <- client
+	if !client.hub.join(client) {
+		conn.WriteMessage(websocket.CloseMessage, shutdownCloseMessage)
+		conn.Close()
+		return
+	}
 
 	// Start client goroutines
 	go client.writePump()
@@ -73,16 +127,22 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 
 	// Send welcome message
 	welcomeMsg := Message{
-		Type:   "connected",
+		Type:   TypeConnected,
 		RoomID: roomID,
 		UserID: userID,
-		Payload: map[string]interface{}{
-			"message": "Connected to WebSocket server",
-			"room_id": roomID,
+		Payload: ConnectedPayload{
+			Message:         "Connected to WebSocket server",
+			RoomID:          roomID,
+			ProtocolVersion: client.protocolVersion,
 		},
 	}
 	data, _ := json.Marshal(welcomeMsg)
-	client.send <- data
+	client.sendText(data)
+}
+
+// HandleSchema serves the JSON Schema of the websocket protocol
+func (h *Handler) HandleSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, Schema())
 }
 
 // BroadcastEvent allows sending custom events to a room
@@ -1,131 +1,196 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"nonza/backend/internal/metrics"
 	"nonza/backend/internal/repository"
 	"nonza/backend/internal/repository/redis"
+
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
+var tracer = otel.Tracer("nonza/backend/internal/transport/websocket")
+
+// expiredDocumentGrace is how long document state outlives its room's expiration in Redis
+const expiredDocumentGrace = 24 * time.Hour
+
+// operationTimeout bounds each Redis/Postgres call the hub makes on behalf of a client
+const operationTimeout = 5 * time.Second
+
+// heartbeatInterval is how often the Run loop proves it is alive; CheckAlive fails after three missed beats
+const heartbeatInterval = 5 * time.Second
+
+// defaultSendQueueBytes bounds each client's outbound queue when Config leaves it unset
+const defaultSendQueueBytes = 4 << 20
+
+// Defaults for the replay log when Config leaves it unset
+const (
+	defaultReplayBufferSize = 500
+	defaultReplayTTL        = time.Hour
+)
+
+// Config tunes per-connection limits of the hub
+type Config struct {
+	// SendQueueBytes caps the bytes queued to a single client before it is treated as a slow consumer
+	SendQueueBytes int
+
+	// ReplayBufferSize is how many recent document updates per room are kept for clients resuming
+	// after a reconnect; ReplayTTL is how long the log outlives the room's last update
+	ReplayBufferSize int
+	ReplayTTL        time.Duration
+
+	// Limits bounds inbound traffic per connection, clients per room and connections per address
+	Limits Limits
+}
+
 // Hub maintains the set of active clients and broadcasts messages to the clients
 type Hub struct {
-	clients     map[*Client]bool           // All registered clients
-	broadcast   chan []byte                 // Broadcast channel for all clients
-	register    chan *Client                // Channel for client registration
-	unregister  chan *Client                // Channel for client unregistration
-	rooms       map[string]map[*Client]bool // Room-based clients (roomID -> clients)
-	redisClient *redis.Client               // Redis client for document state storage
-	roomsRepo   repository.Rooms            // Repository for checking room expiration
-	mu          sync.RWMutex                // Mutex for thread-safe access
+	clients        map[*Client]bool                    // All registered clients
+	broadcast      chan []byte                         // Broadcast channel for all clients
+	register       chan *Client                        // Channel for client registration
+	unregister     chan *Client                        // Channel for client unregistration
+	rooms          map[string]map[*Client]bool         // Room-based clients (roomID -> clients)
+	presence       map[string]map[string]*userPresence // Users per room (roomID -> userID -> presence)
+	instance       string                              // Identifies this instance's presence records in Redis
+	redisClient    *redis.Client                       // Redis client for document state storage
+	roomsRepo      repository.Rooms                    // Repository for checking room expiration
+	orgsRepo       repository.Organizations            // Organizations, for their websocket limit overrides
+	ipConns        map[string]int                      // Connections per client address
+	snapshots      repository.DocumentSnapshots        // Durable copies of document state, read when Redis has lost it
+	events         EventPublisher                      // Domain event bus for SSE, webhooks and other integrations (optional)
+	documentEvents sync.Map                            // roomID -> time of the last document.updated event
+	mu             sync.RWMutex                        // Mutex for thread-safe access
+	heartbeat      atomic.Int64                        // Unix nanos of the last Run loop iteration
+	ctx            context.Context                     // Parent of hub operations; cancelled on shutdown
+	cfg            Config
+	log            *slog.Logger
+
+	closing atomic.Bool  // Set by Shutdown; new connections are refused
+	storeMu sync.RWMutex // Held for reading by document stores, for writing by the shutdown flush
+
+	relayLocks [relayLockStripes]sync.Mutex // See roomLock
+	quit       chan struct{}                // Closed by Shutdown to stop the Run loop
+	stopped    chan struct{}                // Closed when the Run loop has returned
 }
 
 // NewHub creates a new Hub with Redis support
-func NewHub(redisClient *redis.Client, roomsRepo repository.Rooms) *Hub {
+func NewHub(ctx context.Context, cfg Config, redisClient *redis.Client, roomsRepo repository.Rooms, orgsRepo repository.Organizations, snapshots repository.DocumentSnapshots, events EventPublisher, log *slog.Logger) *Hub {
+	if cfg.SendQueueBytes <= 0 {
+		cfg.SendQueueBytes = defaultSendQueueBytes
+	}
+	if cfg.ReplayBufferSize <= 0 {
+		cfg.ReplayBufferSize = defaultReplayBufferSize
+	}
+	if cfg.ReplayTTL <= 0 {
+		cfg.ReplayTTL = defaultReplayTTL
+	}
+	cfg.Limits = cfg.Limits.withDefaults()
+
 	return &Hub{
 		clients:     make(map[*Client]bool),
 		broadcast:   make(chan []byte, 256),
 		register:    make(chan *Client),
 		unregister:  make(chan *Client),
 		rooms:       make(map[string]map[*Client]bool),
+		presence:    make(map[string]map[string]*userPresence),
+		instance:    instanceID(),
 		redisClient: redisClient,
 		roomsRepo:   roomsRepo,
+		orgsRepo:    orgsRepo,
+		ipConns:     make(map[string]int),
+		snapshots:   snapshots,
+		events:      events,
+		ctx:         ctx,
+		cfg:         cfg,
+		quit:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		log:         log.With(slog.String("component", "websocket_hub")),
 	}
 }
 
-// Run starts the hub
+// Run starts the hub and returns once Shutdown has disconnected every client
 func (h *Hub) Run() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	defer close(h.stopped)
+	h.heartbeat.Store(time.Now().UnixNano())
+
+	go h.refreshPresence()
+	go h.listenBroadcasts()
+
 	for {
 		select {
+		case <-h.quit:
+			return
+
+		case <-ticker.C:
+			h.heartbeat.Store(time.Now().UnixNano())
+
 		case client := <-h.register:
 			h.mu.Lock()
+			if limit := h.admitLocked(client); limit != "" {
+				h.mu.Unlock()
+				client.closeForLimit(limit)
+				continue
+			}
 			h.clients[client] = true
-			var userJoinedMsg map[string]interface{}
+			var joined PresenceDiffPayload
 			if client.roomID != "" {
-				if h.rooms[client.roomID] == nil {
-					h.rooms[client.roomID] = make(map[*Client]bool)
-				}
-				h.rooms[client.roomID][client] = true
-
-				// Prepare user_joined message; send after unlock to avoid deadlock (broadcastToRoomExcluding takes RLock)
-				userJoinedMsg = map[string]interface{}{
-					"type":    "user_joined",
-					"room_id": client.roomID,
-					"user_id": client.userID,
-					"payload": map[string]interface{}{
-						"user_id": client.userID,
-						"room_id": client.roomID,
-					},
-				}
+				// Publish the diff after unlock to avoid deadlock (broadcastToRoomExcluding takes RLock)
+				joined = h.joinRoomLocked(client, client.roomID)
 
 				// Load document state from Redis for new client (async, doesn't block registration)
-				go h.loadDocumentForClient(client)
+				if client.resume {
+					go client.sendSyncAck(TypeResumeAck, client.sync(client.lastSeq, true))
+				} else {
+					go h.loadDocumentForClient(client)
+				}
 			}
 			h.mu.Unlock()
 
-			// Notify existing participants (outside lock to avoid deadlock)
-			if userJoinedMsg != nil {
-				h.broadcastToRoomExcluding(client.roomID, userJoinedMsg, client)
+			// Send the roster and notify existing participants (outside lock to avoid deadlock)
+			if client.roomID != "" {
+				h.sendRoster(client)
+				h.publishPresence(client.roomID, joined, client)
 			}
-			log.Printf("Client registered. Total clients: %d", len(h.clients))
+			client.log.Info("Client registered", slog.Int("total_clients", h.GetTotalClientsCount()))
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			roomID := client.roomID
-			userID := client.userID
+			var roomID string
+			var left PresenceDiffPayload
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
-				close(client.send)
-				close(client.sendBinary)
-				if roomID != "" {
-					if roomClients, ok := h.rooms[roomID]; ok {
-						delete(roomClients, client)
-						if len(roomClients) == 0 {
-							delete(h.rooms, roomID)
-							// Note: Document cleanup for expired rooms is handled by cron job
-						}
-					}
-				}
+				h.releaseLocked(client)
+				client.queue.close(nil)
+				roomID, left = h.leaveRoomLocked(client)
 			}
 			h.mu.Unlock()
-			log.Printf("Client unregistered. Total clients: %d", len(h.clients))
-			
+			client.log.Info("Client unregistered", slog.Int("total_clients", h.GetTotalClientsCount()))
+
 			// Notify other clients in the room about disconnection
 			// This helps them clean up stale awareness states
 			if roomID != "" {
-				h.broadcastToRoomExcluding(roomID, Message{
-					Type:   "user_left",
-					RoomID: roomID,
-					UserID: userID,
-					Payload: map[string]interface{}{
-						"user_id": userID,
-						"room_id": roomID,
-					},
-				}, nil)
+				h.publishPresence(roomID, left, nil)
 			}
 
 		case message := <-h.broadcast:
-			h.mu.RLock()
-			for client := range h.clients {
-				// Use goroutine with recover to safely send to potentially closed channel
-				go func(cli *Client) {
-					defer func() {
-						if r := recover(); r != nil {
-							log.Printf("Panic recovered while broadcasting to client %s: %v", cli.userID, r)
-						}
-					}()
-					select {
-					case cli.send <- message:
-					default:
-						// Channel is full or closed, skip this client
-						log.Printf("Broadcast channel full or closed for client %s", cli.userID)
-					}
-				}(client)
+			for _, client := range h.snapshotClients() {
+				client.sendText(message)
 			}
-			h.mu.RUnlock()
 		}
 	}
 }
@@ -137,181 +202,186 @@ func (h *Hub) BroadcastToRoom(roomID string, message interface{}) error {
 
 // broadcastToRoomExcluding sends a message to all clients in the room except excludeClient (nil = no exclude)
 func (h *Hub) broadcastToRoomExcluding(roomID string, message interface{}, excludeClient *Client) error {
-	h.mu.RLock()
-	roomClients, ok := h.rooms[roomID]
-	if !ok {
-		h.mu.RUnlock()
+	recipients := h.roomRecipients(roomID, excludeClient)
+	if len(recipients) == 0 {
 		return nil
 	}
 	data, err := json.Marshal(message)
 	if err != nil {
-		h.mu.RUnlock()
 		return err
 	}
+	for _, client := range recipients {
+		client.sendText(data)
+	}
+	return nil
+}
+
+// roomRecipients returns the room's clients except excludeClient. Queuing happens after the
+// lock is released: a queue closed by unregister in the meantime just rejects the frame.
+func (h *Hub) roomRecipients(roomID string, excludeClient *Client) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	roomClients := h.rooms[roomID]
+	recipients := make([]*Client, 0, len(roomClients))
 	for client := range roomClients {
-		if client == excludeClient {
-			continue
+		if client != excludeClient {
+			recipients = append(recipients, client)
 		}
-		// Use goroutine with recover to safely send to potentially closed channel
-		go func(cli *Client) {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("Panic recovered while sending to client %s: %v", cli.userID, r)
-				}
-			}()
-			select {
-			case cli.send <- data:
-			default:
-				// Channel is full or closed, skip this client
-				log.Printf("Send channel full or closed for client %s", cli.userID)
-			}
-		}(client)
 	}
-	h.mu.RUnlock()
-	return nil
+	return recipients
+}
+
+// operationContext returns a context for one storage call, cancelled on timeout or hub shutdown
+func (h *Hub) operationContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(h.ctx, operationTimeout)
 }
 
 // isRoomExpired checks if a room has expired
-func (h *Hub) isRoomExpired(roomID string) bool {
+func (h *Hub) isRoomExpired(ctx context.Context, roomID string) bool {
 	roomUUID, err := uuid.Parse(roomID)
 	if err != nil {
 		return false
 	}
-	
-	room, err := h.roomsRepo.GetByID(roomUUID)
+
+	room, err := h.roomsRepo.GetByID(ctx, roomUUID)
 	if err != nil {
 		return false
 	}
-	
+
 	return room.ExpiresAt != nil && room.ExpiresAt.Before(time.Now())
 }
 
 // loadDocumentForClient loads document state from Redis and sends it to the client
 func (h *Hub) loadDocumentForClient(client *Client) {
+	ctx, cancel := h.operationContext()
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "hub.loadDocumentForClient", trace.WithAttributes(
+		attribute.String("room.id", client.roomID),
+		attribute.String("user.id", client.userID),
+	))
+	defer span.End()
+
 	// Check if room has expired
-	if h.isRoomExpired(client.roomID) {
-		log.Printf("Room %s has expired, not loading document", client.roomID)
-		h.redisClient.DeleteDocumentState(client.roomID)
+	if h.isRoomExpired(ctx, client.roomID) {
+		span.SetAttributes(attribute.Bool("room.expired", true))
+		client.log.Info("Room has expired, not loading document")
 		return
 	}
-	
+
 	// Load document state from Redis
-	docState, err := h.redisClient.GetDocumentState(client.roomID)
+	docState, err := h.redisClient.GetDocumentState(ctx, client.roomID)
 	if err != nil {
-		log.Printf("Error loading document state from Redis for room %s: %v", client.roomID, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "load document state")
+		client.log.Error("Failed to load document state from Redis", slog.Any("error", err))
 		return
 	}
-	
+	// Redis lost the state, e.g. restarted without persistence: start from the snapshot taken at
+	// the last shutdown. The client's first yjs_full_state stores it in Redis again.
+	if docState == nil {
+		docState = h.loadSnapshot(ctx, client)
+		span.SetAttributes(attribute.Bool("document.from_snapshot", docState != nil))
+	}
+	span.SetAttributes(attribute.Int("document.bytes", len(docState)))
+
 	// Send document state even if empty (empty document is valid state)
-	if docState != nil {
-		select {
-		case client.sendBinary <- docState:
-			if len(docState) == 0 {
-				log.Printf("Sent empty document state to client %s in room %s (document is empty)", client.userID, client.roomID)
-			} else {
-				log.Printf("Sent document state to client %s in room %s (size: %d bytes)", client.userID, client.roomID, len(docState))
-			}
-		default:
-			log.Printf("Failed to send document state to client %s: channel full", client.userID)
-		}
+	if docState != nil && client.sendBinary(docState) {
+		client.log.Debug("Sent document state", slog.Int("bytes", len(docState)))
 	}
 }
 
+// loadSnapshot returns the room's document snapshot from Postgres, nil if it has none
+func (h *Hub) loadSnapshot(ctx context.Context, client *Client) []byte {
+	roomUUID, err := uuid.Parse(client.roomID)
+	if err != nil {
+		return nil
+	}
+	snapshot, err := h.snapshots.GetByRoomID(ctx, roomUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		client.log.Error("Failed to load document snapshot", slog.Any("error", err))
+		return nil
+	}
+	return snapshot.State
+}
+
 // StoreRoomDocumentState stores full Y.js document state for the room in Redis.
 // Only full state snapshots should be stored; incremental updates are not stored.
-// If room has expired, document will be deleted instead of stored.
+// If room has expired, the update is ignored; the archive job snapshots and removes the stored state.
 // Empty documents (len(data) == 0) are valid and should be stored to clear the document.
-func (h *Hub) StoreRoomDocumentState(roomID string, data []byte) {
+func (h *Hub) StoreRoomDocumentState(roomID string, data []byte, seq int64) {
+	h.storeMu.RLock()
+	defer h.storeMu.RUnlock()
+
+	ctx, cancel := h.operationContext()
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "hub.StoreRoomDocumentState", trace.WithAttributes(
+		attribute.String("room.id", roomID),
+		attribute.Int("document.bytes", len(data)),
+	))
+	defer span.End()
+
 	// Check if room has expired
-	if h.isRoomExpired(roomID) {
-		log.Printf("Room %s has expired, deleting document", roomID)
-		h.redisClient.DeleteDocumentState(roomID)
+	if h.isRoomExpired(ctx, roomID) {
+		span.SetAttributes(attribute.Bool("room.expired", true))
+		h.log.Info("Room has expired, not storing document", slog.String("room_id", roomID))
 		return
 	}
-	
+
 	// Get room to calculate TTL
 	roomUUID, err := uuid.Parse(roomID)
 	if err != nil {
-		log.Printf("Invalid room ID format: %s", roomID)
+		h.log.Warn("Invalid room ID format", slog.String("room_id", roomID))
 		return
 	}
-	
-	room, err := h.roomsRepo.GetByID(roomUUID)
+
+	room, err := h.roomsRepo.GetByID(ctx, roomUUID)
 	if err != nil {
-		log.Printf("Room %s not found: %v", roomID, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "load room")
+		h.log.Warn("Room not found", slog.String("room_id", roomID), slog.Any("error", err))
 		return
 	}
-	
+
 	// Calculate TTL based on room expiration
 	var ttl time.Duration
 	if room.ExpiresAt != nil {
-		ttl = time.Until(*room.ExpiresAt) + time.Hour // TTL until expiration + 1h buffer
-		if ttl < 0 {
-			// Should not happen (isRoomExpired already checked), but just in case
-			h.redisClient.DeleteDocumentState(roomID)
-			return
-		}
+		// Keep the state past expiration so the archive job can snapshot it before it is gone
+		ttl = time.Until(*room.ExpiresAt) + expiredDocumentGrace
 	} else {
 		ttl = 365 * 24 * time.Hour // No expiration - 1 year TTL
 	}
-	
+
 	// Store in Redis (even if empty - empty document is valid state)
-	if err := h.redisClient.SetDocumentState(roomID, data, ttl); err != nil {
-		log.Printf("Error storing document state in Redis for room %s: %v", roomID, err)
+	if err := h.redisClient.SetDocumentState(ctx, roomID, data, seq, ttl); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "store document state")
+		h.log.Error("Failed to store document state in Redis", slog.String("room_id", roomID), slog.Any("error", err))
 		return
 	}
-	
-	if len(data) == 0 {
-		log.Printf("Stored empty document state in Redis for room %s (document cleared, TTL: %v)", roomID, ttl)
-	} else {
-		log.Printf("Stored document state in Redis for room %s (size: %d bytes, TTL: %v)", roomID, len(data), ttl)
-	}
+
+	h.log.Debug("Stored document state in Redis",
+		slog.String("room_id", roomID), slog.Int("bytes", len(data)), slog.Duration("ttl", ttl))
 }
 
 // BroadcastBinaryToRoom sends a binary message to all clients in a specific room (excluding sender).
 // Does NOT update stored document state — only yjs_full_state updates the stored state.
 func (h *Hub) BroadcastBinaryToRoom(roomID string, data []byte, excludeClient *Client) error {
-	h.mu.RLock()
-	roomClients, ok := h.rooms[roomID]
-	if !ok {
-		h.mu.RUnlock()
-		log.Printf("Room %s not found or has no clients", roomID)
+	recipients := h.roomRecipients(roomID, excludeClient)
+	if len(recipients) == 0 {
+		h.log.Debug("Room not found or has no clients", slog.String("room_id", roomID))
 		return nil
 	}
-	clientsToNotify := make([]*Client, 0, len(roomClients))
-	for client := range roomClients {
-		if client != excludeClient {
-			clientsToNotify = append(clientsToNotify, client)
-		}
-	}
-	h.mu.RUnlock()
-
-	log.Printf("Broadcasting Y.js update to %d clients in room %s", len(clientsToNotify), roomID)
 
-	// Send updates to clients (outside of lock to avoid blocking)
-	for _, client := range clientsToNotify {
-		// Create a copy of the data for each client to avoid race conditions
-		dataCopy := make([]byte, len(data))
-		copy(dataCopy, data)
+	h.log.Debug("Broadcasting Y.js update", slog.String("room_id", roomID), slog.Int("recipients", len(recipients)))
 
-		// Use a goroutine with recover to safely send to potentially closed channel
-		go func(cli *Client, data []byte) {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("Panic recovered while sending binary to client %s: %v", cli.userID, r)
-				}
-			}()
-			
-			select {
-			case cli.sendBinary <- data:
-				log.Printf("Sent Y.js update to client %s", cli.userID)
-			default:
-				// Channel is full, skip this client
-				log.Printf("Binary send channel full for client %s", cli.userID)
-			}
-		}(client, dataCopy)
+	// Recipients only read the frame, so they all share data
+	for _, client := range recipients {
+		client.sendBinary(data)
 	}
-
 	return nil
 }
 
@@ -343,3 +413,51 @@ func (h *Hub) GetTotalClientsCount() int {
 	defer h.mu.RUnlock()
 	return len(h.clients)
 }
+
+// CloseRoom sends a room_closed message to every client of the room on this instance and
+// disconnects them. room.closed is published by whoever closes the room, not by each hub.
+func (h *Hub) CloseRoom(roomID string, reason string) {
+	data, err := json.Marshal(Message{
+		Type:    TypeRoomClosed,
+		RoomID:  roomID,
+		Payload: RoomClosedPayload{Reason: reason},
+	})
+	if err != nil {
+		return
+	}
+
+	// The notice is queued before unregister closes the queue, so writePump delivers it ahead of the close frame
+	clients := h.roomRecipients(roomID, nil)
+	for _, client := range clients {
+		client.sendText(data)
+		h.leave(client)
+	}
+
+	if len(clients) > 0 {
+		h.log.Info("Closed room", slog.String("room_id", roomID), slog.String("reason", reason), slog.Int("clients", len(clients)))
+	}
+}
+
+// Stats returns the number of connected clients and the size of every active room
+func (h *Hub) Stats() metrics.HubStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sizes := make([]int, 0, len(h.rooms))
+	for _, roomClients := range h.rooms {
+		sizes = append(sizes, len(roomClients))
+	}
+	return metrics.HubStats{Clients: len(h.clients), RoomSizes: sizes}
+}
+
+// CheckAlive reports an error if the Run loop has stopped or is stuck
+func (h *Hub) CheckAlive(ctx context.Context) error {
+	last := h.heartbeat.Load()
+	if last == 0 {
+		return errors.New("hub is not running")
+	}
+	if since := time.Since(time.Unix(0, last)); since > 3*heartbeatInterval {
+		return fmt.Errorf("hub loop stalled for %v", since.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// benchRoom registers n clients in one room, each with a goroutine draining its queue like writePump
+func benchRoom(b *testing.B, n int) (*Hub, *Client, func()) {
+	b.Helper()
+
+	hub := NewHub(context.Background(), Config{}, nil, nil, nil, nil, nil, slog.New(slog.DiscardHandler))
+	hub.rooms["room"] = make(map[*Client]bool)
+
+	var wg sync.WaitGroup
+	clients := make([]*Client, 0, n+1)
+	for i := 0; i <= n; i++ {
+		client := &Client{
+			hub:    hub,
+			queue:  newSendQueue(hub.cfg.SendQueueBytes),
+			roomID: "room",
+			userID: fmt.Sprintf("user-%d", i),
+			log:    hub.log,
+		}
+		hub.clients[client] = true
+		hub.rooms["room"][client] = true
+		clients = append(clients, client)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range client.queue.ready {
+				if _, closed, _ := client.queue.take(); closed {
+					return
+				}
+			}
+		}()
+	}
+
+	stop := func() {
+		for _, client := range clients {
+			client.queue.close(nil)
+		}
+		wg.Wait()
+	}
+	return hub, clients[0], stop
+}
+
+func BenchmarkBroadcastBinaryToRoom(b *testing.B) {
+	update := make([]byte, 256)
+	for _, n := range []int{2, 10, 50} {
+		b.Run(fmt.Sprintf("clients=%d", n), func(b *testing.B) {
+			hub, sender, stop := benchRoom(b, n)
+			defer stop()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				hub.BroadcastBinaryToRoom("room", update, sender)
+			}
+		})
+	}
+}
+
+// BenchmarkGoroutineFanOut is the delivery BroadcastBinaryToRoom used before send queues:
+// a copy and a goroutine per recipient per message, sending on a 256-slot channel
+func BenchmarkGoroutineFanOut(b *testing.B) {
+	update := make([]byte, 256)
+	for _, n := range []int{2, 10, 50} {
+		b.Run(fmt.Sprintf("clients=%d", n), func(b *testing.B) {
+			var wg sync.WaitGroup
+			channels := make([]chan []byte, n)
+			for i := range channels {
+				channels[i] = make(chan []byte, 256)
+				wg.Add(1)
+				go func(ch chan []byte) {
+					defer wg.Done()
+					for range ch {
+					}
+				}(channels[i])
+			}
+
+			var sends sync.WaitGroup
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, ch := range channels {
+					dataCopy := make([]byte, len(update))
+					copy(dataCopy, update)
+
+					sends.Add(1)
+					go func(ch chan []byte, data []byte) {
+						defer sends.Done()
+						select {
+						case ch <- data:
+						default:
+						}
+					}(ch, dataCopy)
+				}
+			}
+			sends.Wait()
+			b.StopTimer()
+
+			for _, ch := range channels {
+				close(ch)
+			}
+			wg.Wait()
+		})
+	}
+}
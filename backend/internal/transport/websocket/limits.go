@@ -0,0 +1,158 @@
+package websocket
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"nonza/backend/internal/metrics"
+	"nonza/backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+)
+
+// Limits bounds what one connection, room or client address may use. Zero fields take the
+// defaults below. All but MaxConnectionsPerIP can be overridden per organization (models.WSLimits).
+// Room and address limits count the connections of this instance.
+type Limits struct {
+	MessagesPerSecond   int // inbound messages per connection; bursts of one second's worth are allowed
+	BytesPerSecond      int // inbound bytes per connection
+	MaxMessageBytes     int // largest single inbound message
+	MaxClientsPerRoom   int // connections per room
+	MaxDocumentBytes    int // largest document state a room may store
+	MaxConnectionsPerIP int // concurrent connections per client address
+}
+
+var defaultLimits = Limits{
+	MessagesPerSecond:   50,
+	BytesPerSecond:      1 << 20,
+	MaxMessageBytes:     512 << 10,
+	MaxClientsPerRoom:   200,
+	MaxDocumentBytes:    8 << 20,
+	MaxConnectionsPerIP: 50,
+}
+
+// Close codes sent to clients that exceed a limit. Exceeding MaxMessageBytes closes the
+// connection with the standard 1009 (message too big).
+const (
+	closeRateLimited        = 4029
+	closeRoomFull           = 4030
+	closeTooManyConnections = 4031
+	closeDocumentTooLarge   = 4032
+)
+
+// limitCloseMessages maps the limits enforced by closing the connection to their close frames
+var limitCloseMessages = map[string][]byte{
+	metrics.LimitMessageRate:   websocket.FormatCloseMessage(closeRateLimited, "message rate limit exceeded"),
+	metrics.LimitByteRate:      websocket.FormatCloseMessage(closeRateLimited, "byte rate limit exceeded"),
+	metrics.LimitRoomClients:   websocket.FormatCloseMessage(closeRoomFull, "room is full"),
+	metrics.LimitIPConnections: websocket.FormatCloseMessage(closeTooManyConnections, "too many connections"),
+	metrics.LimitDocumentSize:  websocket.FormatCloseMessage(closeDocumentTooLarge, "document too large"),
+}
+
+// withDefaults fills unset limits with the defaults
+func (l Limits) withDefaults() Limits {
+	fill := func(v *int, def int) {
+		if *v <= 0 {
+			*v = def
+		}
+	}
+	fill(&l.MessagesPerSecond, defaultLimits.MessagesPerSecond)
+	fill(&l.BytesPerSecond, defaultLimits.BytesPerSecond)
+	fill(&l.MaxMessageBytes, defaultLimits.MaxMessageBytes)
+	fill(&l.MaxClientsPerRoom, defaultLimits.MaxClientsPerRoom)
+	fill(&l.MaxDocumentBytes, defaultLimits.MaxDocumentBytes)
+	fill(&l.MaxConnectionsPerIP, defaultLimits.MaxConnectionsPerIP)
+	return l
+}
+
+// override applies an organization's overrides
+func (l Limits) override(o *models.WSLimits) Limits {
+	if o == nil {
+		return l
+	}
+	set := func(v *int, o *int) {
+		if o != nil && *o > 0 {
+			*v = *o
+		}
+	}
+	set(&l.MessagesPerSecond, o.MessagesPerSecond)
+	set(&l.BytesPerSecond, o.BytesPerSecond)
+	set(&l.MaxMessageBytes, o.MaxMessageBytes)
+	set(&l.MaxClientsPerRoom, o.MaxClientsPerRoom)
+	set(&l.MaxDocumentBytes, o.MaxDocumentBytes)
+	return l
+}
+
+// roomLimits returns the limits of a room: the server's, with its organization's overrides applied.
+// Rooms that can't be loaded get the server's limits.
+func (h *Hub) roomLimits(ctx context.Context, roomID string) Limits {
+	limits := h.cfg.Limits
+	if h.roomsRepo == nil || h.orgsRepo == nil {
+		return limits
+	}
+
+	roomUUID, err := uuid.Parse(roomID)
+	if err != nil {
+		return limits
+	}
+	room, err := h.roomsRepo.GetByID(ctx, roomUUID)
+	if err != nil {
+		return limits
+	}
+	org, err := h.orgsRepo.GetByID(ctx, room.OrganizationID)
+	if err != nil {
+		h.log.Warn("Failed to load organization limits", slog.String("room_id", roomID), slog.Any("error", err))
+		return limits
+	}
+	return limits.override(org.WSLimits)
+}
+
+// admitLocked checks the client against the room and address limits and counts it in.
+// It returns the exceeded limit, or "" when the client is admitted. The caller holds h.mu.
+func (h *Hub) admitLocked(client *Client) string {
+	if h.ipConns[client.ip] >= h.cfg.Limits.MaxConnectionsPerIP {
+		return metrics.LimitIPConnections
+	}
+	if client.roomID != "" && len(h.rooms[client.roomID]) >= client.limits.MaxClientsPerRoom {
+		return metrics.LimitRoomClients
+	}
+	h.ipConns[client.ip]++
+	return ""
+}
+
+// releaseLocked uncounts a client admitted by admitLocked. The caller holds h.mu.
+func (h *Hub) releaseLocked(client *Client) {
+	if h.ipConns[client.ip]--; h.ipConns[client.ip] <= 0 {
+		delete(h.ipConns, client.ip)
+	}
+}
+
+// applyLimits sets the client's limits and the rate limiters enforcing them on inbound messages.
+// A single message may be as large as MaxMessageBytes even if BytesPerSecond is lower.
+func (c *Client) applyLimits(limits Limits) {
+	c.limits = limits
+	c.msgLimiter = rate.NewLimiter(rate.Limit(limits.MessagesPerSecond), limits.MessagesPerSecond)
+	c.byteLimiter = rate.NewLimiter(rate.Limit(limits.BytesPerSecond), max(limits.BytesPerSecond, limits.MaxMessageBytes))
+}
+
+// allowMessage takes an inbound message of n bytes from the rate limits.
+// It returns the exceeded limit, or "" when the message may be handled.
+func (c *Client) allowMessage(n int) string {
+	if !c.msgLimiter.Allow() {
+		return metrics.LimitMessageRate
+	}
+	if !c.byteLimiter.AllowN(time.Now(), n) {
+		return metrics.LimitByteRate
+	}
+	return ""
+}
+
+// closeForLimit disconnects the client with the close code of the exceeded limit
+func (c *Client) closeForLimit(limit string) {
+	metrics.WSLimitViolations.WithLabelValues(limit).Inc()
+	c.log.Warn("Closing connection over websocket limit", slog.String("limit", limit))
+	c.queue.close(limitCloseMessages[limit])
+}
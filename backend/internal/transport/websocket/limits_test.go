@@ -0,0 +1,71 @@
+package websocket
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"nonza/backend/internal/metrics"
+	"nonza/backend/internal/models"
+)
+
+func TestLimitsOverride(t *testing.T) {
+	ten, zero := 10, 0
+	limits := Limits{MaxClientsPerRoom: 5}.withDefaults().override(&models.WSLimits{
+		MessagesPerSecond: &ten,
+		MaxDocumentBytes:  &zero,
+	})
+
+	if limits.MessagesPerSecond != 10 {
+		t.Errorf("MessagesPerSecond = %d, want the override 10", limits.MessagesPerSecond)
+	}
+	if limits.MaxClientsPerRoom != 5 {
+		t.Errorf("MaxClientsPerRoom = %d, want the server's 5", limits.MaxClientsPerRoom)
+	}
+	if limits.MaxDocumentBytes != defaultLimits.MaxDocumentBytes {
+		t.Errorf("MaxDocumentBytes = %d, want the default: zero overrides are ignored", limits.MaxDocumentBytes)
+	}
+}
+
+func TestAdmitLocked(t *testing.T) {
+	hub := NewHub(context.Background(), Config{Limits: Limits{MaxClientsPerRoom: 2, MaxConnectionsPerIP: 3}},
+		nil, nil, nil, nil, nil, slog.New(slog.DiscardHandler))
+
+	connect := func(ip, roomID string) string {
+		client := &Client{hub: hub, roomID: roomID, ip: ip}
+		client.applyLimits(hub.cfg.Limits)
+		limit := hub.admitLocked(client)
+		if limit == "" {
+			hub.clients[client] = true
+			hub.joinRoomLocked(client, roomID)
+		}
+		return limit
+	}
+
+	steps := []struct {
+		ip, room string
+		want     string
+	}{
+		{"10.0.0.1", "a", ""},
+		{"10.0.0.1", "a", ""},
+		{"10.0.0.2", "a", metrics.LimitRoomClients},
+		{"10.0.0.1", "b", ""},
+		{"10.0.0.1", "c", metrics.LimitIPConnections},
+		{"10.0.0.2", "c", ""},
+	}
+	for i, s := range steps {
+		if got := connect(s.ip, s.room); got != s.want {
+			t.Errorf("step %d (%s into %s): limit = %q, want %q", i, s.ip, s.room, got, s.want)
+		}
+	}
+
+	for client := range hub.clients {
+		if client.ip == "10.0.0.1" {
+			hub.releaseLocked(client)
+			break
+		}
+	}
+	if got := connect("10.0.0.1", "c"); got != "" {
+		t.Errorf("after a disconnect: limit = %q, want none", got)
+	}
+}
@@ -0,0 +1,282 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"nonza/backend/internal/metrics"
+	"nonza/backend/internal/models"
+	"nonza/backend/internal/repository/redis"
+
+	"github.com/google/uuid"
+)
+
+// presenceRefreshInterval is how often the hub rewrites its presence records in Redis,
+// well within redis.PresenceStaleAfter so live records never look stale
+const presenceRefreshInterval = redis.PresenceStaleAfter / 3
+
+// maxDisplayNameLength bounds the display name a client announces on connect
+const maxDisplayNameLength = 100
+
+// userPresence is this instance's view of one user in one room, who may have several connections (tabs)
+type userPresence struct {
+	entry   redis.PresenceEntry
+	clients map[*Client]struct{}
+}
+
+// snapshot returns the entry with the connection count and activity of the user's current connections
+func (p *userPresence) snapshot() redis.PresenceEntry {
+	entry := p.entry
+	entry.Connections = len(p.clients)
+	for client := range p.clients {
+		if active := client.lastActiveAt(); active.After(entry.LastActiveAt) {
+			entry.LastActiveAt = active
+			entry.Device = client.device
+		}
+	}
+	return entry
+}
+
+func (d PresenceDiffPayload) empty() bool {
+	return len(d.Joined) == 0 && len(d.Updated) == 0 && len(d.Left) == 0
+}
+
+// joinRoomLocked adds client to roomID and its presence. The caller holds h.mu.
+func (h *Hub) joinRoomLocked(client *Client, roomID string) PresenceDiffPayload {
+	if h.rooms[roomID] == nil {
+		h.rooms[roomID] = make(map[*Client]bool)
+	}
+	h.rooms[roomID][client] = true
+
+	users := h.presence[roomID]
+	if users == nil {
+		users = make(map[string]*userPresence)
+		h.presence[roomID] = users
+	}
+
+	user, ok := users[client.userID]
+	if !ok {
+		user = &userPresence{
+			entry: redis.PresenceEntry{
+				UserID:       client.userID,
+				DisplayName:  client.displayName,
+				Role:         client.role,
+				Device:       client.device,
+				ConnectedAt:  client.connectedAt,
+				LastActiveAt: client.connectedAt,
+			},
+			clients: make(map[*Client]struct{}),
+		}
+		users[client.userID] = user
+	}
+	user.clients[client] = struct{}{}
+
+	if !ok {
+		return PresenceDiffPayload{Joined: []redis.PresenceEntry{user.snapshot()}}
+	}
+	return PresenceDiffPayload{Updated: []redis.PresenceEntry{user.snapshot()}}
+}
+
+// leaveRoomLocked removes client from its room and the room's presence. The caller holds h.mu.
+func (h *Hub) leaveRoomLocked(client *Client) (string, PresenceDiffPayload) {
+	roomID := client.roomID
+	if roomID == "" {
+		return "", PresenceDiffPayload{}
+	}
+
+	if roomClients, ok := h.rooms[roomID]; ok {
+		delete(roomClients, client)
+		if len(roomClients) == 0 {
+			delete(h.rooms, roomID)
+			h.documentEvents.Delete(roomID)
+			// Note: Document cleanup for expired rooms is handled by cron job
+		}
+	}
+
+	users := h.presence[roomID]
+	user, ok := users[client.userID]
+	if !ok {
+		return roomID, PresenceDiffPayload{}
+	}
+	if _, ok := user.clients[client]; !ok {
+		return roomID, PresenceDiffPayload{}
+	}
+	delete(user.clients, client)
+	if len(user.clients) > 0 {
+		return roomID, PresenceDiffPayload{Updated: []redis.PresenceEntry{user.snapshot()}}
+	}
+
+	delete(users, client.userID)
+	if len(users) == 0 {
+		delete(h.presence, roomID)
+	}
+	return roomID, PresenceDiffPayload{Left: []string{client.userID}}
+}
+
+// moveClient switches client to roomID, or takes it out of its room when roomID is empty
+func (h *Hub) moveClient(client *Client, roomID string) {
+	var limits Limits
+	if roomID != "" {
+		ctx, cancel := h.operationContext()
+		limits = h.roomLimits(ctx, roomID)
+		cancel()
+	}
+
+	h.mu.Lock()
+	if roomID != "" && len(h.rooms[roomID]) >= limits.MaxClientsPerRoom {
+		h.mu.Unlock()
+		client.closeForLimit(metrics.LimitRoomClients)
+		return
+	}
+	oldRoomID, left := h.leaveRoomLocked(client)
+	client.roomID = roomID
+	var joined PresenceDiffPayload
+	if roomID != "" {
+		joined = h.joinRoomLocked(client, roomID)
+	}
+	h.mu.Unlock()
+	if roomID != "" {
+		client.applyLimits(limits)
+	}
+
+	if oldRoomID != "" {
+		h.publishPresence(oldRoomID, left, nil)
+	}
+	if roomID != "" {
+		h.sendRoster(client)
+		h.publishPresence(roomID, joined, client)
+	}
+}
+
+// Roster returns the users connected to the room through this instance
+func (h *Hub) Roster(roomID string) []redis.PresenceEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	users := h.presence[roomID]
+	entries := make([]redis.PresenceEntry, 0, len(users))
+	for _, user := range users {
+		entries = append(entries, user.snapshot())
+	}
+	return entries
+}
+
+// sendRoster sends a newly joined client everyone in its room; diffs follow as people come and go
+func (h *Hub) sendRoster(client *Client) {
+	data, err := json.Marshal(Message{
+		Type:    TypePresenceRoster,
+		RoomID:  client.roomID,
+		Payload: PresenceRosterPayload{Users: h.Roster(client.roomID)},
+	})
+	if err != nil {
+		return
+	}
+	client.sendText(data)
+}
+
+// publishPresence sends a presence diff to the room (except exclude) and records it in Redis
+func (h *Hub) publishPresence(roomID string, diff PresenceDiffPayload, exclude *Client) {
+	if diff.empty() {
+		return
+	}
+
+	h.broadcastToRoomExcluding(roomID, Message{
+		Type:    TypePresenceDiff,
+		RoomID:  roomID,
+		Payload: diff,
+	}, exclude)
+
+	go h.storePresence(roomID, diff)
+	h.publishParticipantEvents(roomID, diff)
+}
+
+func (h *Hub) storePresence(roomID string, diff PresenceDiffPayload) {
+	ctx, cancel := h.operationContext()
+	defer cancel()
+
+	for _, entry := range slices.Concat(diff.Joined, diff.Updated) {
+		if err := h.redisClient.SetPresence(ctx, roomID, h.instance, entry, redis.PresenceStaleAfter); err != nil {
+			h.log.Warn("Failed to store presence", slog.String("room_id", roomID), slog.Any("error", err))
+		}
+	}
+	for _, userID := range diff.Left {
+		if err := h.redisClient.RemovePresence(ctx, roomID, h.instance, userID); err != nil {
+			h.log.Warn("Failed to remove presence", slog.String("room_id", roomID), slog.Any("error", err))
+		}
+	}
+}
+
+// refreshPresence periodically rewrites this instance's presence records so they stay fresh
+// and carry the latest activity, until the hub stops
+func (h *Hub) refreshPresence() {
+	ticker := time.NewTicker(presenceRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.quit:
+			return
+		case <-ticker.C:
+		}
+
+		h.mu.RLock()
+		rooms := make(map[string][]redis.PresenceEntry, len(h.presence))
+		for roomID, users := range h.presence {
+			for _, user := range users {
+				rooms[roomID] = append(rooms[roomID], user.snapshot())
+			}
+		}
+		h.mu.RUnlock()
+
+		for roomID, entries := range rooms {
+			h.storePresence(roomID, PresenceDiffPayload{Updated: entries})
+		}
+	}
+}
+
+// instanceID identifies this server process, so instances don't overwrite each other's presence records
+func instanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	return hostname + "-" + uuid.NewString()[:8]
+}
+
+// normalizeRole maps the role a client announces to a participant role, defaulting to participant
+func normalizeRole(role string) string {
+	switch models.ParticipantRole(role) {
+	case models.RoleMainSpeaker, models.RoleModerator:
+		return role
+	default:
+		return string(models.RoleParticipant)
+	}
+}
+
+// normalizeDisplayName trims the display name a client announces and caps its length
+func normalizeDisplayName(name string) string {
+	name = strings.TrimSpace(name)
+	if runes := []rune(name); len(runes) > maxDisplayNameLength {
+		name = string(runes[:maxDisplayNameLength])
+	}
+	return name
+}
+
+// deviceFromUserAgent classifies the connecting device as mobile, tablet or desktop
+func deviceFromUserAgent(ua string) string {
+	switch {
+	case ua == "":
+		return "unknown"
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet") ||
+		(strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobi")):
+		return "tablet"
+	case strings.Contains(ua, "Mobi") || strings.Contains(ua, "iPhone"):
+		return "mobile"
+	default:
+		return "desktop"
+	}
+}
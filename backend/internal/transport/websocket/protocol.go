@@ -0,0 +1,315 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"nonza/backend/internal/repository/redis"
+
+	"github.com/gorilla/websocket"
+)
+
+// Protocol versions are negotiated with the Sec-WebSocket-Protocol header ("nonza.v1").
+// Clients that don't offer a subprotocol get ProtocolV1, the behaviour of the legacy clients.
+const (
+	ProtocolV1 = 1
+
+	CurrentProtocolVersion = ProtocolV1
+)
+
+// subprotocols maps the Sec-WebSocket-Protocol values the server accepts to protocol versions,
+// newest first: the upgrader picks the first one the client offers
+var subprotocols = []struct {
+	name    string
+	version int
+}{
+	{"nonza.v1", ProtocolV1},
+}
+
+// Message types
+const (
+	TypeJoinRoom           = "join_room"
+	TypeLeaveRoom          = "leave_room"
+	TypePing               = "ping"
+	TypePong               = "pong"
+	TypeConnected          = "connected"
+	TypeError              = "error"
+	TypePresenceRoster     = "presence_roster"
+	TypePresenceDiff       = "presence_diff"
+	TypeParticipantJoining = "participant_joining"
+	TypeYjsUpdate          = "yjs_update"
+	TypeYjsUpdateAck       = "yjs_update_ack"
+	TypeYjsFullState       = "yjs_full_state"
+	TypeYjsAwareness       = "yjs_awareness"
+	TypeYjsSync            = "yjs_sync"
+	TypeYjsSyncAck         = "yjs_sync_ack"
+	TypeResume             = "resume"
+	TypeResumeAck          = "resume_ack"
+	TypeResyncRequired     = "resync_required"
+	TypeFullStateRequest   = "full_state_request"
+	TypeRoomUpdated        = "room_updated"
+	TypeRoomClosed         = "room_closed"
+	TypeServerShutdown     = "server_shutdown"
+)
+
+// Error codes sent in error frames
+const (
+	ErrCodeMalformed      = "malformed_message"
+	ErrCodeUnknownType    = "unknown_type"
+	ErrCodeForbiddenType  = "forbidden_type"
+	ErrCodeInvalidPayload = "invalid_payload"
+)
+
+// origin says which side may send a message kind
+type origin int
+
+const (
+	fromServer origin = iota
+	fromClient
+	fromBoth
+)
+
+func (o origin) String() string {
+	switch o {
+	case fromClient:
+		return "client"
+	case fromBoth:
+		return "both"
+	default:
+		return "server"
+	}
+}
+
+// messageKind describes one message type of the protocol
+type messageKind struct {
+	origin      origin
+	payload     func() any // returns a pointer to the payload struct
+	description string
+}
+
+// registry lists every message type of protocol v1. Clients may only send the kinds marked
+// fromClient or fromBoth; anything else is answered with an error frame and not relayed.
+var registry = map[string]messageKind{
+	TypeJoinRoom:           {fromClient, func() any { return &RoomPayload{} }, "Move the connection to another room"},
+	TypeLeaveRoom:          {fromClient, func() any { return &EmptyPayload{} }, "Leave the current room"},
+	TypePing:               {fromClient, func() any { return &EmptyPayload{} }, "Application-level keepalive, answered with pong"},
+	TypeYjsUpdate:          {fromBoth, func() any { return &UpdatePayload{} }, "Incremental Y.js document update; the server adds its sequence number"},
+	TypeYjsFullState:       {fromClient, func() any { return &UpdatePayload{} }, "Full Y.js document state (Y.encodeStateAsUpdate) including updates up to seq"},
+	TypeYjsAwareness:       {fromClient, func() any { return &UpdatePayload{} }, "Y.js awareness update, relayed as a binary frame and never stored"},
+	TypeYjsSync:            {fromClient, func() any { return &EmptyPayload{} }, "Request the stored document state"},
+	TypeResume:             {fromClient, func() any { return &ResumePayload{} }, "Request the document updates after last_seq"},
+	TypePong:               {fromServer, func() any { return &EmptyPayload{} }, "Answer to ping"},
+	TypeConnected:          {fromServer, func() any { return &ConnectedPayload{} }, "Sent once the connection is registered"},
+	TypeError:              {fromServer, func() any { return &ErrorPayload{} }, "A client message was rejected"},
+	TypePresenceRoster:     {fromServer, func() any { return &PresenceRosterPayload{} }, "Everyone in the room, sent to a client when it joins"},
+	TypePresenceDiff:       {fromServer, func() any { return &PresenceDiffPayload{} }, "Users who joined, changed (e.g. opened another tab) or left since the roster"},
+	TypeParticipantJoining: {fromServer, func() any { return &ParticipantJoiningPayload{} }, "A participant requested a media token for the room"},
+	TypeYjsUpdateAck:       {fromServer, func() any { return &SeqPayload{} }, "Sequence number assigned to the sender's own update"},
+	TypeYjsSyncAck:         {fromServer, func() any { return &SyncAckPayload{} }, "Answer to yjs_sync, after the document state"},
+	TypeResumeAck:          {fromServer, func() any { return &SyncAckPayload{} }, "Answer to resume, after the replayed updates"},
+	TypeResyncRequired:     {fromServer, func() any { return &EmptyPayload{} }, "Queued updates were dropped; the client must resume"},
+	TypeFullStateRequest:   {fromServer, func() any { return &EmptyPayload{} }, "Send yjs_full_state so a peer can catch up"},
+	TypeRoomUpdated:        {fromServer, func() any { return &RoomUpdatedPayload{} }, "The room's settings changed, e.g. its expiry was extended; a changed e2ee_enabled needs a new media token"},
+	TypeRoomClosed:         {fromServer, func() any { return &RoomClosedPayload{} }, "The room was closed; the connection is about to end"},
+	TypeServerShutdown:     {fromServer, func() any { return &ShutdownPayload{} }, "The server is restarting; reconnect after the given delay"},
+}
+
+// EmptyPayload is the payload of messages that carry no data
+type EmptyPayload struct{}
+
+// RoomPayload names a room
+type RoomPayload struct {
+	RoomID string `json:"room_id"`
+}
+
+func (p *RoomPayload) validate() error {
+	if p.RoomID == "" {
+		return errors.New("room_id is required")
+	}
+	return nil
+}
+
+// UpdatePayload carries a base64-encoded Y.js update
+type UpdatePayload struct {
+	Update string `json:"update"`
+	Seq    int64  `json:"seq,omitempty"`
+
+	data []byte // Update decoded by validate
+}
+
+func (p *UpdatePayload) validate() error {
+	if p.Seq < 0 {
+		return errors.New("seq must not be negative")
+	}
+	data, err := base64.StdEncoding.DecodeString(p.Update)
+	if err != nil {
+		return fmt.Errorf("update is not valid base64: %w", err)
+	}
+	p.data = data
+	return nil
+}
+
+// ResumePayload carries the last update sequence number a client has seen
+type ResumePayload struct {
+	LastSeq int64 `json:"last_seq"`
+}
+
+func (p *ResumePayload) validate() error {
+	if p.LastSeq < 0 {
+		return errors.New("last_seq must not be negative")
+	}
+	return nil
+}
+
+// ConnectedPayload welcomes a client and confirms the negotiated protocol version
+type ConnectedPayload struct {
+	Message         string `json:"message"`
+	RoomID          string `json:"room_id"`
+	ProtocolVersion int    `json:"protocol_version"`
+}
+
+// ErrorPayload explains why a client message was rejected
+type ErrorPayload struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"` // type of the rejected message, if it could be read
+}
+
+// PresenceRosterPayload lists the users connected to a room
+type PresenceRosterPayload struct {
+	Users []redis.PresenceEntry `json:"users"`
+}
+
+// PresenceDiffPayload is a change to a room's roster
+type PresenceDiffPayload struct {
+	Joined  []redis.PresenceEntry `json:"joined,omitempty"`
+	Updated []redis.PresenceEntry `json:"updated,omitempty"`
+	Left    []string              `json:"left,omitempty"` // user IDs
+}
+
+// ParticipantJoiningPayload announces a participant that was issued a media token
+type ParticipantJoiningPayload struct {
+	ParticipantID   string `json:"participant_id"`
+	ParticipantName string `json:"participant_name"`
+}
+
+// SeqPayload carries an update sequence number
+type SeqPayload struct {
+	Seq int64 `json:"seq"`
+}
+
+// SyncAckPayload tells a client what it received in answer to yjs_sync or resume
+type SyncAckPayload struct {
+	Synced    bool  `json:"synced"`     // the server had document state to send
+	Seq       int64 `json:"seq"`        // sequence number the client is caught up to
+	Replayed  int   `json:"replayed"`   // updates replayed from the log
+	FullState bool  `json:"full_state"` // the stored document state was sent
+}
+
+// RoomUpdatedPayload is the room's settings after a change and the fields that changed
+type RoomUpdatedPayload struct {
+	Name        string     `json:"name"`
+	Slug        *string    `json:"slug,omitempty"`
+	RoomType    string     `json:"room_type"`
+	IsTemporary bool       `json:"is_temporary"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	E2EEEnabled bool       `json:"e2ee_enabled"`
+	Changed     []string   `json:"changed"`
+}
+
+// RoomClosedPayload says why a room was closed
+type RoomClosedPayload struct {
+	Reason string `json:"reason"`
+}
+
+// ShutdownPayload tells clients when to reconnect
+type ShutdownPayload struct {
+	ReconnectAfterMs int64 `json:"reconnect_after_ms"`
+}
+
+// validator is implemented by payloads with constraints beyond their JSON shape
+type validator interface {
+	validate() error
+}
+
+// protocolError is a rejected client message, reported back in an error frame
+type protocolError struct {
+	code    string
+	message string
+	msgType string
+}
+
+func (e *protocolError) Error() string {
+	return e.code + ": " + e.message
+}
+
+// inboundMessage is the envelope of a client message; the payload is decoded once its type is known
+type inboundMessage struct {
+	Type    string          `json:"type"`
+	RoomID  string          `json:"room_id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// decodeMessage parses a client text frame and returns its type and validated payload
+func decodeMessage(data []byte) (string, any, error) {
+	var envelope inboundMessage
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return "", nil, &protocolError{code: ErrCodeMalformed, message: "message is not a JSON object"}
+	}
+	if envelope.Type == "" {
+		return "", nil, &protocolError{code: ErrCodeMalformed, message: "type is required"}
+	}
+
+	kind, ok := registry[envelope.Type]
+	if !ok {
+		return "", nil, &protocolError{code: ErrCodeUnknownType, message: "unknown message type", msgType: envelope.Type}
+	}
+	if kind.origin == fromServer {
+		return "", nil, &protocolError{code: ErrCodeForbiddenType, message: "message type is sent by the server only", msgType: envelope.Type}
+	}
+
+	payload := kind.payload()
+	if raw := bytes.TrimSpace(envelope.Payload); len(raw) > 0 && !bytes.Equal(raw, []byte("null")) {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(payload); err != nil {
+			return "", nil, &protocolError{code: ErrCodeInvalidPayload, message: err.Error(), msgType: envelope.Type}
+		}
+	}
+	if v, ok := payload.(validator); ok {
+		if err := v.validate(); err != nil {
+			return "", nil, &protocolError{code: ErrCodeInvalidPayload, message: err.Error(), msgType: envelope.Type}
+		}
+	}
+	return envelope.Type, payload, nil
+}
+
+// negotiateProtocol picks the protocol version from the subprotocols the client offers.
+// ok is false when the client offered subprotocols but none is supported.
+func negotiateProtocol(r *http.Request) (name string, version int, ok bool) {
+	offered := websocket.Subprotocols(r)
+	if len(offered) == 0 {
+		return "", ProtocolV1, true
+	}
+	for _, p := range subprotocols {
+		if slices.Contains(offered, p.name) {
+			return p.name, p.version, true
+		}
+	}
+	return "", 0, false
+}
+
+// supportedSubprotocols lists the accepted Sec-WebSocket-Protocol values, newest first
+func supportedSubprotocols() []string {
+	names := make([]string, len(subprotocols))
+	for i, p := range subprotocols {
+		names[i] = p.name
+	}
+	return names
+}
@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDecodeMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantType string
+		wantCode string
+	}{
+		{"yjs update", `{"type":"yjs_update","room_id":"r","payload":{"update":"AQID"}}`, TypeYjsUpdate, ""},
+		{"sync without payload", `{"type":"yjs_sync","room_id":"r"}`, TypeYjsSync, ""},
+		{"resume", `{"type":"resume","payload":{"last_seq":12}}`, TypeResume, ""},
+		{"not json", `hello`, "", ErrCodeMalformed},
+		{"missing type", `{"payload":{}}`, "", ErrCodeMalformed},
+		{"unknown type", `{"type":"custom_event","payload":{}}`, "", ErrCodeUnknownType},
+		{"forged presence", `{"type":"presence_diff","payload":{"joined":[{"user_id":"u"}]}}`, "", ErrCodeForbiddenType},
+		{"forged participant", `{"type":"participant_joining","payload":{}}`, "", ErrCodeForbiddenType},
+		{"bad base64", `{"type":"yjs_update","payload":{"update":"%%%"}}`, "", ErrCodeInvalidPayload},
+		{"payload as string", `{"type":"yjs_update","payload":"AQID"}`, "", ErrCodeInvalidPayload},
+		{"unknown field", `{"type":"ping","payload":{"extra":1}}`, "", ErrCodeInvalidPayload},
+		{"join without room", `{"type":"join_room","payload":{}}`, "", ErrCodeInvalidPayload},
+		{"negative resume", `{"type":"resume","payload":{"last_seq":-1}}`, "", ErrCodeInvalidPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgType, _, err := decodeMessage([]byte(tt.data))
+			if tt.wantCode == "" {
+				if err != nil || msgType != tt.wantType {
+					t.Fatalf("decodeMessage = %q, %v; want %q", msgType, err, tt.wantType)
+				}
+				return
+			}
+			var perr *protocolError
+			if !errors.As(err, &perr) || perr.code != tt.wantCode {
+				t.Fatalf("decodeMessage error = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestDecodeMessage_DecodesUpdate(t *testing.T) {
+	_, payload, err := decodeMessage([]byte(`{"type":"yjs_full_state","payload":{"update":"AQID","seq":4}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := payload.(*UpdatePayload)
+	if string(update.data) != "\x01\x02\x03" || update.Seq != 4 {
+		t.Fatalf("unexpected payload %+v", update)
+	}
+}
+
+func TestNegotiateProtocol(t *testing.T) {
+	tests := []struct {
+		offered     string
+		wantName    string
+		wantVersion int
+		wantOK      bool
+	}{
+		{"", "", ProtocolV1, true},
+		{"nonza.v1", "nonza.v1", ProtocolV1, true},
+		{"nonza.v9, nonza.v1", "nonza.v1", ProtocolV1, true},
+		{"nonza.v9", "", 0, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/ws", nil)
+		if tt.offered != "" {
+			r.Header.Set("Sec-WebSocket-Protocol", tt.offered)
+		}
+		name, version, ok := negotiateProtocol(r)
+		if name != tt.wantName || version != tt.wantVersion || ok != tt.wantOK {
+			t.Errorf("offered %q: got %q, %d, %v", tt.offered, name, version, ok)
+		}
+	}
+}
+
+func TestSchemaCoversRegistry(t *testing.T) {
+	defs := Schema()["$defs"].(map[string]any)
+	if len(defs) != len(registry) {
+		t.Fatalf("schema has %d definitions, registry %d", len(defs), len(registry))
+	}
+
+	update := defs[TypeYjsUpdate].(map[string]any)["properties"].(map[string]any)["payload"].(map[string]any)
+	if required := update["required"].([]string); len(required) != 1 || required[0] != "update" {
+		t.Fatalf("yjs_update payload requires %v, want [update]", required)
+	}
+}
@@ -0,0 +1,188 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"nonza/backend/internal/metrics"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	errQueueFull   = errors.New("send queue is full")
+	errQueueClosed = errors.New("send queue is closed")
+)
+
+// frame is one outbound websocket message
+type frame struct {
+	kind   int  // websocket.TextMessage or websocket.BinaryMessage
+	update bool // Y.js document or awareness update the client can recover by resyncing
+	data   []byte
+}
+
+// sendQueue is a client's outbound buffer, bounded by the bytes it holds rather than by message count.
+// Producers never block and never see a closed channel: after close, push reports errQueueClosed.
+// The single consumer (writePump) waits on ready and takes everything queued at once.
+type sendQueue struct {
+	mu       sync.Mutex
+	frames   []frame
+	bytes    int
+	maxBytes int
+	closed   bool
+	closeMsg []byte // close frame payload written after the remaining frames
+
+	ready chan struct{} // signalled (without blocking) whenever frames are added or the queue is closed
+}
+
+func newSendQueue(maxBytes int) *sendQueue {
+	return &sendQueue{
+		maxBytes: maxBytes,
+		ready:    make(chan struct{}, 1),
+	}
+}
+
+// push appends a frame unless it would take the queue over its byte limit.
+// A single frame larger than the limit is accepted into an empty queue so it can still be delivered.
+func (q *sendQueue) push(f frame) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return errQueueClosed
+	}
+	if q.bytes > 0 && q.bytes+len(f.data) > q.maxBytes {
+		return errQueueFull
+	}
+	q.frames = append(q.frames, f)
+	q.bytes += len(f.data)
+	q.signal()
+	return nil
+}
+
+// dropUpdates discards queued Y.js updates and returns how many were dropped
+func (q *sendQueue) dropUpdates() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	kept := q.frames[:0]
+	dropped := 0
+	q.bytes = 0
+	for _, f := range q.frames {
+		if f.update {
+			dropped++
+			continue
+		}
+		kept = append(kept, f)
+		q.bytes += len(f.data)
+	}
+	clear(q.frames[len(kept):])
+	q.frames = kept
+	return dropped
+}
+
+// close stops accepting frames. Frames already queued are still delivered, followed by a
+// close frame carrying closeMsg (nil for an empty close frame). Only the first call has effect.
+func (q *sendQueue) close(closeMsg []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.closeMsg = closeMsg
+	q.signal()
+}
+
+// take removes and returns every queued frame. closed reports that the queue was closed
+// and nothing more will follow the returned frames.
+func (q *sendQueue) take() (frames []frame, closed bool, closeMsg []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	frames = q.frames
+	q.frames = nil
+	q.bytes = 0
+	return frames, q.closed, q.closeMsg
+}
+
+func (q *sendQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// closeSlowConsumer is sent to clients that cannot keep up even after a resync; they should reconnect
+const closeSlowConsumer = 4008
+
+var slowConsumerCloseMessage = websocket.FormatCloseMessage(closeSlowConsumer, "slow consumer")
+
+// sendText queues a JSON message; reports whether it was accepted
+func (c *Client) sendText(data []byte) bool {
+	return c.enqueue(frame{kind: websocket.TextMessage, data: data})
+}
+
+// sendBinary queues a raw Y.js update; reports whether it was accepted
+func (c *Client) sendBinary(data []byte) bool {
+	if !c.enqueue(frame{kind: websocket.BinaryMessage, update: true, data: data}) {
+		return false
+	}
+	metrics.WSBytesRelayed.Add(float64(len(data)))
+	return true
+}
+
+// sendUpdate queues a sequenced yjs_update message; reports whether it was accepted
+func (c *Client) sendUpdate(data []byte) bool {
+	if !c.enqueue(frame{kind: websocket.TextMessage, update: true, data: data}) {
+		return false
+	}
+	metrics.WSBytesRelayed.Add(float64(len(data)))
+	return true
+}
+
+func (c *Client) enqueue(f frame) bool {
+	err := c.queue.push(f)
+	if errors.Is(err, errQueueFull) {
+		c.handleSlowConsumer(f)
+		return false
+	}
+	return err == nil
+}
+
+// handleSlowConsumer runs when the client's queue is full. Dropping a Y.js update would leave the
+// document diverged, so the first time the client loses its queued updates and is asked to resync
+// (resume from its last sequence number); if it overflows again before that, or a message that
+// cannot be recovered doesn't fit, it is disconnected.
+func (c *Client) handleSlowConsumer(f frame) {
+	if f.update && c.resyncing.CompareAndSwap(false, true) {
+		dropped := c.queue.dropUpdates() + 1
+		metrics.WSDroppedMessages.WithLabelValues(metrics.ChannelUpdate).Add(float64(dropped))
+		metrics.WSSlowConsumers.WithLabelValues(metrics.SlowConsumerResync).Inc()
+		c.log.Warn("Client fell behind, requesting resync", slog.Int("dropped", dropped))
+
+		data, _ := json.Marshal(Message{Type: TypeResyncRequired, RoomID: c.roomID})
+		if c.queue.push(frame{kind: websocket.TextMessage, data: data}) == nil {
+			return
+		}
+	}
+
+	metrics.WSDroppedMessages.WithLabelValues(channelOf(f)).Inc()
+	metrics.WSSlowConsumers.WithLabelValues(metrics.SlowConsumerDisconnect).Inc()
+	c.log.Warn("Disconnecting slow client", slog.Int("bytes", len(f.data)))
+	c.queue.close(slowConsumerCloseMessage)
+}
+
+func channelOf(f frame) string {
+	switch {
+	case f.update:
+		return metrics.ChannelUpdate
+	case f.kind == websocket.BinaryMessage:
+		return metrics.ChannelBinary
+	default:
+		return metrics.ChannelText
+	}
+}
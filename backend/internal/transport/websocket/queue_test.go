@@ -0,0 +1,61 @@
+package websocket
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSendQueue_ByteLimit(t *testing.T) {
+	q := newSendQueue(10)
+
+	if err := q.push(frame{kind: websocket.BinaryMessage, data: make([]byte, 32)}); err != nil {
+		t.Fatalf("oversized frame into empty queue: %v", err)
+	}
+	if err := q.push(frame{kind: websocket.TextMessage, data: []byte("x")}); !errors.Is(err, errQueueFull) {
+		t.Fatalf("push over the limit = %v, want errQueueFull", err)
+	}
+
+	frames, closed, _ := q.take()
+	if len(frames) != 1 || closed {
+		t.Fatalf("take = %d frames, closed %v", len(frames), closed)
+	}
+	if err := q.push(frame{kind: websocket.TextMessage, data: []byte("x")}); err != nil {
+		t.Fatalf("push after take: %v", err)
+	}
+}
+
+func TestSendQueue_DropUpdatesKeepsMessages(t *testing.T) {
+	q := newSendQueue(1 << 10)
+	q.push(frame{kind: websocket.TextMessage, data: []byte("a")})
+	q.push(frame{kind: websocket.BinaryMessage, update: true, data: []byte("bb")})
+	q.push(frame{kind: websocket.TextMessage, data: []byte("c")})
+	q.push(frame{kind: websocket.TextMessage, update: true, data: []byte("dd")})
+
+	if dropped := q.dropUpdates(); dropped != 2 {
+		t.Fatalf("dropped %d, want 2", dropped)
+	}
+	if q.bytes != 2 {
+		t.Fatalf("bytes = %d, want 2", q.bytes)
+	}
+	frames, _, _ := q.take()
+	if len(frames) != 2 || string(frames[0].data) != "a" || string(frames[1].data) != "c" {
+		t.Fatalf("unexpected frames after drop: %+v", frames)
+	}
+}
+
+func TestSendQueue_CloseKeepsQueuedFrames(t *testing.T) {
+	q := newSendQueue(1 << 10)
+	q.push(frame{kind: websocket.TextMessage, data: []byte("bye")})
+	q.close([]byte("first"))
+	q.close([]byte("second"))
+
+	if err := q.push(frame{kind: websocket.TextMessage, data: []byte("late")}); !errors.Is(err, errQueueClosed) {
+		t.Fatalf("push after close = %v, want errQueueClosed", err)
+	}
+	frames, closed, closeMsg := q.take()
+	if len(frames) != 1 || !closed || string(closeMsg) != "first" {
+		t.Fatalf("take = %d frames, closed %v, close message %q", len(frames), closed, closeMsg)
+	}
+}
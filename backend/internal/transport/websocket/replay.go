@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+
+	"nonza/backend/internal/repository/redis"
+)
+
+// relayLockStripes is the number of locks rooms are spread over to keep their updates in sequence order
+const relayLockStripes = 64
+
+// syncAck describes what a client received in answer to yjs_sync or resume
+type syncAck struct {
+	Seq       int64 // sequence number the client is caught up to
+	Replayed  int   // updates replayed from the log
+	FullState bool  // whether the stored document state was sent
+	HasState  bool  // whether the server had any document state
+}
+
+// roomLock serializes sequencing and delivery of a room's updates, so every client receives
+// them in sequence order and replays never interleave with live updates
+func (h *Hub) roomLock(roomID string) *sync.Mutex {
+	hash := fnv.New32a()
+	hash.Write([]byte(roomID))
+	return &h.relayLocks[hash.Sum32()%relayLockStripes]
+}
+
+// RelayDocumentUpdate numbers a Y.js document update, appends it to the room's replay log and
+// sends it to every client in the room except sender, who gets a yjs_update_ack with the number.
+// Returns the sequence number, or 0 if Redis was unavailable and the update went out unsequenced.
+func (h *Hub) RelayDocumentUpdate(roomID string, data []byte, sender *Client) int64 {
+	mu := h.roomLock(roomID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	ctx, cancel := h.operationContext()
+	defer cancel()
+
+	seq, err := h.redisClient.AppendDocumentUpdate(ctx, roomID, data, h.cfg.ReplayBufferSize, h.cfg.ReplayTTL)
+	if err != nil {
+		h.log.Error("Failed to log document update, relaying without sequence number",
+			slog.String("room_id", roomID), slog.Any("error", err))
+		h.BroadcastBinaryToRoom(roomID, data, sender)
+		return 0
+	}
+
+	message, err := updateMessage(roomID, seq, data)
+	if err != nil {
+		return 0
+	}
+	for _, client := range h.roomRecipients(roomID, sender) {
+		client.sendUpdate(message)
+	}
+
+	if sender != nil {
+		ack, _ := json.Marshal(Message{
+			Type:    TypeYjsUpdateAck,
+			RoomID:  roomID,
+			Payload: SeqPayload{Seq: seq},
+		})
+		sender.sendText(ack)
+	}
+	h.documentUpdated(roomID, seq)
+	return seq
+}
+
+// syncClient brings client up to date. With resume set it first tries to replay the updates after
+// lastSeq from the log; if the log no longer reaches back that far (or the client is new) it sends
+// the stored document state followed by the updates logged since that state was taken.
+func (h *Hub) syncClient(ctx context.Context, client *Client, lastSeq int64, resume bool) (syncAck, error) {
+	mu := h.roomLock(client.roomID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	seq, err := h.redisClient.DocumentSeq(ctx, client.roomID)
+	if err != nil {
+		return syncAck{}, err
+	}
+
+	if resume && lastSeq <= seq {
+		updates, err := h.redisClient.DocumentUpdatesSince(ctx, client.roomID, lastSeq)
+		if err != nil {
+			return syncAck{}, err
+		}
+		if continuous(updates, lastSeq, seq) {
+			h.replay(client, updates)
+			return syncAck{Seq: seq, Replayed: len(updates), HasState: true}, nil
+		}
+		client.log.Info("Replay log does not cover the gap, sending full state",
+			slog.Int64("last_seq", lastSeq), slog.Int64("seq", seq))
+	}
+
+	docState, err := h.redisClient.GetDocumentState(ctx, client.roomID)
+	if err != nil {
+		return syncAck{}, err
+	}
+	stateSeq, err := h.redisClient.DocumentStateSeq(ctx, client.roomID)
+	if err != nil {
+		return syncAck{}, err
+	}
+	updates, err := h.redisClient.DocumentUpdatesSince(ctx, client.roomID, stateSeq)
+	if err != nil {
+		return syncAck{}, err
+	}
+
+	hasState := len(docState) > 0
+	if hasState && client.sendBinary(docState) {
+		client.log.Debug("Sent document state", slog.Int("bytes", len(docState)))
+	}
+	h.replay(client, updates)
+
+	if !continuous(updates, stateSeq, seq) {
+		// Neither the stored state nor the log has everything: peers hold the rest and push it as full state
+		h.broadcastToRoomExcluding(client.roomID, Message{Type: TypeFullStateRequest, RoomID: client.roomID}, client)
+	}
+
+	return syncAck{Seq: seq, Replayed: len(updates), FullState: true, HasState: hasState || len(updates) > 0}, nil
+}
+
+func (h *Hub) replay(client *Client, updates []redis.DocumentUpdate) {
+	for _, update := range updates {
+		message, err := updateMessage(client.roomID, update.Seq, update.Data)
+		if err != nil {
+			continue
+		}
+		if !client.sendUpdate(message) {
+			return
+		}
+	}
+	if len(updates) > 0 {
+		client.log.Debug("Replayed document updates", slog.Int("updates", len(updates)))
+	}
+}
+
+// continuous reports whether updates pick up right after the sequence number after and reach seq
+func continuous(updates []redis.DocumentUpdate, after, seq int64) bool {
+	if len(updates) == 0 {
+		return after == seq
+	}
+	return updates[0].Seq == after+1
+}
+
+func updateMessage(roomID string, seq int64, data []byte) ([]byte, error) {
+	return json.Marshal(Message{
+		Type:   TypeYjsUpdate,
+		RoomID: roomID,
+		Payload: UpdatePayload{
+			Update: base64.StdEncoding.EncodeToString(data),
+			Seq:    seq,
+		},
+	})
+}
@@ -0,0 +1,38 @@
+package websocket
+
+import (
+	"testing"
+
+	"nonza/backend/internal/repository/redis"
+)
+
+func TestContinuous(t *testing.T) {
+	updates := func(seqs ...int64) []redis.DocumentUpdate {
+		out := make([]redis.DocumentUpdate, len(seqs))
+		for i, seq := range seqs {
+			out[i] = redis.DocumentUpdate{Seq: seq}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name       string
+		updates    []redis.DocumentUpdate
+		after, seq int64
+		want       bool
+	}{
+		{"up to date", nil, 7, 7, true},
+		{"no updates yet", nil, 0, 0, true},
+		{"missed updates in log", updates(8, 9), 7, 9, true},
+		{"log trimmed past the gap", updates(12, 13), 7, 13, false},
+		{"log expired", nil, 7, 9, false},
+		{"sequence reset", nil, 40, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := continuous(tt.updates, tt.after, tt.seq); got != tt.want {
+				t.Errorf("continuous = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Schema returns a JSON Schema (draft 2020-12) of the messages of the current protocol version,
+// generated from the payload structs in the registry
+func Schema() map[string]any {
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+
+	defs := make(map[string]any, len(types))
+	refs := make([]any, 0, len(types))
+	for _, t := range types {
+		kind := registry[t]
+		defs[t] = map[string]any{
+			"description": kind.description,
+			"type":        "object",
+			"properties": map[string]any{
+				"type":    map[string]any{"const": t},
+				"room_id": map[string]any{"type": "string"},
+				"user_id": map[string]any{"type": "string"},
+				"payload": structSchema(reflect.TypeOf(kind.payload()).Elem()),
+			},
+			"required":    []string{"type"},
+			"x-direction": kind.origin.String(),
+		}
+		refs = append(refs, map[string]any{"$ref": "#/$defs/" + t})
+	}
+
+	return map[string]any{
+		"$schema":        "https://json-schema.org/draft/2020-12/schema",
+		"$id":            fmt.Sprintf("nonza.v%d", CurrentProtocolVersion),
+		"title":          "Nonza websocket protocol",
+		"x-version":      CurrentProtocolVersion,
+		"x-subprotocols": supportedSubprotocols(),
+		"oneOf":          refs,
+		"$defs":          defs,
+	}
+}
+
+// structSchema describes the exported, JSON-tagged fields of a payload struct.
+// Fields without omitempty are required.
+func structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		properties[name] = fieldSchema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func fieldSchema(t reflect.Type) map[string]any {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": fieldSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return map[string]any{}
+	}
+}
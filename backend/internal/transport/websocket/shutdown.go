@@ -0,0 +1,158 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"nonza/backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// finalStateWindow is how long clients get to send a last yjs_full_state after server_shutdown
+	finalStateWindow = 2 * time.Second
+
+	// Clients are told to reconnect after a random delay in this range so they don't all hit the next instance at once
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 5 * time.Second
+)
+
+// shutdownCloseMessage tells clients the server is restarting (1012) and they should reconnect
+var shutdownCloseMessage = websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server shutdown")
+
+// join registers a client with the Run loop. Returns false once the hub has stopped.
+func (h *Hub) join(client *Client) bool {
+	select {
+	case h.register <- client:
+		return true
+	case <-h.stopped:
+		return false
+	}
+}
+
+// leave unregisters a client; after the hub has stopped there is nothing left to unregister from
+func (h *Hub) leave(client *Client) {
+	select {
+	case h.unregister <- client:
+	case <-h.stopped:
+	}
+}
+
+// Shutdown refuses new connections, tells connected clients to reconnect elsewhere,
+// persists document state and disconnects everyone with a service-restart close code.
+// It returns once the Run loop has stopped or ctx expires.
+func (h *Hub) Shutdown(ctx context.Context) {
+	if !h.closing.CompareAndSwap(false, true) {
+		return
+	}
+
+	clients := h.snapshotClients()
+	h.log.Info("Shutting down websocket hub", slog.Int("clients", len(clients)))
+
+	for _, client := range clients {
+		h.notifyShutdown(client)
+	}
+
+	// Give clients a moment to push their final document state
+	if len(clients) > 0 {
+		select {
+		case <-time.After(finalStateWindow):
+		case <-ctx.Done():
+		}
+	}
+
+	h.flushDocuments(ctx)
+
+	// Clients that registered after the first pass are disconnected too. Closing the queue
+	// first makes the service-restart frame win over the plain close done by unregister.
+	for _, client := range h.snapshotClients() {
+		client.queue.close(shutdownCloseMessage)
+		select {
+		case h.unregister <- client:
+		case <-ctx.Done():
+		}
+	}
+
+	close(h.quit)
+	select {
+	case <-h.stopped:
+		h.log.Info("Websocket hub stopped")
+	case <-ctx.Done():
+		h.log.Warn("Websocket hub did not stop before the shutdown deadline")
+	}
+}
+
+func (h *Hub) snapshotClients() []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+func (h *Hub) notifyShutdown(client *Client) {
+	delay := minReconnectDelay + rand.N(maxReconnectDelay-minReconnectDelay)
+	data, err := json.Marshal(Message{
+		Type:    TypeServerShutdown,
+		RoomID:  client.roomID,
+		Payload: ShutdownPayload{ReconnectAfterMs: delay.Milliseconds()},
+	})
+	if err != nil {
+		return
+	}
+	client.sendText(data)
+}
+
+// flushDocuments waits for document stores in flight and copies the Redis state of every
+// room with connected clients to its snapshot in Postgres, so nothing is lost if Redis is not
+// persistent: clients of a room whose Redis state is gone are sent the snapshot instead
+func (h *Hub) flushDocuments(ctx context.Context) {
+	h.storeMu.Lock()
+	defer h.storeMu.Unlock()
+
+	h.mu.RLock()
+	roomIDs := make([]string, 0, len(h.rooms))
+	for roomID := range h.rooms {
+		roomIDs = append(roomIDs, roomID)
+	}
+	h.mu.RUnlock()
+
+	flushed := 0
+	for _, roomID := range roomIDs {
+		roomUUID, err := uuid.Parse(roomID)
+		if err != nil {
+			continue
+		}
+
+		state, err := h.redisClient.GetDocumentState(ctx, roomID)
+		if err != nil {
+			h.log.Error("Failed to load document state for shutdown snapshot", slog.String("room_id", roomID), slog.Any("error", err))
+			continue
+		}
+		if len(state) == 0 {
+			continue
+		}
+
+		if err := h.snapshots.Save(ctx, &models.DocumentSnapshot{
+			RoomID: roomUUID,
+			State:  state,
+			Size:   len(state),
+		}); err != nil {
+			h.log.Error("Failed to snapshot document at shutdown", slog.String("room_id", roomID), slog.Any("error", err))
+			continue
+		}
+		flushed++
+	}
+
+	if flushed > 0 {
+		h.log.Info("Snapshotted documents at shutdown", slog.Int("rooms", flushed))
+	}
+}
@@ -1,11 +1,15 @@
 package livekit
 
 import (
+	"context"
 	"nonza/backend/internal/config"
 
 	"github.com/livekit/protocol/auth"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("nonza/backend/internal/webrtc/livekit")
+
 type Client struct {
 	apiKey    string
 	apiSecret string
@@ -20,16 +24,19 @@ func NewClient(cfg *config.Config) *Client {
 	}
 }
 
-func (c *Client) GenerateAccessToken(roomName, participantIdentity string, participantName string) (string, error) {
+func (c *Client) GenerateAccessToken(ctx context.Context, roomName, participantIdentity string, participantName string) (string, error) {
+	_, span := tracer.Start(ctx, "livekit.GenerateAccessToken")
+	defer span.End()
+
 	at := auth.NewAccessToken(c.apiKey, c.apiSecret)
 	canPublish := true
 	canSubscribe := true
 	canUpdateOwnMetadata := true
 	grant := &auth.VideoGrant{
-		RoomJoin:            true,
-		Room:                roomName,
-		CanPublish:          &canPublish,
-		CanSubscribe:        &canSubscribe,
+		RoomJoin:             true,
+		Room:                 roomName,
+		CanPublish:           &canPublish,
+		CanSubscribe:         &canSubscribe,
 		CanUpdateOwnMetadata: &canUpdateOwnMetadata,
 	}
 
@@ -0,0 +1,111 @@
+package livekit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	lkproto "github.com/livekit/protocol/livekit"
+	"github.com/twitchtv/twirp"
+)
+
+const apiTimeout = 10 * time.Second
+
+// DeleteRoom ends the LiveKit room, disconnecting every participant still in it.
+// A room that does not exist on the server is not an error.
+func (c *Client) DeleteRoom(ctx context.Context, roomName string) error {
+	ctx, cancel, err := c.adminContext(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	_, err = c.roomService().DeleteRoom(ctx, &lkproto.DeleteRoomRequest{Room: roomName})
+	var twerr twirp.Error
+	if errors.As(err, &twerr) && twerr.Code() == twirp.NotFound {
+		return nil
+	}
+	return err
+}
+
+// UpdateRoomMetadata replaces the metadata of the LiveKit room, which is sent to every
+// participant in it. A room that does not exist on the server yet is not an error.
+func (c *Client) UpdateRoomMetadata(ctx context.Context, roomName, metadata string) error {
+	ctx, cancel, err := c.adminContext(ctx, roomName)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	_, err = c.roomService().UpdateRoomMetadata(ctx, &lkproto.UpdateRoomMetadataRequest{Room: roomName, Metadata: metadata})
+	var twerr twirp.Error
+	if errors.As(err, &twerr) && twerr.Code() == twirp.NotFound {
+		return nil
+	}
+	return err
+}
+
+// Ping checks that the LiveKit server answers on its HTTP port
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpURL(c.url), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("livekit responded with %s", resp.Status)
+	}
+	return nil
+}
+
+func (c *Client) roomService() lkproto.RoomService {
+	return lkproto.NewRoomServiceProtobufClient(httpURL(c.url), &http.Client{Timeout: apiTimeout})
+}
+
+// adminContext returns a request context authorized to manage roomName through the server API
+func (c *Client) adminContext(ctx context.Context, roomName string) (context.Context, context.CancelFunc, error) {
+	at := auth.NewAccessToken(c.apiKey, c.apiSecret)
+	at.AddGrant(&auth.VideoGrant{
+		Room:       roomName,
+		RoomAdmin:  true,
+		RoomCreate: true,
+	}).SetValidFor(time.Minute)
+
+	token, err := at.ToJWT()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer "+token)
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	ctx, err = twirp.WithHTTPRequestHeaders(ctx, header)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return ctx, cancel, nil
+}
+
+// httpURL converts the signalling URL (ws/wss) to the server API URL (http/https)
+func httpURL(url string) string {
+	switch {
+	case strings.HasPrefix(url, "wss://"):
+		return "https://" + strings.TrimPrefix(url, "wss://")
+	case strings.HasPrefix(url, "ws://"):
+		return "http://" + strings.TrimPrefix(url, "ws://")
+	default:
+		return url
+	}
+}
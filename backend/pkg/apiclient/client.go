@@ -0,0 +1,134 @@
+// Package apiclient is a Go client of the Nonza REST API. Its types and methods are generated
+// from the OpenAPI document served at /api/v1/openapi.json; Version is that document's version.
+// Event streams and CSV exports are not covered.
+package apiclient
+
+//go:generate go run ../../cmd/apigen -spec ../../internal/openapi/openapi.json -out client_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls the API of one Nonza server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	adminToken string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests through the given client instead of http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAdminToken authenticates requests with the server's admin token, which the admin,
+// audit and organization listing operations require
+func WithAdminToken(token string) Option {
+	return func(c *Client) {
+		c.adminToken = token
+	}
+}
+
+// New returns a client of the server at baseURL, e.g. https://nonza.example.com
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey returns a context whose calls carry the Idempotency-Key header. Retrying a
+// call with the same key replays its first successful response instead of repeating the change.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// Ptr returns a pointer to v, for optional parameters
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Error makes a problem returned by the API an error. Branch on Code, which is stable.
+func (p *Problem) Error() string {
+	detail := p.Detail
+	if detail == "" {
+		detail = p.Title
+	}
+	return fmt.Sprintf("nonza: %s (%d): %s", p.Code, p.Status, detail)
+}
+
+// do sends a request and decodes a successful response into out. A failed one is returned
+// as a *Problem.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	target := c.baseURL + basePath + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "nonza-apiclient/"+Version)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+	if key, ok := ctx.Value(idempotencyKey{}).(string); ok && method != http.MethodGet {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := &Problem{}
+		if err := json.NewDecoder(resp.Body).Decode(problem); err != nil || problem.Code == "" {
+			// Not from the API itself, e.g. a proxy in front of it
+			problem = &Problem{Code: "http_error", Title: http.StatusText(resp.StatusCode)}
+		}
+		problem.Status = resp.StatusCode
+		return problem
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,727 @@
+// Code generated by apigen from the OpenAPI document; DO NOT EDIT.
+
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Version is the version of the API document the client was generated from
+const Version = "1.5.2"
+
+// basePath is where the API is served, relative to the base URL
+const basePath = "/api/v1"
+
+type AuditEvent struct {
+	ID             string                 `json:"id"`
+	OccurredAt     time.Time              `json:"occurred_at"`
+	OrganizationID string                 `json:"organization_id"`
+	ActorType      string                 `json:"actor_type"`
+	ActorID        string                 `json:"actor_id,omitempty"`
+	Action         string                 `json:"action"`
+	TargetType     string                 `json:"target_type,omitempty"`
+	TargetID       string                 `json:"target_id,omitempty"`
+	Status         int                    `json:"status"` // HTTP status of the call
+	IP             string                 `json:"ip,omitempty"`
+	UserAgent      string                 `json:"user_agent,omitempty"`
+	RequestID      string                 `json:"request_id,omitempty"`
+	Diff           map[string]FieldChange `json:"diff,omitempty"`
+}
+
+// AuditEventPage: A page of audit events
+type AuditEventPage struct {
+	Items      []AuditEvent `json:"items"`
+	NextCursor string       `json:"next_cursor"` // Passed as cursor to get the next page; empty on the last one
+}
+
+type CreateOrganizationRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Slug        string `json:"slug,omitempty"` // Lowercase latin letters, digits and single hyphens, 2 to 64 characters
+}
+
+type CreateRoomRequest struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug,omitempty"` // Lowercase latin letters, digits and single hyphens, 2 to 64 characters. Permanent rooms without one get a slug made from the name, transliterated from Cyrillic
+	RoomType    string `json:"room_type"`
+	IsTemporary bool   `json:"is_temporary,omitempty"`
+	ExpiresIn   string `json:"expires_in,omitempty"`   // Lifetime of a temporary room as a Go duration, e.g. 2h30m
+	E2EEEnabled bool   `json:"e2ee_enabled,omitempty"` // Generate an end-to-end encryption key, handed out with tokens
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"` // https URL of a public host; http and local addresses are only accepted with ENV=local
+	Events []string `json:"events"`
+}
+
+type Delivery struct {
+	ID             string     `json:"id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // Only while pending
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type FieldChange struct {
+	Old json.RawMessage `json:"old,omitempty"` // The value before; unset on creation
+	New json.RawMessage `json:"new,omitempty"` // The value after; unset on deletion
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type GenerateTokenRequest struct {
+	ShortCode       string `json:"short_code"`                 // Short code of the room to join
+	ParticipantID   string `json:"participant_id,omitempty"`   // Identity in the call; generated when omitted
+	ParticipantName string `json:"participant_name,omitempty"` // Name shown to the other participants
+}
+
+type ICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
+
+type Job struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"` // Cron schedule
+}
+
+type JobRun struct {
+	ID            string     `json:"id"`
+	JobName       string     `json:"job_name"`
+	Target        string     `json:"target,omitempty"` // What the run is for, e.g. the organization being deleted
+	Trigger       string     `json:"trigger"`
+	Instance      string     `json:"instance"`
+	Status        string     `json:"status"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	RoomsAffected int        `json:"rooms_affected"`
+	Error         string     `json:"error,omitempty"`
+}
+
+type Organization struct {
+	ID                   string      `json:"id"`
+	Name                 string      `json:"name"`
+	Description          string      `json:"description"`
+	Slug                 string      `json:"slug,omitempty"` // Names the organization in vanity links of its rooms, /r/{org}/{slug}
+	ArchiveRetentionDays int         `json:"archive_retention_days,omitempty"`
+	WSLimits             *WSLimits   `json:"ws_limits,omitempty"`
+	ShortCodes           *ShortCodes `json:"short_codes,omitempty"`
+	DeletionRequestedAt  *time.Time  `json:"deletion_requested_at,omitempty"` // Set while the organization is being deleted
+	CreatedAt            time.Time   `json:"created_at"`
+	UpdatedAt            time.Time   `json:"updated_at"`
+}
+
+// OrganizationPage: A page of organizations
+type OrganizationPage struct {
+	Items      []Organization `json:"items"`
+	NextCursor string         `json:"next_cursor"` // Passed as cursor to get the next page; empty on the last one
+}
+
+type Participant struct {
+	ID            string     `json:"id"`
+	RoomID        string     `json:"room_id"`
+	UserID        string     `json:"user_id,omitempty"`
+	AnonymousID   string     `json:"anonymous_id,omitempty"`
+	Role          string     `json:"role"`
+	IsMainSpeaker bool       `json:"is_main_speaker"`
+	JoinedAt      time.Time  `json:"joined_at"`
+	LeftAt        *time.Time `json:"left_at,omitempty"` // Unset while the participant is in the room
+}
+
+// ParticipantPage: A page of participants
+type ParticipantPage struct {
+	Items      []Participant `json:"items"`
+	NextCursor string        `json:"next_cursor"` // Passed as cursor to get the next page; empty on the last one
+}
+
+type Presence struct {
+	RoomID string         `json:"room_id"`
+	Users  []PresenceUser `json:"users"`
+}
+
+type PresenceUser struct {
+	UserID       string    `json:"user_id"`
+	DisplayName  string    `json:"display_name,omitempty"`
+	Role         string    `json:"role"`
+	Device       string    `json:"device"`
+	Connections  int       `json:"connections"` // Open connections of the user
+	ConnectedAt  time.Time `json:"connected_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+}
+
+// Problem: RFC 7807 problem details, returned as application/problem+json for every error
+type Problem struct {
+	Type      string       `json:"type"` // urn:nonza:problem:<code>
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"` // Stable error code to branch on
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"` // The offending fields of a validation error
+}
+
+type Room struct {
+	ID              string     `json:"id"`
+	OrganizationID  string     `json:"organization_id"`
+	Name            string     `json:"name"`
+	Slug            string     `json:"slug,omitempty"` // Names the room in its organization's vanity link, /r/{org}/{slug}
+	ShortCode       *string    `json:"short_code"`
+	RoomType        string     `json:"room_type"`
+	IsTemporary     bool       `json:"is_temporary"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	Status          string     `json:"status"`
+	ArchivedAt      *time.Time `json:"archived_at,omitempty"`
+	LiveKitRoomName string     `json:"livekit_room_name"`
+	E2EEEnabled     bool       `json:"e2ee_enabled"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// RoomEvent: The data of an event of the room event stream
+type RoomEvent struct {
+	RoomID     string          `json:"room_id"`
+	Type       string          `json:"type"` // The event type, e.g. participant.joined
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"` // The event's payload, shaped by its type
+}
+
+// RoomPage: A page of rooms
+type RoomPage struct {
+	Items      []Room `json:"items"`
+	NextCursor string `json:"next_cursor"` // Passed as cursor to get the next page; empty on the last one
+}
+
+// ShortCodes: Format of the short codes of the organization's new rooms; omitted fields take the strategy's default. Omitted from an update, the server default (random, 10 lowercase letters) is used again.
+type ShortCodes struct {
+	Strategy  string   `json:"strategy"`             // random draws characters from an alphabet (abc-defg-hij), words joins words from a list (amber-fox-river)
+	Length    int      `json:"length,omitempty"`     // random: number of characters, 10 by default
+	Alphabet  string   `json:"alphabet,omitempty"`   // random: distinct lowercase latin letters or digits to draw from, a-z by default
+	Words     []string `json:"words,omitempty"`      // words: lowercase latin words of 2 to 12 letters to draw from instead of the built-in list
+	WordCount int      `json:"word_count,omitempty"` // words: words per code, 3 by default
+}
+
+type Token struct {
+	Token         string      `json:"token"` // LiveKit access token
+	URL           string      `json:"url"`   // LiveKit server to connect to
+	RoomName      string      `json:"room_name"`
+	ParticipantID string      `json:"participant_id"`
+	EncryptionKey string      `json:"encryption_key,omitempty"` // The room's end-to-end encryption key; only for rooms with E2EE
+	ICEServers    []ICEServer `json:"ice_servers,omitempty"`    // TURN servers with short-lived credentials
+}
+
+type UpdateOrganizationRequest struct {
+	Name                 string      `json:"name"`
+	Description          string      `json:"description,omitempty"`
+	Slug                 string      `json:"slug,omitempty"`                   // Lowercase latin letters, digits and single hyphens, 2 to 64 characters; omitting it removes the slug, and links with the old one stop working
+	ArchiveRetentionDays int         `json:"archive_retention_days,omitempty"` // Days archived rooms are kept; the server default when omitted
+	WSLimits             *WSLimits   `json:"ws_limits,omitempty"`
+	ShortCodes           *ShortCodes `json:"short_codes,omitempty"`
+}
+
+// UpdateRoomRequest: Omitted or null fields keep their value
+type UpdateRoomRequest struct {
+	Name        *string    `json:"name,omitempty"`
+	Slug        *string    `json:"slug,omitempty"` // Empty removes the slug. Links with the former slug redirect to the room until another room takes it
+	RoomType    *string    `json:"room_type,omitempty"`
+	IsTemporary *bool      `json:"is_temporary,omitempty"` // false makes the room permanent and clears its expiry
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`   // New expiry of a temporary room, in the future
+	E2EEEnabled *bool      `json:"e2ee_enabled,omitempty"` // Turning encryption on generates a new key; participants need a new token
+}
+
+type UpdateWebhookRequest struct {
+	URL     string   `json:"url"` // https URL of a public host; http and local addresses are only accepted with ENV=local
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+}
+
+// WSLimits: Overrides of the server's websocket limits for the organization's rooms; omitted fields keep the default
+type WSLimits struct {
+	MessagesPerSecond int `json:"messages_per_second,omitempty"`  // Messages a client may send per second
+	BytesPerSecond    int `json:"bytes_per_second,omitempty"`     // Bytes a client may send per second
+	MaxMessageBytes   int `json:"max_message_bytes,omitempty"`    // Largest message a client may send
+	MaxClientsPerRoom int `json:"max_clients_per_room,omitempty"` // Most clients connected to one room
+	MaxDocumentBytes  int `json:"max_document_bytes,omitempty"`   // Largest shared document of a room
+}
+
+type Webhook struct {
+	ID             string    `json:"id"`
+	OrganizationID string    `json:"organization_id"`
+	URL            string    `json:"url"`
+	Events         []string  `json:"events"`
+	Enabled        bool      `json:"enabled"`
+	Secret         string    `json:"secret,omitempty"` // Signing secret; only returned when the endpoint is created
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// GetJobRun: Get a run of a background job (GET /admin/job-runs/{runID}).
+func (c *Client) GetJobRun(ctx context.Context, runID string) (*JobRun, error) {
+	var out JobRun
+	if err := c.do(ctx, http.MethodGet, "/admin/job-runs/"+url.PathEscape(runID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListJobs: List the background jobs (GET /admin/jobs).
+func (c *Client) ListJobs(ctx context.Context) ([]Job, error) {
+	var out []Job
+	if err := c.do(ctx, http.MethodGet, "/admin/jobs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RunJob: Run a background job now (POST /admin/jobs/{name}/run).
+func (c *Client) RunJob(ctx context.Context, name string) (*JobRun, error) {
+	var out JobRun
+	if err := c.do(ctx, http.MethodPost, "/admin/jobs/"+url.PathEscape(name)+"/run", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListJobRunsParams are the query parameters of ListJobRuns
+type ListJobRunsParams struct {
+	Limit int // How many runs to return; 20 by default, at most 100
+}
+
+// ListJobRuns: List the latest runs of a background job (GET /admin/jobs/{name}/runs).
+func (c *Client) ListJobRuns(ctx context.Context, name string, params *ListJobRunsParams) ([]JobRun, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var out []JobRun
+	if err := c.do(ctx, http.MethodGet, "/admin/jobs/"+url.PathEscape(name)+"/runs", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetOpenAPI: Get this document (GET /openapi.json).
+func (c *Client) GetOpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var out json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/openapi.json", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListAuditEventsParams are the query parameters of ListAuditEvents
+type ListAuditEventsParams struct {
+	Action     string     // Only events of this action, e.g. room.create
+	Actor      string     // Only events of this actor
+	TargetType string     // Only events on targets of this type
+	TargetID   string     // Only events on this target
+	From       *time.Time // Only events at or after this time
+	To         *time.Time // Only events before this time
+	Format     string     // json for a page, csv for an export of every matching event
+	Limit      int        // Page size; 50 by default, at most 500
+	Sort       string     // Field to sort by, prefixed with - for descending order
+	Cursor     string     // next_cursor of the previous page; only valid with the same sort
+}
+
+// ListAuditEvents: List the audit log of an organization (GET /org/{id}/audit).
+func (c *Client) ListAuditEvents(ctx context.Context, id string, params *ListAuditEventsParams) (*AuditEventPage, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Action != "" {
+			query.Set("action", params.Action)
+		}
+		if params.Actor != "" {
+			query.Set("actor", params.Actor)
+		}
+		if params.TargetType != "" {
+			query.Set("target_type", params.TargetType)
+		}
+		if params.TargetID != "" {
+			query.Set("target_id", params.TargetID)
+		}
+		if params.From != nil {
+			query.Set("from", params.From.Format(time.RFC3339Nano))
+		}
+		if params.To != nil {
+			query.Set("to", params.To.Format(time.RFC3339Nano))
+		}
+		if params.Format != "" {
+			query.Set("format", params.Format)
+		}
+		if params.Limit != 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Sort != "" {
+			query.Set("sort", params.Sort)
+		}
+		if params.Cursor != "" {
+			query.Set("cursor", params.Cursor)
+		}
+	}
+	var out AuditEventPage
+	if err := c.do(ctx, http.MethodGet, "/org/"+url.PathEscape(id)+"/audit", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRoomsParams are the query parameters of ListRooms
+type ListRoomsParams struct {
+	RoomType    string // Only rooms of this type
+	Name        string // Only rooms whose name starts with this prefix
+	IsTemporary *bool  // Only temporary or only permanent rooms
+	Expired     *bool  // Only rooms whose expiry has or has not passed
+	Limit       int    // Page size; 50 by default, at most 200
+	Sort        string // Field to sort by, prefixed with - for descending order
+	Cursor      string // next_cursor of the previous page; only valid with the same sort
+}
+
+// ListRooms: List the active rooms of an organization (GET /org/{id}/rooms).
+func (c *Client) ListRooms(ctx context.Context, id string, params *ListRoomsParams) (*RoomPage, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.RoomType != "" {
+			query.Set("room_type", params.RoomType)
+		}
+		if params.Name != "" {
+			query.Set("name", params.Name)
+		}
+		if params.IsTemporary != nil {
+			query.Set("is_temporary", strconv.FormatBool(*params.IsTemporary))
+		}
+		if params.Expired != nil {
+			query.Set("expired", strconv.FormatBool(*params.Expired))
+		}
+		if params.Limit != 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Sort != "" {
+			query.Set("sort", params.Sort)
+		}
+		if params.Cursor != "" {
+			query.Set("cursor", params.Cursor)
+		}
+	}
+	var out RoomPage
+	if err := c.do(ctx, http.MethodGet, "/org/"+url.PathEscape(id)+"/rooms", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateRoom: Create a room (POST /org/{id}/rooms).
+func (c *Client) CreateRoom(ctx context.Context, id string, body CreateRoomRequest) (*Room, error) {
+	var out Room
+	if err := c.do(ctx, http.MethodPost, "/org/"+url.PathEscape(id)+"/rooms", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListArchivedRoomsParams are the query parameters of ListArchivedRooms
+type ListArchivedRoomsParams struct {
+	RoomType    string // Only rooms of this type
+	Name        string // Only rooms whose name starts with this prefix
+	IsTemporary *bool  // Only temporary or only permanent rooms
+	Expired     *bool  // Only rooms whose expiry has or has not passed
+	Limit       int    // Page size; 50 by default, at most 200
+	Sort        string // Field to sort by, prefixed with - for descending order
+	Cursor      string // next_cursor of the previous page; only valid with the same sort
+}
+
+// ListArchivedRooms: List the archived rooms of an organization (GET /org/{id}/rooms/archived).
+func (c *Client) ListArchivedRooms(ctx context.Context, id string, params *ListArchivedRoomsParams) (*RoomPage, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.RoomType != "" {
+			query.Set("room_type", params.RoomType)
+		}
+		if params.Name != "" {
+			query.Set("name", params.Name)
+		}
+		if params.IsTemporary != nil {
+			query.Set("is_temporary", strconv.FormatBool(*params.IsTemporary))
+		}
+		if params.Expired != nil {
+			query.Set("expired", strconv.FormatBool(*params.Expired))
+		}
+		if params.Limit != 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Sort != "" {
+			query.Set("sort", params.Sort)
+		}
+		if params.Cursor != "" {
+			query.Set("cursor", params.Cursor)
+		}
+	}
+	var out RoomPage
+	if err := c.do(ctx, http.MethodGet, "/org/"+url.PathEscape(id)+"/rooms/archived", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListWebhooks: List the webhook endpoints of an organization (GET /org/{id}/webhooks).
+func (c *Client) ListWebhooks(ctx context.Context, id string) ([]Webhook, error) {
+	var out []Webhook
+	if err := c.do(ctx, http.MethodGet, "/org/"+url.PathEscape(id)+"/webhooks", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateWebhook: Register a webhook endpoint (POST /org/{id}/webhooks).
+func (c *Client) CreateWebhook(ctx context.Context, id string, body CreateWebhookRequest) (*Webhook, error) {
+	var out Webhook
+	if err := c.do(ctx, http.MethodPost, "/org/"+url.PathEscape(id)+"/webhooks", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWebhook: Get a webhook endpoint (GET /org/{id}/webhooks/{webhookID}).
+func (c *Client) GetWebhook(ctx context.Context, id string, webhookID string) (*Webhook, error) {
+	var out Webhook
+	if err := c.do(ctx, http.MethodGet, "/org/"+url.PathEscape(id)+"/webhooks/"+url.PathEscape(webhookID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateWebhook: Update a webhook endpoint (PUT /org/{id}/webhooks/{webhookID}).
+func (c *Client) UpdateWebhook(ctx context.Context, id string, webhookID string, body UpdateWebhookRequest) (*Webhook, error) {
+	var out Webhook
+	if err := c.do(ctx, http.MethodPut, "/org/"+url.PathEscape(id)+"/webhooks/"+url.PathEscape(webhookID), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWebhook: Delete a webhook endpoint (DELETE /org/{id}/webhooks/{webhookID}).
+func (c *Client) DeleteWebhook(ctx context.Context, id string, webhookID string) error {
+	return c.do(ctx, http.MethodDelete, "/org/"+url.PathEscape(id)+"/webhooks/"+url.PathEscape(webhookID), nil, nil, nil)
+}
+
+// ListWebhookDeliveriesParams are the query parameters of ListWebhookDeliveries
+type ListWebhookDeliveriesParams struct {
+	Limit int // How many deliveries to return; 50 by default, at most 200
+}
+
+// ListWebhookDeliveries: List the latest deliveries to a webhook endpoint (GET /org/{id}/webhooks/{webhookID}/deliveries).
+func (c *Client) ListWebhookDeliveries(ctx context.Context, id string, webhookID string, params *ListWebhookDeliveriesParams) ([]Delivery, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Limit != 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+	}
+	var out []Delivery
+	if err := c.do(ctx, http.MethodGet, "/org/"+url.PathEscape(id)+"/webhooks/"+url.PathEscape(webhookID)+"/deliveries", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RedeliverWebhook: Deliver an event to a webhook endpoint again (POST /org/{id}/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver).
+func (c *Client) RedeliverWebhook(ctx context.Context, id string, webhookID string, deliveryID string) (*Delivery, error) {
+	var out Delivery
+	if err := c.do(ctx, http.MethodPost, "/org/"+url.PathEscape(id)+"/webhooks/"+url.PathEscape(webhookID)+"/deliveries/"+url.PathEscape(deliveryID)+"/redeliver", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListOrganizationsParams are the query parameters of ListOrganizations
+type ListOrganizationsParams struct {
+	Name     string // Only organizations whose name starts with this prefix
+	Deleting *bool  // Only organizations that are or are not being deleted
+	Limit    int    // Page size; 50 by default, at most 200
+	Sort     string // Field to sort by, prefixed with - for descending order
+	Cursor   string // next_cursor of the previous page; only valid with the same sort
+}
+
+// ListOrganizations: List organizations (GET /organizations).
+func (c *Client) ListOrganizations(ctx context.Context, params *ListOrganizationsParams) (*OrganizationPage, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Name != "" {
+			query.Set("name", params.Name)
+		}
+		if params.Deleting != nil {
+			query.Set("deleting", strconv.FormatBool(*params.Deleting))
+		}
+		if params.Limit != 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Sort != "" {
+			query.Set("sort", params.Sort)
+		}
+		if params.Cursor != "" {
+			query.Set("cursor", params.Cursor)
+		}
+	}
+	var out OrganizationPage
+	if err := c.do(ctx, http.MethodGet, "/organizations", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateOrganization: Create an organization (POST /organizations).
+func (c *Client) CreateOrganization(ctx context.Context, body CreateOrganizationRequest) (*Organization, error) {
+	var out Organization
+	if err := c.do(ctx, http.MethodPost, "/organizations", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOrganization: Get an organization (GET /organizations/{id}).
+func (c *Client) GetOrganization(ctx context.Context, id string) (*Organization, error) {
+	var out Organization
+	if err := c.do(ctx, http.MethodGet, "/organizations/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateOrganization: Update an organization (PUT /organizations/{id}).
+func (c *Client) UpdateOrganization(ctx context.Context, id string, body UpdateOrganizationRequest) (*Organization, error) {
+	var out Organization
+	if err := c.do(ctx, http.MethodPut, "/organizations/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteOrganization: Request the deletion of an organization (DELETE /organizations/{id}).
+func (c *Client) DeleteOrganization(ctx context.Context, id string) (*JobRun, error) {
+	var out JobRun
+	if err := c.do(ctx, http.MethodDelete, "/organizations/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOrganizationDeletion: Get the status of an organization's deletion (GET /organizations/{id}/deletion).
+func (c *Client) GetOrganizationDeletion(ctx context.Context, id string) (*JobRun, error) {
+	var out JobRun
+	if err := c.do(ctx, http.MethodGet, "/organizations/"+url.PathEscape(id)+"/deletion", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResolveRoomSlug: Resolve a room's vanity link (GET /r/{org}/{slug}).
+func (c *Client) ResolveRoomSlug(ctx context.Context, org string, slug string) (*Room, error) {
+	var out Room
+	if err := c.do(ctx, http.MethodGet, "/r/"+url.PathEscape(org)+"/"+url.PathEscape(slug), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRoom: Get a room (GET /rooms/id/{id}).
+func (c *Client) GetRoom(ctx context.Context, id string) (*Room, error) {
+	var out Room
+	if err := c.do(ctx, http.MethodGet, "/rooms/id/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateRoom: Change the settings of an active room (PATCH /rooms/id/{id}).
+func (c *Client) UpdateRoom(ctx context.Context, id string, body UpdateRoomRequest) (*Room, error) {
+	var out Room
+	if err := c.do(ctx, http.MethodPatch, "/rooms/id/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteRoom: Permanently delete a room (DELETE /rooms/id/{id}).
+func (c *Client) DeleteRoom(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/rooms/id/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// ListRoomParticipantsParams are the query parameters of ListRoomParticipants
+type ListRoomParticipantsParams struct {
+	Role   string // Only participants with this role
+	Active *bool  // Only participants who are or are not still in the room
+	Limit  int    // Page size; 50 by default, at most 200
+	Sort   string // Field to sort by, prefixed with - for descending order
+	Cursor string // next_cursor of the previous page; only valid with the same sort
+}
+
+// ListRoomParticipants: List the participants of a room (GET /rooms/id/{id}/participants).
+func (c *Client) ListRoomParticipants(ctx context.Context, id string, params *ListRoomParticipantsParams) (*ParticipantPage, error) {
+	query := url.Values{}
+	if params != nil {
+		if params.Role != "" {
+			query.Set("role", params.Role)
+		}
+		if params.Active != nil {
+			query.Set("active", strconv.FormatBool(*params.Active))
+		}
+		if params.Limit != 0 {
+			query.Set("limit", strconv.Itoa(params.Limit))
+		}
+		if params.Sort != "" {
+			query.Set("sort", params.Sort)
+		}
+		if params.Cursor != "" {
+			query.Set("cursor", params.Cursor)
+		}
+	}
+	var out ParticipantPage
+	if err := c.do(ctx, http.MethodGet, "/rooms/id/"+url.PathEscape(id)+"/participants", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRoomPresence: Get the users connected to a room (GET /rooms/id/{id}/presence).
+func (c *Client) GetRoomPresence(ctx context.Context, id string) (*Presence, error) {
+	var out Presence
+	if err := c.do(ctx, http.MethodGet, "/rooms/id/"+url.PathEscape(id)+"/presence", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRoomByShortCode: Get a room by its short code (GET /rooms/{shortCode}).
+func (c *Client) GetRoomByShortCode(ctx context.Context, shortCode string) (*Room, error) {
+	var out Room
+	if err := c.do(ctx, http.MethodGet, "/rooms/"+url.PathEscape(shortCode), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateToken: Issue a token to join a room's call (POST /tokens).
+func (c *Client) CreateToken(ctx context.Context, body GenerateTokenRequest) (*Token, error) {
+	var out Token
+	if err := c.do(ctx, http.MethodPost, "/tokens", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
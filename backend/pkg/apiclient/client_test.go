@@ -0,0 +1,66 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientSendsRequestAndDecodesProblem(t *testing.T) {
+	var got struct {
+		method, path, query, auth, key string
+		body                           CreateRoomRequest
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.method, got.path, got.query = r.Method, r.URL.Path, r.URL.RawQuery
+		got.auth, got.key = r.Header.Get("Authorization"), r.Header.Get("Idempotency-Key")
+		_ = json.NewDecoder(r.Body).Decode(&got.body)
+
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"type":"urn:nonza:problem:organization_not_found","title":"Not Found","status":404,"code":"organization_not_found","detail":"organization not found"}`))
+	}))
+	defer server.Close()
+
+	client := New(server.URL+"/", WithAdminToken("secret"))
+	ctx := WithIdempotencyKey(context.Background(), "retry-1")
+	_, err := client.CreateRoom(ctx, "org 1", CreateRoomRequest{Name: "standup", RoomType: "round_table"})
+
+	if got.method != http.MethodPost || got.path != "/api/v1/org/org 1/rooms" {
+		t.Errorf("sent %s %s, want POST /api/v1/org/org 1/rooms", got.method, got.path)
+	}
+	if got.body.Name != "standup" || got.auth != "Bearer secret" || got.key != "retry-1" {
+		t.Errorf("sent body %+v with authorization %q and idempotency key %q", got.body, got.auth, got.key)
+	}
+
+	var problem *Problem
+	if !errors.As(err, &problem) {
+		t.Fatalf("error %v, want a *Problem", err)
+	}
+	if problem.Code != "organization_not_found" || problem.Status != http.StatusNotFound {
+		t.Errorf("problem %+v, want organization_not_found with status 404", problem)
+	}
+}
+
+func TestClientEncodesQuery(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"items":[],"next_cursor":""}`))
+	}))
+	defer server.Close()
+
+	page, err := New(server.URL).ListRooms(context.Background(), "org", &ListRoomsParams{IsTemporary: Ptr(false), Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "is_temporary=false&limit=10"; query != want {
+		t.Errorf("query %q, want %q", query, want)
+	}
+	if page.Items == nil || page.NextCursor != "" {
+		t.Errorf("page %+v, want an empty last page", page)
+	}
+}
@@ -0,0 +1,25 @@
+package room
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed blocklist.txt
+var blocklistFile string
+
+// blocklist holds offensive words, English and transliterated Russian, that no short code may
+// contain
+var blocklist = strings.Fields(blocklistFile)
+
+// ContainsBlockedWord reports whether a code, read with its hyphens removed, contains an
+// offensive word. Random codes spell one by chance now and then; they are drawn again.
+func ContainsBlockedWord(code string) bool {
+	s := strings.ReplaceAll(strings.ToLower(code), "-", "")
+	for _, word := range blocklist {
+		if strings.Contains(s, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,33 +2,171 @@ package room
 
 import (
 	"crypto/rand"
+	_ "embed"
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
+	"regexp"
+	"strings"
 )
 
+// Short code defaults: ten lowercase letters in groups of 3, 4 and 3, e.g. abc-defg-hij
 const (
-	codeLength1 = 3
-	codeLength2 = 4
-	codeLength3 = 3
-	letters     = "abcdefghijklmnopqrstuvwxyz"
+	DefaultAlphabet  = "abcdefghijklmnopqrstuvwxyz"
+	DefaultLength    = 10
+	DefaultWordCount = 3
+
+	MinCodeLength = 4
+	MaxCodeLength = 20
+	MinWordCount  = 2
+	MaxWordCount  = 5
+	MaxWordLength = 12
+
+	// maxGroupLength is the longest run of random characters between hyphens
+	maxGroupLength = 4
+	// minCodeSpace is the fewest distinct codes a format may have, so that codes stay hard to
+	// guess and collisions rare
+	minCodeSpace = 1 << 20
+)
+
+var (
+	ErrAlphabet     = errors.New("alphabet must be at least 2 distinct lowercase latin letters or digits")
+	ErrCodeLength   = fmt.Errorf("length must be %d to %d", MinCodeLength, MaxCodeLength)
+	ErrWordCount    = fmt.Errorf("word count must be %d to %d", MinWordCount, MaxWordCount)
+	ErrWord         = fmt.Errorf("words must be 2 to %d lowercase latin letters", MaxWordLength)
+	ErrBlockedWord  = errors.New("word list contains a blocked word")
+	ErrCodeSpace    = fmt.Errorf("format allows fewer than %d distinct codes", minCodeSpace)
+	wordPattern     = regexp.MustCompile(`^[a-z]{2,` + fmt.Sprint(MaxWordLength) + `}$`)
+	alphabetPattern = regexp.MustCompile(`^[a-z0-9]+$`)
 )
 
-func GenerateShortCode() string {
-	part1 := generateRandomLetters(codeLength1)
-	part2 := generateRandomLetters(codeLength2)
-	part3 := generateRandomLetters(codeLength3)
+//go:embed words.txt
+var defaultWordList string
+
+// DefaultWords is the built-in word list of word codes: short, common and unambiguous English words
+var DefaultWords = strings.Fields(defaultWordList)
+
+// DefaultCodes generates codes in the default format
+var DefaultCodes CodeGenerator = &RandomCodes{alphabet: DefaultAlphabet, length: DefaultLength}
+
+// CodeGenerator makes candidate short codes for rooms. Codes are drawn at random, so two rooms
+// can be given the same one: callers insert the room and draw again if the code is taken.
+type CodeGenerator interface {
+	Generate() (string, error)
+}
 
-	return fmt.Sprintf("%s-%s-%s", part1, part2, part3)
+// RandomCodes draws each character of a code from an alphabet, splitting the code into
+// hyphenated groups of up to four characters
+type RandomCodes struct {
+	alphabet string
+	length   int
 }
 
-func generateRandomLetters(length int) string {
-	result := make([]byte, length)
-	max := big.NewInt(int64(len(letters)))
+// NewRandomCodes returns a generator of length characters from alphabet
+func NewRandomCodes(alphabet string, length int) (*RandomCodes, error) {
+	if len(alphabet) < 2 || !alphabetPattern.MatchString(alphabet) || hasRepeats(alphabet) {
+		return nil, ErrAlphabet
+	}
+	if length < MinCodeLength || length > MaxCodeLength {
+		return nil, ErrCodeLength
+	}
+	if math.Pow(float64(len(alphabet)), float64(length)) < minCodeSpace {
+		return nil, ErrCodeSpace
+	}
+	return &RandomCodes{alphabet: alphabet, length: length}, nil
+}
 
-	for i := range result {
-		n, _ := rand.Int(rand.Reader, max)
-		result[i] = letters[n.Int64()]
+func (g *RandomCodes) Generate() (string, error) {
+	groups := groupLengths(g.length)
+	parts := make([]string, len(groups))
+	for i, n := range groups {
+		part := make([]byte, n)
+		for j := range part {
+			k, err := randomIndex(len(g.alphabet))
+			if err != nil {
+				return "", err
+			}
+			part[j] = g.alphabet[k]
+		}
+		parts[i] = string(part)
 	}
+	return strings.Join(parts, "-"), nil
+}
 
-	return string(result)
+// groupLengths splits a code into as few groups of at most maxGroupLength as it takes, as
+// even as possible with the longer ones in the middle: 10 becomes 3-4-3
+func groupLengths(length int) []int {
+	count := (length + maxGroupLength - 1) / maxGroupLength
+	groups := make([]int, count)
+	for i := range groups {
+		groups[i] = length / count
+	}
+	extra := length % count
+	for i := 0; i < extra; i++ {
+		groups[(count-extra)/2+i]++
+	}
+	return groups
+}
+
+// WordCodes joins words drawn from a list with hyphens, e.g. amber-fox-river
+type WordCodes struct {
+	words []string
+	count int
+}
+
+// NewWordCodes returns a generator of count words from the list. Duplicates in the list are
+// dropped; blocked words are refused rather than silently dropped.
+func NewWordCodes(words []string, count int) (*WordCodes, error) {
+	if count < MinWordCount || count > MaxWordCount {
+		return nil, ErrWordCount
+	}
+	seen := make(map[string]bool, len(words))
+	unique := make([]string, 0, len(words))
+	for _, w := range words {
+		if !wordPattern.MatchString(w) {
+			return nil, ErrWord
+		}
+		if ContainsBlockedWord(w) {
+			return nil, ErrBlockedWord
+		}
+		if !seen[w] {
+			seen[w] = true
+			unique = append(unique, w)
+		}
+	}
+	if math.Pow(float64(len(unique)), float64(count)) < minCodeSpace {
+		return nil, ErrCodeSpace
+	}
+	return &WordCodes{words: unique, count: count}, nil
+}
+
+func (g *WordCodes) Generate() (string, error) {
+	parts := make([]string, g.count)
+	for i := range parts {
+		k, err := randomIndex(len(g.words))
+		if err != nil {
+			return "", err
+		}
+		parts[i] = g.words[k]
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// randomIndex returns a uniformly random index below n from the system's secure source
+func randomIndex(n int) (int, error) {
+	k, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("generate short code: %w", err)
+	}
+	return int(k.Int64()), nil
+}
+
+func hasRepeats(s string) bool {
+	for i := range s {
+		if strings.IndexByte(s[i+1:], s[i]) >= 0 {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,78 @@
+package room
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestDefaultCodesKeepTheirFormat(t *testing.T) {
+	format := regexp.MustCompile(`^[a-z]{3}-[a-z]{4}-[a-z]{3}$`)
+	for range 100 {
+		code, err := DefaultCodes.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !format.MatchString(code) {
+			t.Fatalf("code %q is not xxx-xxxx-xxx", code)
+		}
+	}
+}
+
+func TestGroupLengths(t *testing.T) {
+	tests := map[int][]int{4: {4}, 6: {3, 3}, 7: {4, 3}, 10: {3, 4, 3}, 11: {4, 4, 3}, 20: {4, 4, 4, 4, 4}}
+	for length, want := range tests {
+		if got := groupLengths(length); !slices.Equal(got, want) {
+			t.Errorf("groupLengths(%d) = %v, want %v", length, got, want)
+		}
+	}
+}
+
+func TestNewRandomCodesRejects(t *testing.T) {
+	tests := []struct {
+		alphabet string
+		length   int
+		want     error
+	}{
+		{"abcA", 10, ErrAlphabet},
+		{"aab", 10, ErrAlphabet},
+		{DefaultAlphabet, 3, ErrCodeLength},
+		{"0123456789", 5, ErrCodeSpace},
+	}
+	for _, tt := range tests {
+		if _, err := NewRandomCodes(tt.alphabet, tt.length); !errors.Is(err, tt.want) {
+			t.Errorf("NewRandomCodes(%q, %d) = %v, want %v", tt.alphabet, tt.length, err, tt.want)
+		}
+	}
+}
+
+func TestWordCodes(t *testing.T) {
+	g, err := NewWordCodes(DefaultWords, DefaultWordCount)
+	if err != nil {
+		t.Fatalf("default word list: %v", err)
+	}
+	code, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[a-z]+-[a-z]+-[a-z]+$`).MatchString(code) {
+		t.Errorf("code %q is not three words", code)
+	}
+
+	if _, err := NewWordCodes([]string{"amber", "fuck"}, 2); !errors.Is(err, ErrBlockedWord) {
+		t.Errorf("a blocked word was accepted: %v", err)
+	}
+	if _, err := NewWordCodes([]string{"amber", "amber", "fox"}, 5); !errors.Is(err, ErrCodeSpace) {
+		t.Errorf("a tiny word list was accepted: %v", err)
+	}
+}
+
+func TestContainsBlockedWord(t *testing.T) {
+	if !ContainsBlockedWord("xsh-itqa-bcd") {
+		t.Error("a word split by a hyphen was not caught")
+	}
+	if ContainsBlockedWord("amber-fox-river") {
+		t.Error("an innocent code was blocked")
+	}
+}
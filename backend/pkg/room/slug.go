@@ -0,0 +1,99 @@
+package room
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	MinSlugLength = 2
+	MaxSlugLength = 64
+)
+
+var (
+	ErrSlugLength   = errors.New("slug must be 2 to 64 characters")
+	ErrSlugFormat   = errors.New("slug may only contain lowercase latin letters, digits and single hyphens between them")
+	ErrSlugReserved = errors.New("slug is reserved")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// uuidPattern matches what the resolver would take for an ID rather than a slug
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// reservedSlugs would clash with routes, or read like something other than a name
+var reservedSlugs = map[string]bool{
+	"admin": true, "api": true, "app": true, "archived": true, "create": true, "delete": true,
+	"edit": true, "events": true, "health": true, "help": true, "id": true, "join": true,
+	"login": true, "logout": true, "metrics": true, "new": true, "nonza": true, "null": true,
+	"org": true, "organizations": true, "participants": true, "presence": true, "r": true,
+	"room": true, "rooms": true, "settings": true, "static": true, "support": true,
+	"tokens": true, "undefined": true, "webhooks": true, "ws": true,
+}
+
+// cyrillic spells Russian, Ukrainian and Belarusian letters in latin, close to the passport
+// transliteration people already recognise
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// ValidateSlug checks a slug given by a user. Slugs are not normalized: "Standup" is rejected
+// rather than silently becoming "standup".
+func ValidateSlug(slug string) error {
+	if len(slug) < MinSlugLength || len(slug) > MaxSlugLength {
+		return ErrSlugLength
+	}
+	if !slugPattern.MatchString(slug) {
+		return ErrSlugFormat
+	}
+	if reservedSlugs[slug] || uuidPattern.MatchString(slug) {
+		return ErrSlugReserved
+	}
+	return nil
+}
+
+// Slugify derives a slug from a name: lowercased, Cyrillic transliterated, anything else that
+// is not a latin letter or digit collapsed into hyphens, e.g. "Планёрка отдела #3" becomes
+// "planerka-otdela-3". Long names are cut at a word boundary. The result may still fail
+// ValidateSlug, e.g. when the name has no letters.
+func Slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		var part string
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			part = string(r)
+		case cyrillic[r] != "":
+			part = cyrillic[r]
+		case r == 'ъ' || r == 'ь':
+			continue // silent signs
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			continue // other scripts can't be spelled in latin here; drop them
+		default:
+			hyphen = b.Len() > 0
+			continue
+		}
+		if hyphen {
+			part = "-" + part
+			hyphen = false
+		}
+		if b.Len()+len(part) > MaxSlugLength {
+			// Cut a long name at the end of a word rather than in the middle of one
+			if !strings.HasPrefix(part, "-") {
+				if i := strings.LastIndexByte(b.String(), '-'); i > 0 {
+					return b.String()[:i]
+				}
+			}
+			break
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package room
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Weekly Standup":          "weekly-standup",
+		"Планёрка отдела #3":      "planerka-otdela-3",
+		"  Щука & Подъезд  ":      "shchuka-podezd",
+		"Їжак та Ґанок":           "yizhak-ta-ganok",
+		"Q3 — итоги":              "q3-itogi",
+		"会议":                      "",
+		strings.Repeat("ab ", 40): strings.TrimSuffix(strings.Repeat("ab-", 21), "-"),
+	}
+	for name, want := range tests {
+		if got := Slugify(name); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestValidateSlug(t *testing.T) {
+	tests := map[string]error{
+		"weekly-standup":                       nil,
+		"q3":                                   nil,
+		"a":                                    ErrSlugLength,
+		"Weekly":                               ErrSlugFormat,
+		"weekly--sync":                         ErrSlugFormat,
+		"-weekly":                              ErrSlugFormat,
+		"планёрка":                             ErrSlugFormat,
+		"admin":                                ErrSlugReserved,
+		"3f2504e0-4f89-11d3-9a0c-0305e82c3301": ErrSlugReserved,
+	}
+	for slug, want := range tests {
+		if err := ValidateSlug(slug); !errors.Is(err, want) {
+			t.Errorf("ValidateSlug(%q) = %v, want %v", slug, err, want)
+		}
+	}
+}
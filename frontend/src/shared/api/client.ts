@@ -33,10 +33,11 @@ export class ApiClient {
       clearTimeout(timeoutId);
 
       if (!response.ok) {
-        const error = await response
+        // Errors are RFC 7807 problem documents: { code, detail, errors, ... }
+        const problem = await response
           .json()
-          .catch(() => ({ error: response.statusText }));
-        throw new Error(error.error || `HTTP ${response.status}`);
+          .catch(() => ({ detail: response.statusText }));
+        throw new Error(problem.detail || `HTTP ${response.status}`);
       }
 
       return await response.json();
@@ -1,3 +1,6 @@
+// Websocket protocol version spoken by this client (Sec-WebSocket-Protocol)
+export const WS_SUBPROTOCOL = "nonza.v1";
+
 export interface WebSocketMessage {
   type: string;
   room_id?: string;
@@ -39,7 +42,7 @@ export class WebSocketClient {
 
     return new Promise((resolve, reject) => {
       try {
-        this.ws = new WebSocket(this.url);
+        this.ws = new WebSocket(this.url, [WS_SUBPROTOCOL]);
 
         this.ws.onopen = () => {
           this.isConnecting = false;
@@ -3,11 +3,13 @@ import {
   applyAwarenessUpdate,
   encodeAwarenessUpdate,
 } from "y-protocols/awareness";
+import { WS_SUBPROTOCOL } from "./websocket-client";
 
 export interface YjsWebSocketProviderOptions {
   url: string;
   roomId: string;
   userId: string;
+  displayName?: string; // Shown to others in the room's presence roster
   doc: Y.Doc;
   awareness?: any; // Y.js awareness object
 }
@@ -21,9 +23,14 @@ export class YjsWebSocketProvider {
   private url: string;
   private roomId: string;
   private userId: string;
+  private displayName?: string;
   private doc: Y.Doc;
   public awareness: any; // Public so CollaborationCaret can access it
   private synced = false;
+  // Sequence number of the last document update received in order; sent on reconnect to resume
+  private lastSeq = 0;
+  // Set while a resume is in flight, so a gap doesn't trigger another one
+  private resuming = false;
   private reconnectAttempts = 0;
   private maxReconnectAttempts = 5;
   private reconnectDelay = 1000;
@@ -47,6 +54,7 @@ export class YjsWebSocketProvider {
     this.url = options.url;
     this.roomId = options.roomId;
     this.userId = options.userId;
+    this.displayName = options.displayName;
     this.doc = options.doc;
     this.awareness = options.awareness;
 
@@ -167,8 +175,16 @@ export class YjsWebSocketProvider {
     this.emitStatus("connecting");
 
     try {
-      const wsUrl = `${this.url}/ws?room_id=${encodeURIComponent(this.roomId)}&user_id=${encodeURIComponent(this.userId)}`;
-      this.ws = new WebSocket(wsUrl);
+      let wsUrl = `${this.url}/ws?room_id=${encodeURIComponent(this.roomId)}&user_id=${encodeURIComponent(this.userId)}`;
+      if (this.displayName) {
+        wsUrl += `&name=${encodeURIComponent(this.displayName)}`;
+      }
+      // Reconnecting: the server replays the updates we missed instead of sending the whole document
+      this.resuming = this.lastSeq > 0;
+      if (this.resuming) {
+        wsUrl += `&last_seq=${this.lastSeq}`;
+      }
+      this.ws = new WebSocket(wsUrl, [WS_SUBPROTOCOL]);
 
       this.ws.binaryType = "arraybuffer";
 
@@ -177,7 +193,9 @@ export class YjsWebSocketProvider {
         this.emitStatus("connected");
 
         // Send initial sync message
-        this.sendSync();
+        if (!this.resuming) {
+          this.sendSync();
+        }
       };
 
       this.ws.onmessage = (event: MessageEvent) => {
@@ -224,7 +242,14 @@ export class YjsWebSocketProvider {
         this.emitStatus("disconnected");
 
         // Attempt to reconnect only if it wasn't a clean close or if it was a server error
-        if (!event.wasClean || event.code === 1006) {
+        // 1012: server restart, 4008: disconnected as a slow consumer, 4029: rate limited (backs off)
+        if (
+          !event.wasClean ||
+          event.code === 1006 ||
+          event.code === 1012 ||
+          event.code === 4008 ||
+          event.code === 4029
+        ) {
           this.attemptReconnect();
         }
       };
@@ -312,9 +337,13 @@ export class YjsWebSocketProvider {
   private processJsonMessage(message: Record<string, unknown>): void {
     if (message.type === "yjs_update") {
       // Decode base64 update
-      if (message.update && typeof message.update === "string") {
+      const payload = message.payload as
+        | { update?: string; seq?: number }
+        | undefined;
+      const encoded = payload?.update ?? message.update;
+      if (encoded && typeof encoded === "string") {
         try {
-          const binaryString = atob(message.update);
+          const binaryString = atob(encoded);
           const update = new Uint8Array(binaryString.length);
           for (let i = 0; i < binaryString.length; i++) {
             update[i] = binaryString.charCodeAt(i);
@@ -322,6 +351,9 @@ export class YjsWebSocketProvider {
 
           // Apply update to document (with origin to prevent echo)
           Y.applyUpdate(this.doc, update, this);
+          if (typeof payload?.seq === "number") {
+            this.observeSeq(payload.seq);
+          }
           if (!this.synced) {
             this.synced = true;
             this.emitSynced();
@@ -340,15 +372,55 @@ export class YjsWebSocketProvider {
         "[YjsWebSocketProvider] Received yjs_awareness as JSON (should be binary)",
       );
     } else if (message.type === "connected") {
-      this.sendSync();
-    } else if (message.type === "yjs_sync_ack") {
+      if (!this.resuming) {
+        this.sendSync();
+      }
+    } else if (message.type === "yjs_update_ack") {
+      // Our own update got a sequence number; count it so it isn't mistaken for a gap
+      const payload = message.payload as { seq?: number } | undefined;
+      if (typeof payload?.seq === "number") {
+        this.observeSeq(payload.seq);
+      }
+    } else if (
+      message.type === "yjs_sync_ack" ||
+      message.type === "resume_ack"
+    ) {
+      const payload = message.payload as
+        | { synced?: boolean; seq?: number; full_state?: boolean }
+        | undefined;
+      if (message.type === "resume_ack") {
+        this.resuming = false;
+      }
+      if (typeof payload?.seq === "number") {
+        this.lastSeq = Math.max(this.lastSeq, payload.seq);
+      }
       // If server had no state (first participant), push our full state so server has it for new joiners
-      const payload = message.payload as { synced?: boolean } | undefined;
       if (payload?.synced === false) {
         window.setTimeout(() => this.sendFullState(), 500);
       }
-    } else if (message.type === "user_joined") {
+      if (message.type === "resume_ack" && !this.synced) {
+        this.synced = true;
+        this.emitSynced();
+      }
+    } else if (message.type === "presence_diff") {
+      // Someone new joined: make sure the server has our state for them
+      if (message.payload?.joined?.length) {
+        this.sendFullState();
+      }
+    } else if (message.type === "resync_required") {
+      // Server dropped updates queued for us: fetch what we missed
+      if (this.lastSeq > 0) {
+        this.sendResume();
+      } else {
+        this.sendSync();
+      }
+    } else if (message.type === "full_state_request") {
       this.sendFullState();
+    } else if (message.type === "error") {
+      console.warn(
+        "[YjsWebSocketProvider] Server rejected message:",
+        message.payload,
+      );
     }
   }
 
@@ -463,6 +535,32 @@ export class YjsWebSocketProvider {
     }
   }
 
+  // Tracks document update sequence numbers; a gap means updates were missed and must be replayed
+  private observeSeq(seq: number): void {
+    if (seq <= this.lastSeq) {
+      return;
+    }
+    if (this.lastSeq === 0 || seq === this.lastSeq + 1) {
+      this.lastSeq = seq;
+      return;
+    }
+    this.sendResume();
+  }
+
+  private sendResume(): void {
+    if (this.resuming || !this.ws || this.ws.readyState !== WebSocket.OPEN) {
+      return;
+    }
+    this.resuming = true;
+    this.ws.send(
+      JSON.stringify({
+        type: "resume",
+        room_id: this.roomId,
+        payload: { last_seq: this.lastSeq },
+      }),
+    );
+  }
+
   private sendSync(): void {
     if (!this.ws || this.ws.readyState !== WebSocket.OPEN) {
       return;
@@ -491,7 +589,8 @@ export class YjsWebSocketProvider {
       const message = {
         type: "yjs_full_state",
         room_id: this.roomId,
-        payload: { update: base64 },
+        // The state includes every update up to lastSeq, so the server replays only later ones with it
+        payload: { update: base64, seq: this.lastSeq },
       };
       this.ws.send(JSON.stringify(message));
     } catch (error) {